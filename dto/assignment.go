@@ -0,0 +1,73 @@
+package dto
+
+import "time"
+
+type CreateAssignment struct {
+	Title       string            `json:"title" binding:"required,min=3,max=100"`
+	Description string            `json:"description" binding:"max=10000"`
+	DueAt       *time.Time        `json:"dueAt"`
+	MaxScore    float64           `json:"maxScore" binding:"min=0,max=1000"`
+	Rubric      []RubricCriterion `json:"rubric" binding:"max=20,dive"`
+	Published   bool              `json:"published"`
+}
+
+type UpdateAssignment struct {
+	Title       string            `json:"title" binding:"required,min=3,max=100"`
+	Description string            `json:"description" binding:"max=10000"`
+	DueAt       *time.Time        `json:"dueAt"`
+	MaxScore    float64           `json:"maxScore" binding:"min=0,max=1000"`
+	Rubric      []RubricCriterion `json:"rubric" binding:"max=20,dive"`
+	Published   bool              `json:"published"`
+}
+
+type RubricCriterion struct {
+	Title       string  `json:"title" binding:"required,max=100"`
+	Description string  `json:"description" binding:"max=1000"`
+	Points      float64 `json:"points" binding:"min=0,max=1000"`
+}
+
+// GradeSubmission grades either with a score, or with one score per rubric criterion that add up to it.
+type GradeSubmission struct {
+	Score        *float64  `json:"score"`
+	RubricScores []float64 `json:"rubricScores"`
+	Feedback     string    `json:"feedback" binding:"max=10000"`
+}
+
+// Upload is a file sent along with a request.
+type Upload struct {
+	Name string
+	Data []byte
+}
+
+type Gradebook struct {
+	Assignments []GradebookAssignment `json:"assignments"`
+	Rows        []GradebookRow        `json:"rows"`
+}
+
+type GradebookAssignment struct {
+	ID       string     `json:"id"`
+	Title    string     `json:"title"`
+	DueAt    *time.Time `json:"dueAt"`
+	MaxScore float64    `json:"maxScore"`
+}
+
+type GradebookRow struct {
+	UserID    string           `json:"userId"`
+	FirstName string           `json:"firstName"`
+	LastName  string           `json:"lastName"`
+	Email     string           `json:"email"`
+	Grades    []GradebookGrade `json:"grades"`
+	Score     float64          `json:"score"`
+	MaxScore  float64          `json:"maxScore"`
+	Percent   float64          `json:"percent"`
+}
+
+// GradebookGrade is the latest grade of a user for an assignment, Status is submitted
+// when the latest version still waits to be graded.
+type GradebookGrade struct {
+	AssignmentID string   `json:"assignmentId"`
+	Status       string   `json:"status"`
+	Score        *float64 `json:"score"`
+	Late         bool     `json:"late"`
+	Version      int      `json:"version"`
+}
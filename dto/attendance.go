@@ -0,0 +1,46 @@
+package dto
+
+import "time"
+
+type CheckIn struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type MarkAttendance struct {
+	OccurrenceAt time.Time `json:"occurrenceAt" binding:"required"`
+	Status       string    `json:"status" binding:"required,oneof=present absent excused"`
+}
+
+type AttendanceQuery struct {
+	OccurrenceAt time.Time `form:"occurrenceAt" time_format:"2006-01-02T15:04:05Z07:00" binding:"required"`
+}
+
+// CheckInCode is shown by instructors during a session, QrPayload encodes the same code for scanning.
+type CheckInCode struct {
+	Code         string    `json:"code"`
+	QrPayload    string    `json:"qrPayload"`
+	OccurrenceAt time.Time `json:"occurrenceAt"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+// AttendanceRecord is the attendance of a user for one past occurrence, Status is empty when it wasn't marked.
+type AttendanceRecord struct {
+	SessionID    string    `json:"sessionId"`
+	Title        string    `json:"title"`
+	OccurrenceAt time.Time `json:"occurrenceAt"`
+	Status       string    `json:"status"`
+	Method       string    `json:"method"`
+}
+
+type AttendanceSummary struct {
+	UserID    string  `json:"userId"`
+	FirstName string  `json:"firstName"`
+	LastName  string  `json:"lastName"`
+	Email     string  `json:"email"`
+	Role      string  `json:"role"`
+	Present   int     `json:"present"`
+	Absent    int     `json:"absent"`
+	Excused   int     `json:"excused"`
+	Unmarked  int     `json:"unmarked"`
+	Rate      float64 `json:"rate"`
+}
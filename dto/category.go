@@ -0,0 +1,15 @@
+package dto
+
+type CreateCategory struct {
+	Name     string  `json:"name" binding:"required,min=2,max=50"`
+	Slug     string  `json:"slug" binding:"omitempty,max=50"`
+	Icon     string  `json:"icon"`
+	ParentID *string `json:"parentId"`
+}
+
+type UpdateCategory struct {
+	Name     string  `json:"name" binding:"required,min=2,max=50"`
+	Slug     string  `json:"slug" binding:"omitempty,max=50"`
+	Icon     string  `json:"icon"`
+	ParentID *string `json:"parentId"`
+}
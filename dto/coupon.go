@@ -0,0 +1,31 @@
+package dto
+
+import "time"
+
+type CreateCoupon struct {
+	Code           string     `json:"code" binding:"required,alphanum,min=3,max=32"`
+	Type           string     `json:"type" binding:"required,oneof=percent fixed"`
+	Value          int        `json:"value" binding:"required,min=1"`
+	TrainingID     *string    `json:"trainingId"`
+	CategoryID     *string    `json:"categoryId"`
+	StartsAt       *time.Time `json:"startsAt"`
+	EndsAt         *time.Time `json:"endsAt"`
+	MaxUses        int        `json:"maxUses" binding:"min=0"`
+	MaxUsesPerUser int        `json:"maxUsesPerUser" binding:"min=0"`
+}
+
+type UpdateCoupon struct {
+	Code           string     `json:"code" binding:"required,alphanum,min=3,max=32"`
+	Type           string     `json:"type" binding:"required,oneof=percent fixed"`
+	Value          int        `json:"value" binding:"required,min=1"`
+	TrainingID     *string    `json:"trainingId"`
+	CategoryID     *string    `json:"categoryId"`
+	StartsAt       *time.Time `json:"startsAt"`
+	EndsAt         *time.Time `json:"endsAt"`
+	MaxUses        int        `json:"maxUses" binding:"min=0"`
+	MaxUsesPerUser int        `json:"maxUsesPerUser" binding:"min=0"`
+}
+
+type Checkout struct {
+	Coupon string `json:"coupon"`
+}
@@ -0,0 +1,13 @@
+package dto
+
+type CreateEnrollment struct {
+	Message string `json:"message" binding:"max=500"`
+}
+
+type RejectEnrollment struct {
+	Reason string `json:"reason" binding:"required,min=1,max=500"`
+}
+
+type EnrollmentQueryParams struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending approved rejected withdrawn"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+import "time"
+
+type CreateInvite struct {
+	Role      string     `json:"role" binding:"omitempty,oneof=co-owner instructor assistant student"`
+	MaxUses   int        `json:"maxUses" binding:"min=0"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type EmailInvites struct {
+	Role      string     `json:"role" binding:"omitempty,oneof=co-owner instructor assistant student"`
+	Emails    []string   `json:"emails" binding:"required,min=1,max=100,dive,email"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
@@ -0,0 +1,34 @@
+package dto
+
+type LeaderboardQuery struct {
+	Period string `form:"period" binding:"omitempty,oneof=all week month"`
+	Size   int    `form:"size" binding:"omitempty,min=1,max=100"`
+	Around int    `form:"around" binding:"omitempty,min=1,max=10"`
+}
+
+type LeaderboardEntry struct {
+	Rank      int64  `json:"rank"`
+	UserID    string `json:"userId"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Points    int    `json:"points"`
+}
+
+// Leaderboard has the top of the board and, unless the caller opted out, their rank and the users ranked around them.
+type Leaderboard struct {
+	Period string             `json:"period"`
+	Total  int64              `json:"total"`
+	Top    []LeaderboardEntry `json:"top"`
+	Me     *LeaderboardEntry  `json:"me"`
+	Nearby []LeaderboardEntry `json:"nearby"`
+}
+
+type LeaderboardPrivacy struct {
+	Hidden bool `json:"hidden"`
+}
+
+type PointsScore struct {
+	UserID     string
+	TrainingID *string
+	Points     int
+}
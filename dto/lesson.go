@@ -0,0 +1,42 @@
+package dto
+
+import "time"
+
+type CreateModule struct {
+	Title       string `json:"title" binding:"required,min=3,max=100"`
+	Description string `json:"description" binding:"max=1000"`
+}
+
+type UpdateModule struct {
+	Title       string `json:"title" binding:"required,min=3,max=100"`
+	Description string `json:"description" binding:"max=1000"`
+}
+
+type CreateLesson struct {
+	Title            string     `json:"title" binding:"required,min=3,max=100"`
+	Body             string     `json:"body" binding:"max=50000"`
+	Duration         int        `json:"duration" binding:"min=0"`
+	Published        bool       `json:"published"`
+	ReleaseAt        *time.Time `json:"releaseAt"`
+	RequiresPrevious bool       `json:"requiresPrevious"`
+}
+
+type UpdateLesson struct {
+	Title            string     `json:"title" binding:"required,min=3,max=100"`
+	Body             string     `json:"body" binding:"max=50000"`
+	Duration         int        `json:"duration" binding:"min=0"`
+	Published        bool       `json:"published"`
+	ReleaseAt        *time.Time `json:"releaseAt"`
+	RequiresPrevious bool       `json:"requiresPrevious"`
+}
+
+// ReorderCurriculum is the whole curriculum of a training in its new order,
+// lessons can be moved between modules by listing them under another module.
+type ReorderCurriculum struct {
+	Modules []ModuleOrder `json:"modules" binding:"required,dive"`
+}
+
+type ModuleOrder struct {
+	ID      string   `json:"id" binding:"required"`
+	Lessons []string `json:"lessons"`
+}
@@ -0,0 +1,7 @@
+package dto
+
+type UpdateNotificationPreference struct {
+	DayReminder  bool `json:"dayReminder"`
+	HourReminder bool `json:"hourReminder"`
+	OwnerDigest  bool `json:"ownerDigest"`
+}
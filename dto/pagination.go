@@ -0,0 +1,14 @@
+package dto
+
+type PaginationQuery struct {
+	Cursor string `form:"cursor"`
+	Page   int    `form:"page" binding:"omitempty,min=1"`
+	Size   int    `form:"size" binding:"omitempty,min=1,max=100"`
+	Order  string `form:"order" binding:"omitempty,oneof=asc desc"`
+}
+
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor"`
+	Total      int64  `json:"total"`
+}
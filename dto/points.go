@@ -0,0 +1,11 @@
+package dto
+
+type UpdatePointsRule struct {
+	Points     int `json:"points" binding:"min=0,max=1000"`
+	DailyLimit int `json:"dailyLimit" binding:"min=0,max=1000"`
+}
+
+type PointsHistoryQuery struct {
+	PaginationQuery
+	UserID string `form:"userId"`
+}
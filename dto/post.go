@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type CreatePost struct {
 	Text  string `json:"text" binding:"required,min=1,max=500"`
 	Title string `json:"title"`
@@ -11,6 +13,9 @@ type UpdatePost struct {
 }
 
 type PostQueryParams struct {
-	Page int `json:"page"`
-	Size int `json:"size"`
+	PaginationQuery
+	UserID   string    `form:"userId"`
+	From     time.Time `form:"from"`
+	To       time.Time `form:"to"`
+	HasFiles *bool     `form:"hasFiles"`
 }
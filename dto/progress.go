@@ -0,0 +1,26 @@
+package dto
+
+import "time"
+
+// LessonProgress is how far a user got with one published lesson, the dates are empty until it happens.
+type LessonProgress struct {
+	LessonID    string     `json:"lessonId"`
+	Title       string     `json:"title"`
+	StartedAt   *time.Time `json:"startedAt"`
+	CompletedAt *time.Time `json:"completedAt"`
+}
+
+type TrainingProgress struct {
+	UserID       string           `json:"userId"`
+	FirstName    string           `json:"firstName"`
+	LastName     string           `json:"lastName"`
+	Email        string           `json:"email"`
+	Role         string           `json:"role"`
+	Started      int              `json:"started"`
+	Completed    int              `json:"completed"`
+	Total        int              `json:"total"`
+	Percent      float64          `json:"percent"`
+	LastLessonID *string          `json:"lastLessonId"`
+	LastOpenedAt *time.Time       `json:"lastOpenedAt"`
+	Lessons      []LessonProgress `json:"lessons"`
+}
@@ -0,0 +1,84 @@
+package dto
+
+import "time"
+
+type CreateQuiz struct {
+	LessonID    *string          `json:"lessonId"`
+	Title       string           `json:"title" binding:"required,min=3,max=100"`
+	Description string           `json:"description" binding:"max=1000"`
+	TimeLimit   int              `json:"timeLimit" binding:"min=0,max=600"`
+	MaxAttempts int              `json:"maxAttempts" binding:"min=0,max=100"`
+	Shuffle     bool             `json:"shuffle"`
+	PassPercent float64          `json:"passPercent" binding:"min=0,max=100"`
+	Points      int              `json:"points" binding:"min=0,max=1000"`
+	Published   bool             `json:"published"`
+	Questions   []CreateQuestion `json:"questions" binding:"required,min=1,max=100,dive"`
+}
+
+type UpdateQuiz struct {
+	LessonID    *string          `json:"lessonId"`
+	Title       string           `json:"title" binding:"required,min=3,max=100"`
+	Description string           `json:"description" binding:"max=1000"`
+	TimeLimit   int              `json:"timeLimit" binding:"min=0,max=600"`
+	MaxAttempts int              `json:"maxAttempts" binding:"min=0,max=100"`
+	Shuffle     bool             `json:"shuffle"`
+	PassPercent float64          `json:"passPercent" binding:"min=0,max=100"`
+	Points      int              `json:"points" binding:"min=0,max=1000"`
+	Published   bool             `json:"published"`
+	Questions   []CreateQuestion `json:"questions" binding:"required,min=1,max=100,dive"`
+}
+
+// CreateQuestion keeps the question with the ID when a quiz is updated, questions without one are added.
+type CreateQuestion struct {
+	ID        string   `json:"id"`
+	Type      string   `json:"type" binding:"required,oneof=single multiple true_false short_text numeric"`
+	Text      string   `json:"text" binding:"required,max=2000"`
+	Points    float64  `json:"points" binding:"min=0,max=100"`
+	Options   []string `json:"options" binding:"max=20"`
+	Correct   []int    `json:"correct"`
+	Accepted  []string `json:"accepted" binding:"max=20"`
+	Answer    *float64 `json:"answer"`
+	Tolerance float64  `json:"tolerance" binding:"min=0"`
+}
+
+type SubmitAttempt struct {
+	Answers []AttemptAnswer `json:"answers" binding:"dive"`
+}
+
+// AttemptAnswer answers one question: Choices are option indexes for choice and true/false
+// questions, Text is for short text questions and Number for numeric ones.
+type AttemptAnswer struct {
+	QuestionID string   `json:"questionId" binding:"required"`
+	Choices    []int    `json:"choices"`
+	Text       string   `json:"text" binding:"max=1000"`
+	Number     *float64 `json:"number"`
+}
+
+type QuizResults struct {
+	QuizID         string               `json:"quizId"`
+	Attempts       int                  `json:"attempts"`
+	AveragePercent float64              `json:"averagePercent"`
+	PassRate       float64              `json:"passRate"`
+	Members        []QuizMemberResult   `json:"members"`
+	Questions      []QuizQuestionResult `json:"questions"`
+}
+
+type QuizMemberResult struct {
+	UserID          string     `json:"userId"`
+	FirstName       string     `json:"firstName"`
+	LastName        string     `json:"lastName"`
+	Email           string     `json:"email"`
+	Attempts        int        `json:"attempts"`
+	BestPercent     float64    `json:"bestPercent"`
+	Passed          bool       `json:"passed"`
+	LastSubmittedAt *time.Time `json:"lastSubmittedAt"`
+}
+
+// QuizQuestionResult shows how often a question was answered right over the submitted attempts.
+type QuizQuestionResult struct {
+	QuestionID string  `json:"questionId"`
+	Text       string  `json:"text"`
+	Answered   int     `json:"answered"`
+	Correct    int     `json:"correct"`
+	Rate       float64 `json:"rate"`
+}
@@ -0,0 +1,13 @@
+package dto
+
+type CreateRefund struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+type RejectRefund struct {
+	Reason string `json:"reason" binding:"required,min=1,max=500"`
+}
+
+type RefundQueryParams struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending approved rejected"`
+}
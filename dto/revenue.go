@@ -0,0 +1,39 @@
+package dto
+
+import "time"
+
+type RevenueQuery struct {
+	From     time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Interval string    `form:"interval" binding:"omitempty,oneof=day week month"`
+}
+
+// RevenueTotals sums up sales, amounts are in cents. Refunds are given back from the gross revenue.
+type RevenueTotals struct {
+	Sales   int `json:"sales"`
+	Gross   int `json:"gross"`
+	Refunds int `json:"refunds"`
+	Net     int `json:"net"`
+}
+
+type RevenuePeriod struct {
+	Period string `json:"period"`
+	RevenueTotals
+}
+
+type TrainingRevenue struct {
+	TrainingID   string `json:"trainingId"`
+	TrainingName string `json:"trainingName"`
+	RevenueTotals
+	Periods []RevenuePeriod `json:"periods"`
+}
+
+type RevenueReport struct {
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Interval string    `json:"interval"`
+	Currency string    `json:"currency"`
+	RevenueTotals
+	Periods   []RevenuePeriod   `json:"periods"`
+	Trainings []TrainingRevenue `json:"trainings"`
+}
@@ -0,0 +1,19 @@
+package dto
+
+type CreateReview struct {
+	Rating int    `json:"rating" binding:"required,min=1,max=5"`
+	Text   string `json:"text" binding:"max=2000"`
+}
+
+type UpdateReview struct {
+	Rating int    `json:"rating" binding:"required,min=1,max=5"`
+	Text   string `json:"text" binding:"max=2000"`
+}
+
+type ReplyReview struct {
+	Reply string `json:"reply" binding:"max=2000"`
+}
+
+type ReportReview struct {
+	Reason string `json:"reason" binding:"required,min=3,max=500"`
+}
@@ -0,0 +1,36 @@
+package dto
+
+// RosterRow is a parsed line of a roster import, Line is its line number in the uploaded file.
+type RosterRow struct {
+	Line      int
+	Email     string
+	FirstName string
+	LastName  string
+	Role      string
+}
+
+type RosterRowResult struct {
+	Row     int    `json:"row"`
+	Email   string `json:"email"`
+	UserID  string `json:"userId,omitempty"`
+	Status  string `json:"status"`
+	Created bool   `json:"created"`
+	Error   string `json:"error,omitempty"`
+}
+
+const (
+	RosterAdded             = "added"
+	RosterWaitlisted        = "waitlisted"
+	RosterAlreadyEnrolled   = "already_enrolled"
+	RosterAlreadyWaitlisted = "already_waitlisted"
+	RosterNotFound          = "not_found"
+	RosterInvalid           = "invalid"
+)
+
+type RosterImportQuery struct {
+	CreateMissing bool `form:"createMissing"`
+}
+
+type RosterExportQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv xlsx"`
+}
@@ -0,0 +1,45 @@
+package dto
+
+import "time"
+
+type CreateSession struct {
+	Title       string    `json:"title" binding:"required,min=3,max=100"`
+	Description string    `json:"description" binding:"max=1000"`
+	StartsAt    time.Time `json:"startsAt" binding:"required"`
+	EndsAt      time.Time `json:"endsAt" binding:"required"`
+	TimeZone    string    `json:"timeZone"`
+	Location    string    `json:"location" binding:"max=200"`
+	MeetingURL  string    `json:"meetingUrl" binding:"omitempty,url"`
+	RRule       string    `json:"rrule" binding:"max=200"`
+}
+
+type UpdateSession struct {
+	Title       string    `json:"title" binding:"required,min=3,max=100"`
+	Description string    `json:"description" binding:"max=1000"`
+	StartsAt    time.Time `json:"startsAt" binding:"required"`
+	EndsAt      time.Time `json:"endsAt" binding:"required"`
+	TimeZone    string    `json:"timeZone"`
+	Location    string    `json:"location" binding:"max=200"`
+	MeetingURL  string    `json:"meetingUrl" binding:"omitempty,url"`
+	RRule       string    `json:"rrule" binding:"max=200"`
+}
+
+type ScheduleQuery struct {
+	From time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To   time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// Occurrence is a single meeting of a possibly recurring session.
+type Occurrence struct {
+	SessionID  string    `json:"sessionId"`
+	TrainingID string    `json:"trainingId"`
+	Title      string    `json:"title"`
+	StartsAt   time.Time `json:"startsAt"`
+	EndsAt     time.Time `json:"endsAt"`
+	Location   string    `json:"location"`
+	MeetingURL string    `json:"meetingUrl"`
+}
+
+type CalendarFeed struct {
+	Url string `json:"url"`
+}
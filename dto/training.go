@@ -1,15 +1,64 @@
 package dto
 
+import "github.com/Marcel-MD/xmas-faf-api/models"
+
 type CreateTraining struct {
-	Name     string `json:"name" binding:"required,min=3,max=50"`
-	Price    int    `json:"price" binding:"required"`
-	Category string `json:"category" binding:"required"`
-	Image    string `json:"image" binding:"required"`
+	Name        string   `json:"name" binding:"required,min=3,max=50"`
+	Description string   `json:"description" binding:"max=2000"`
+	Price       int      `json:"price" binding:"min=0"`
+	CategoryID  string   `json:"categoryId" binding:"required"`
+	Tags        []string `json:"tags" binding:"max=10,dive,min=1,max=30"`
+	Image       string   `json:"image" binding:"required"`
+	Visibility  string   `json:"visibility" binding:"omitempty,oneof=draft private unlisted public"`
+	AutoApprove bool     `json:"autoApprove"`
+	Capacity    int      `json:"capacity" binding:"min=0"`
+
+	RefundDays        int  `json:"refundDays" binding:"min=0,max=365"`
+	RefundPercent     int  `json:"refundPercent" binding:"min=0,max=100"`
+	RefundBeforeStart bool `json:"refundBeforeStart"`
 }
 
 type UpdateTraining struct {
-	Name     string `json:"name" binding:"required,min=3,max=50"`
-	Price    int    `json:"price" binding:"required"`
-	Category string `json:"category" binding:"required"`
-	Image    string `json:"image" binding:"required"`
+	Name        string   `json:"name" binding:"required,min=3,max=50"`
+	Description string   `json:"description" binding:"max=2000"`
+	Price       int      `json:"price" binding:"min=0"`
+	CategoryID  string   `json:"categoryId" binding:"required"`
+	Tags        []string `json:"tags" binding:"max=10,dive,min=1,max=30"`
+	Image       string   `json:"image" binding:"required"`
+	Visibility  string   `json:"visibility" binding:"omitempty,oneof=draft private unlisted public"`
+	AutoApprove bool     `json:"autoApprove"`
+	Capacity    int      `json:"capacity" binding:"min=0"`
+
+	RefundDays        int  `json:"refundDays" binding:"min=0,max=365"`
+	RefundPercent     int  `json:"refundPercent" binding:"min=0,max=100"`
+	RefundBeforeStart bool `json:"refundBeforeStart"`
+}
+
+type TrainingQueryParams struct {
+	PaginationQuery
+	Query    string `form:"q"`
+	Category string `form:"category"`
+	Tag      string `form:"tag"`
+	MinPrice *int   `form:"minPrice" binding:"omitempty,min=0"`
+	MaxPrice *int   `form:"maxPrice" binding:"omitempty,min=0"`
+	Sort     string `form:"sort" binding:"omitempty,oneof=newest price popularity rating"`
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+type TrainingFacets struct {
+	Categories   []FacetCount `json:"categories"`
+	PriceBuckets []FacetCount `json:"priceBuckets"`
+}
+
+type TrainingPage struct {
+	Page[models.Training]
+	Facets TrainingFacets `json:"facets"`
+}
+
+type ChangeMemberRole struct {
+	Role string `json:"role" binding:"required,oneof=co-owner instructor assistant student"`
 }
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/middleware"
+	"github.com/Marcel-MD/xmas-faf-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type assignmentHandler struct {
+	service services.IAssignmentService
+}
+
+func routeAssignmentHandler(router *gin.RouterGroup) {
+	h := &assignmentHandler{
+		service: services.GetAssignmentService(),
+	}
+
+	t := router.Group("/trainings").Use(middleware.JwtAuth())
+	t.GET("/:id/assignments", h.findByTraining)
+	t.POST("/:id/assignments", h.create)
+	t.GET("/:id/gradebook", h.gradebook)
+	t.GET("/:id/gradebook/export", h.exportGradebook)
+
+	r := router.Group("/assignments").Use(middleware.JwtAuth())
+	r.GET("/:id", h.findOne)
+	r.PUT("/:id", h.update)
+	r.DELETE("/:id", h.delete)
+	r.POST("/:id/submissions", h.submit)
+	r.GET("/:id/submissions", h.findSubmissions)
+	r.GET("/:id/submissions/:user_id", h.findUserSubmissions)
+
+	s := router.Group("/submissions").Use(middleware.JwtAuth())
+	s.PUT("/:id/grade", h.grade)
+}
+
+func (h *assignmentHandler) findByTraining(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	assignments, err := h.service.FindByTrainingID(trainingID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignments)
+}
+
+func (h *assignmentHandler) findOne(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	assignment, err := h.service.FindOne(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "assignment not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignment)
+}
+
+func (h *assignmentHandler) create(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.CreateAssignment
+	err := c.ShouldBindJSON(&dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	assignment, err := h.service.Create(trainingID, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignment)
+}
+
+func (h *assignmentHandler) update(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.UpdateAssignment
+	err := c.ShouldBindJSON(&dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	assignment, err := h.service.Update(id, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignment)
+}
+
+func (h *assignmentHandler) delete(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	err := h.service.Delete(id, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "assignment deleted"})
+}
+
+func (h *assignmentHandler) submit(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	uploads := []dto.Upload{}
+
+	form, err := c.MultipartForm()
+	if err != nil && err != http.ErrNotMultipart {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if form != nil {
+		for _, header := range form.File["files"] {
+			file, err := header.Open()
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			data, err := io.ReadAll(file)
+			file.Close()
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			uploads = append(uploads, dto.Upload{Name: header.Filename, Data: data})
+		}
+	}
+
+	submission, err := h.service.Submit(id, userID, c.PostForm("text"), uploads)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, submission)
+}
+
+func (h *assignmentHandler) findSubmissions(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	submissions, err := h.service.FindSubmissions(id, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, submissions)
+}
+
+func (h *assignmentHandler) findUserSubmissions(c *gin.Context) {
+	id := c.Param("id")
+	memberID := c.Param("user_id")
+	userID := c.GetString("user_id")
+
+	submissions, err := h.service.FindUserSubmissions(id, memberID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, submissions)
+}
+
+func (h *assignmentHandler) grade(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.GradeSubmission
+	err := c.ShouldBindJSON(&dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	submission, err := h.service.Grade(id, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, submission)
+}
+
+func (h *assignmentHandler) gradebook(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	gradebook, err := h.service.Gradebook(trainingID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gradebook)
+}
+
+func (h *assignmentHandler) exportGradebook(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	data, err := h.service.ExportGradebook(trainingID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=gradebook-%s.csv", trainingID))
+	c.Data(http.StatusOK, "text/csv", data)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/middleware"
+	"github.com/Marcel-MD/xmas-faf-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type attendanceHandler struct {
+	service services.IAttendanceService
+}
+
+func routeAttendanceHandler(router *gin.RouterGroup) {
+	h := &attendanceHandler{
+		service: services.GetAttendanceService(),
+	}
+
+	r := router.Group("/sessions").Use(middleware.JwtAuth())
+	r.GET("/:id/check-in-code", h.checkInCode)
+	r.POST("/:id/check-in", h.checkIn)
+	r.GET("/:id/attendance", h.findByOccurrence)
+	r.PUT("/:id/attendance/:user_id", h.mark)
+
+	t := router.Group("/trainings").Use(middleware.JwtAuth())
+	t.GET("/:id/attendance", h.trainingReport)
+	t.GET("/:id/attendance/:user_id", h.userReport)
+}
+
+func (h *attendanceHandler) checkInCode(c *gin.Context) {
+	sessionID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	code, err := h.service.CheckInCode(sessionID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, code)
+}
+
+func (h *attendanceHandler) checkIn(c *gin.Context) {
+	sessionID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.CheckIn
+	err := c.ShouldBindJSON(&dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	attendance, err := h.service.CheckIn(sessionID, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attendance)
+}
+
+func (h *attendanceHandler) findByOccurrence(c *gin.Context) {
+	sessionID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var query dto.AttendanceQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	attendances, err := h.service.FindByOccurrence(sessionID, userID, query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attendances)
+}
+
+func (h *attendanceHandler) mark(c *gin.Context) {
+	sessionID := c.Param("id")
+	memberID := c.Param("user_id")
+	userID := c.GetString("user_id")
+
+	var dto dto.MarkAttendance
+	err := c.ShouldBindJSON(&dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	attendance, err := h.service.Mark(sessionID, memberID, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attendance)
+}
+
+func (h *attendanceHandler) trainingReport(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	summaries, err := h.service.TrainingReport(trainingID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summaries)
+}
+
+func (h *attendanceHandler) userReport(c *gin.Context) {
+	trainingID := c.Param("id")
+	memberID := c.Param("user_id")
+	userID := c.GetString("user_id")
+
+	records, err := h.service.UserReport(trainingID, memberID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, records)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/middleware"
+	"github.com/Marcel-MD/xmas-faf-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type categoryHandler struct {
+	service         services.ICategoryService
+	trainingService services.ITrainingService
+}
+
+func routeCategoryHandler(router *gin.RouterGroup) {
+	h := &categoryHandler{
+		service:         services.GetCategoryService(),
+		trainingService: services.GetTrainingService(),
+	}
+
+	r := router.Group("/categories")
+	r.GET("/", h.findAll)
+	r.GET("/:slug", h.findOne)
+	r.GET("/:slug/trainings", h.findTrainings)
+
+	p := r.Use(middleware.JwtAuth())
+	p.POST("/", h.create)
+	p.PUT("/:id", h.update)
+	p.DELETE("/:id", h.delete)
+}
+
+func (h *categoryHandler) findAll(c *gin.Context) {
+	categories := h.service.FindAll()
+	c.JSON(http.StatusOK, categories)
+}
+
+func (h *categoryHandler) findOne(c *gin.Context) {
+	slug := c.Param("slug")
+
+	category, err := h.service.FindBySlug(slug)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+func (h *categoryHandler) findTrainings(c *gin.Context) {
+	var params dto.TrainingQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	params.Category = c.Param("slug")
+
+	trainings, err := h.trainingService.FindAll(params)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, trainings)
+}
+
+func (h *categoryHandler) create(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var dto dto.CreateCategory
+	err := c.ShouldBindJSON(&dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.service.Create(dto, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+func (h *categoryHandler) update(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.UpdateCategory
+	err := c.ShouldBindJSON(&dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.service.Update(id, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+func (h *categoryHandler) delete(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	err := h.service.Delete(id, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"category_id": id})
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Marcel-MD/xmas-faf-api/middleware"
+	"github.com/Marcel-MD/xmas-faf-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type certificateHandler struct {
+	service services.ICertificateService
+}
+
+func routeCertificateHandler(router *gin.RouterGroup) {
+	h := &certificateHandler{
+		service: services.GetCertificateService(),
+	}
+
+	t := router.Group("/trainings").Use(middleware.JwtAuth())
+	t.GET("/:id/certificates", h.findByTraining)
+	t.POST("/:id/certificates/:user_id", h.issue)
+
+	r := router.Group("/certificates")
+	r.GET("/:serial", h.verify)
+
+	a := r.Use(middleware.JwtAuth())
+	a.GET("/", h.findCurrent)
+}
+
+func (h *certificateHandler) verify(c *gin.Context) {
+	serial := c.Param("serial")
+
+	certificate, err := h.service.FindBySerial(serial)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "certificate not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, certificate)
+}
+
+func (h *certificateHandler) findCurrent(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	certificates := h.service.FindByUserID(userID)
+
+	c.JSON(http.StatusOK, certificates)
+}
+
+func (h *certificateHandler) findByTraining(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	certificates, err := h.service.FindByTrainingID(trainingID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, certificates)
+}
+
+func (h *certificateHandler) issue(c *gin.Context) {
+	trainingID := c.Param("id")
+	memberID := c.Param("user_id")
+	userID := c.GetString("user_id")
+
+	certificate, err := h.service.Issue(trainingID, memberID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, certificate)
+}
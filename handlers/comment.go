@@ -28,9 +28,15 @@ func routeCommentHandler(router *gin.RouterGroup) {
 func (h *commentHandler) find(c *gin.Context) {
 	postId := c.Param("post_id")
 
+	var params dto.PaginationQuery
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	userID := c.GetString("user_id")
 
-	comments, err := h.service.FindByPostID(postId, userID)
+	comments, err := h.service.FindByPostID(postId, userID, params)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/middleware"
+	"github.com/Marcel-MD/xmas-faf-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type couponHandler struct {
+	service services.ICouponService
+}
+
+func routeCouponHandler(router *gin.RouterGroup) {
+	h := &couponHandler{
+		service: services.GetCouponService(),
+	}
+
+	r := router.Group("/coupons").Use(middleware.JwtAuth())
+	r.GET("/", h.findAll)
+	r.GET("/:id", h.findOne)
+	r.POST("/", h.create)
+	r.PUT("/:id", h.update)
+	r.DELETE("/:id", h.delete)
+}
+
+func (h *couponHandler) findAll(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	coupons := h.service.FindAll(userID)
+
+	c.JSON(http.StatusOK, coupons)
+}
+
+func (h *couponHandler) findOne(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	coupon, err := h.service.FindOne(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "coupon not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, coupon)
+}
+
+func (h *couponHandler) create(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var dto dto.CreateCoupon
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	coupon, err := h.service.Create(userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, coupon)
+}
+
+func (h *couponHandler) update(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.UpdateCoupon
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	coupon, err := h.service.Update(id, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, coupon)
+}
+
+func (h *couponHandler) delete(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	err := h.service.Delete(id, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "coupon deleted"})
+}
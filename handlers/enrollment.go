@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/middleware"
+	"github.com/Marcel-MD/xmas-faf-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type enrollmentHandler struct {
+	service services.IEnrollmentService
+}
+
+func routeEnrollmentHandler(router *gin.RouterGroup) {
+	h := &enrollmentHandler{
+		service: services.GetEnrollmentService(),
+	}
+
+	t := router.Group("/trainings").Use(middleware.JwtAuth())
+	t.POST("/:id/enrollments", h.apply)
+	t.GET("/:id/enrollments", h.findByTraining)
+
+	r := router.Group("/enrollments").Use(middleware.JwtAuth())
+	r.GET("/current", h.findCurrent)
+	r.GET("/:id", h.findOne)
+	r.POST("/:id/approve", h.approve)
+	r.POST("/:id/reject", h.reject)
+	r.POST("/:id/withdraw", h.withdraw)
+}
+
+func (h *enrollmentHandler) apply(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.CreateEnrollment
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	enrollment, err := h.service.Apply(trainingID, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *enrollmentHandler) findByTraining(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var params dto.EnrollmentQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	enrollments, err := h.service.FindByTrainingID(trainingID, userID, params)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollments)
+}
+
+func (h *enrollmentHandler) findCurrent(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	enrollments := h.service.FindByUserID(userID)
+	c.JSON(http.StatusOK, enrollments)
+}
+
+func (h *enrollmentHandler) findOne(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	enrollment, err := h.service.FindOne(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "enrollment not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *enrollmentHandler) approve(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	enrollment, err := h.service.Approve(id, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *enrollmentHandler) reject(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.RejectEnrollment
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	enrollment, err := h.service.Reject(id, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *enrollmentHandler) withdraw(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	enrollment, err := h.service.Withdraw(id, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/middleware"
+	"github.com/Marcel-MD/xmas-faf-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type inviteHandler struct {
+	service services.IInviteService
+}
+
+func routeInviteHandler(router *gin.RouterGroup) {
+	h := &inviteHandler{
+		service: services.GetInviteService(),
+	}
+
+	t := router.Group("/trainings").Use(middleware.JwtAuth())
+	t.GET("/:id/invites", h.findByTraining)
+	t.POST("/:id/invites", h.create)
+	t.POST("/:id/invites/email", h.sendEmails)
+
+	r := router.Group("/invites")
+	r.GET("/:code", h.findByCode)
+
+	p := r.Use(middleware.JwtAuth())
+	p.POST("/:code/redeem", h.redeem)
+	p.DELETE("/:id", h.revoke)
+}
+
+func (h *inviteHandler) findByTraining(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	invites, err := h.service.FindByTrainingID(trainingID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invites)
+}
+
+func (h *inviteHandler) create(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.CreateInvite
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invite, err := h.service.Create(trainingID, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invite)
+}
+
+func (h *inviteHandler) sendEmails(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.EmailInvites
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invites, err := h.service.SendEmails(trainingID, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invites)
+}
+
+func (h *inviteHandler) findByCode(c *gin.Context) {
+	code := c.Param("code")
+
+	invite, err := h.service.FindByCode(code)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invite not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":         invite.Code,
+		"role":         invite.Role,
+		"expiresAt":    invite.ExpiresAt,
+		"trainingId":   invite.TrainingID,
+		"trainingName": invite.Training.Name,
+	})
+}
+
+func (h *inviteHandler) redeem(c *gin.Context) {
+	code := c.Param("code")
+	userID := c.GetString("user_id")
+
+	invite, waitlisted, err := h.service.Redeem(code, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"training_id": invite.TrainingID, "waitlisted": waitlisted})
+}
+
+func (h *inviteHandler) revoke(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	invite, err := h.service.Revoke(id, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invite)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Marcel-MD/xmas-faf-api/middleware"
+	"github.com/Marcel-MD/xmas-faf-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type invoiceHandler struct {
+	service services.IInvoiceService
+}
+
+func routeInvoiceHandler(router *gin.RouterGroup) {
+	h := &invoiceHandler{
+		service: services.GetInvoiceService(),
+	}
+
+	r := router.Group("/invoices").Use(middleware.JwtAuth())
+	r.GET("/", h.findCurrent)
+	r.GET("/:id", h.findOne)
+	r.GET("/:id/pdf", h.pdf)
+}
+
+func (h *invoiceHandler) findCurrent(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	invoices := h.service.FindByUserID(userID)
+
+	c.JSON(http.StatusOK, invoices)
+}
+
+func (h *invoiceHandler) findOne(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	invoice, err := h.service.FindOne(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
+
+func (h *invoiceHandler) pdf(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	invoice, data, err := h.service.Pdf(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=invoice-%s.pdf", invoice.Number))
+	c.Data(http.StatusOK, "application/pdf", data)
+}
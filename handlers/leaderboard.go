@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/middleware"
+	"github.com/Marcel-MD/xmas-faf-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type leaderboardHandler struct {
+	service services.ILeaderboardService
+}
+
+func routeLeaderboardHandler(router *gin.RouterGroup) {
+	h := &leaderboardHandler{
+		service: services.GetLeaderboardService(),
+	}
+
+	t := router.Group("/trainings").Use(middleware.JwtAuth())
+	t.GET("/:id/leaderboard", h.findByTraining)
+
+	r := router.Group("/leaderboard").Use(middleware.JwtAuth())
+	r.GET("/", h.find)
+	r.PUT("/privacy", h.updatePrivacy)
+}
+
+func (h *leaderboardHandler) find(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var query dto.LeaderboardQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	leaderboard, err := h.service.Find(userID, query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, leaderboard)
+}
+
+func (h *leaderboardHandler) findByTraining(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var query dto.LeaderboardQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	leaderboard, err := h.service.FindByTrainingID(trainingID, userID, query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, leaderboard)
+}
+
+func (h *leaderboardHandler) updatePrivacy(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var dto dto.LeaderboardPrivacy
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.service.UpdatePrivacy(userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/middleware"
+	"github.com/Marcel-MD/xmas-faf-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type lessonHandler struct {
+	moduleService services.IModuleService
+	service       services.ILessonService
+}
+
+func routeLessonHandler(router *gin.RouterGroup) {
+	h := &lessonHandler{
+		moduleService: services.GetModuleService(),
+		service:       services.GetLessonService(),
+	}
+
+	t := router.Group("/trainings")
+	t.GET("/:id/modules", middleware.OptionalJwtAuth(), h.findModules)
+	t.POST("/:id/modules", middleware.JwtAuth(), h.createModule)
+	t.PUT("/:id/curriculum/order", middleware.JwtAuth(), h.reorder)
+
+	m := router.Group("/modules").Use(middleware.JwtAuth())
+	m.PUT("/:id", h.updateModule)
+	m.DELETE("/:id", h.deleteModule)
+	m.POST("/:id/lessons", h.create)
+
+	r := router.Group("/lessons").Use(middleware.JwtAuth())
+	r.GET("/:id", h.findOne)
+	r.PUT("/:id", h.update)
+	r.DELETE("/:id", h.delete)
+	r.POST("/:id/complete", h.complete)
+	r.POST("/:id/files", h.upload)
+}
+
+func (h *lessonHandler) findModules(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	modules, err := h.moduleService.FindByTrainingID(trainingID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "training not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, modules)
+}
+
+func (h *lessonHandler) createModule(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.CreateModule
+	err := c.ShouldBindJSON(&dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	module, err := h.moduleService.Create(trainingID, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, module)
+}
+
+func (h *lessonHandler) updateModule(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.UpdateModule
+	err := c.ShouldBindJSON(&dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	module, err := h.moduleService.Update(id, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, module)
+}
+
+func (h *lessonHandler) deleteModule(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	err := h.moduleService.Delete(id, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "module deleted"})
+}
+
+func (h *lessonHandler) reorder(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.ReorderCurriculum
+	err := c.ShouldBindJSON(&dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	modules, err := h.moduleService.Reorder(trainingID, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, modules)
+}
+
+func (h *lessonHandler) findOne(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	lesson, err := h.service.FindOne(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "lesson not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, lesson)
+}
+
+func (h *lessonHandler) create(c *gin.Context) {
+	moduleID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.CreateLesson
+	err := c.ShouldBindJSON(&dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lesson, err := h.service.Create(moduleID, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, lesson)
+}
+
+func (h *lessonHandler) update(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.UpdateLesson
+	err := c.ShouldBindJSON(&dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lesson, err := h.service.Update(id, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, lesson)
+}
+
+func (h *lessonHandler) delete(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	err := h.service.Delete(id, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "lesson deleted"})
+}
+
+func (h *lessonHandler) complete(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	progress, err := h.service.Complete(id, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+func (h *lessonHandler) upload(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	form, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, err := form.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	uploaded, err := h.service.Upload(id, userID, form.Filename, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, uploaded)
+}
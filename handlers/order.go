@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/middleware"
+	"github.com/Marcel-MD/xmas-faf-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type orderHandler struct {
+	service services.IOrderService
+}
+
+func routeOrderHandler(router *gin.RouterGroup) {
+	h := &orderHandler{
+		service: services.GetOrderService(),
+	}
+
+	t := router.Group("/trainings").Use(middleware.JwtAuth())
+	t.POST("/:id/checkout", h.checkout)
+
+	r := router.Group("/orders").Use(middleware.JwtAuth())
+	r.GET("/", h.findCurrent)
+	r.GET("/:id", h.findOne)
+
+	p := router.Group("/payments")
+	p.POST("/webhook", h.webhook)
+}
+
+func (h *orderHandler) checkout(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	// The body is optional, it's only needed to apply a coupon.
+	var dto dto.Checkout
+	if err := c.ShouldBindJSON(&dto); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.service.Checkout(trainingID, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func (h *orderHandler) findCurrent(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	orders := h.service.FindByUserID(userID)
+
+	c.JSON(http.StatusOK, orders)
+}
+
+func (h *orderHandler) findOne(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	order, err := h.service.FindOne(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func (h *orderHandler) webhook(c *gin.Context) {
+	payload, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.service.HandleWebhook(payload, c.Request.Header)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "event received"})
+}
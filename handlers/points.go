@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/middleware"
+	"github.com/Marcel-MD/xmas-faf-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type pointsHandler struct {
+	service services.IPointsService
+}
+
+func routePointsHandler(router *gin.RouterGroup) {
+	h := &pointsHandler{
+		service: services.GetPointsService(),
+	}
+
+	r := router.Group("/points").Use(middleware.JwtAuth())
+	r.GET("/history", h.findHistory)
+	r.GET("/rules", h.findRules)
+	r.PUT("/rules/:event", h.updateRule)
+}
+
+func (h *pointsHandler) findHistory(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var query dto.PointsHistoryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	history, err := h.service.FindHistory(userID, query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+func (h *pointsHandler) findRules(c *gin.Context) {
+	rules := h.service.FindRules()
+
+	c.JSON(http.StatusOK, rules)
+}
+
+func (h *pointsHandler) updateRule(c *gin.Context) {
+	event := c.Param("event")
+	userID := c.GetString("user_id")
+
+	var dto dto.UpdatePointsRule
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.service.UpdateRule(event, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
@@ -28,9 +28,15 @@ func routePostHandler(router *gin.RouterGroup) {
 func (h *postHandler) find(c *gin.Context) {
 	trainingID := c.Param("training_id")
 
+	var params dto.PostQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	userID := c.GetString("user_id")
 
-	posts, err := h.service.FindByTrainingID(trainingID, userID)
+	posts, err := h.service.FindByTrainingID(trainingID, userID, params)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Marcel-MD/xmas-faf-api/middleware"
+	"github.com/Marcel-MD/xmas-faf-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type progressHandler struct {
+	service services.IProgressService
+}
+
+func routeProgressHandler(router *gin.RouterGroup) {
+	h := &progressHandler{
+		service: services.GetProgressService(),
+	}
+
+	r := router.Group("/trainings").Use(middleware.JwtAuth())
+	r.GET("/:id/progress", h.findOne)
+	r.GET("/:id/progress/members", h.findByTraining)
+}
+
+func (h *progressHandler) findOne(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	progress, err := h.service.FindOne(trainingID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+func (h *progressHandler) findByTraining(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	grid, err := h.service.FindByTrainingID(trainingID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, grid)
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/middleware"
+	"github.com/Marcel-MD/xmas-faf-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type quizHandler struct {
+	service services.IQuizService
+}
+
+func routeQuizHandler(router *gin.RouterGroup) {
+	h := &quizHandler{
+		service: services.GetQuizService(),
+	}
+
+	t := router.Group("/trainings").Use(middleware.JwtAuth())
+	t.GET("/:id/quizzes", h.findByTraining)
+	t.POST("/:id/quizzes", h.create)
+
+	r := router.Group("/quizzes").Use(middleware.JwtAuth())
+	r.GET("/:id", h.findOne)
+	r.PUT("/:id", h.update)
+	r.DELETE("/:id", h.delete)
+	r.GET("/:id/results", h.results)
+	r.GET("/:id/attempts", h.findAttempts)
+	r.POST("/:id/attempts", h.start)
+	r.GET("/:id/attempts/:attempt_id", h.findAttempt)
+	r.POST("/:id/attempts/:attempt_id/submit", h.submit)
+}
+
+func (h *quizHandler) findByTraining(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	quizzes, err := h.service.FindByTrainingID(trainingID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quizzes)
+}
+
+func (h *quizHandler) findOne(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	quiz, err := h.service.FindOne(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "quiz not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, quiz)
+}
+
+func (h *quizHandler) create(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.CreateQuiz
+	err := c.ShouldBindJSON(&dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quiz, err := h.service.Create(trainingID, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quiz)
+}
+
+func (h *quizHandler) update(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.UpdateQuiz
+	err := c.ShouldBindJSON(&dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quiz, err := h.service.Update(id, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quiz)
+}
+
+func (h *quizHandler) delete(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	err := h.service.Delete(id, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "quiz deleted"})
+}
+
+func (h *quizHandler) results(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	results, err := h.service.Results(id, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+func (h *quizHandler) findAttempts(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	attempts, err := h.service.FindAttempts(id, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attempts)
+}
+
+func (h *quizHandler) start(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	attempt, err := h.service.Start(id, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attempt)
+}
+
+func (h *quizHandler) findAttempt(c *gin.Context) {
+	id := c.Param("id")
+	attemptID := c.Param("attempt_id")
+	userID := c.GetString("user_id")
+
+	attempt, err := h.service.FindAttempt(id, attemptID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "attempt not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, attempt)
+}
+
+func (h *quizHandler) submit(c *gin.Context) {
+	id := c.Param("id")
+	attemptID := c.Param("attempt_id")
+	userID := c.GetString("user_id")
+
+	var dto dto.SubmitAttempt
+	err := c.ShouldBindJSON(&dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	attempt, err := h.service.Submit(id, attemptID, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attempt)
+}
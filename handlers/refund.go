@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/middleware"
+	"github.com/Marcel-MD/xmas-faf-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type refundHandler struct {
+	service services.IRefundService
+}
+
+func routeRefundHandler(router *gin.RouterGroup) {
+	h := &refundHandler{
+		service: services.GetRefundService(),
+	}
+
+	o := router.Group("/orders").Use(middleware.JwtAuth())
+	o.POST("/:id/refunds", h.request)
+
+	t := router.Group("/trainings").Use(middleware.JwtAuth())
+	t.GET("/:id/refunds", h.findByTraining)
+
+	r := router.Group("/refunds").Use(middleware.JwtAuth())
+	r.GET("/current", h.findCurrent)
+	r.GET("/:id", h.findOne)
+	r.POST("/:id/approve", h.approve)
+	r.POST("/:id/reject", h.reject)
+}
+
+func (h *refundHandler) request(c *gin.Context) {
+	orderID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.CreateRefund
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	refund, err := h.service.Request(orderID, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, refund)
+}
+
+func (h *refundHandler) findByTraining(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var params dto.RefundQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	refunds, err := h.service.FindByTrainingID(trainingID, userID, params)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, refunds)
+}
+
+func (h *refundHandler) findCurrent(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	refunds := h.service.FindByUserID(userID)
+
+	c.JSON(http.StatusOK, refunds)
+}
+
+func (h *refundHandler) findOne(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	refund, err := h.service.FindOne(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "refund not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, refund)
+}
+
+func (h *refundHandler) approve(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	refund, err := h.service.Approve(id, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, refund)
+}
+
+func (h *refundHandler) reject(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.RejectRefund
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	refund, err := h.service.Reject(id, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, refund)
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/middleware"
+	"github.com/Marcel-MD/xmas-faf-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type revenueHandler struct {
+	service services.IRevenueService
+}
+
+func routeRevenueHandler(router *gin.RouterGroup) {
+	h := &revenueHandler{
+		service: services.GetRevenueService(),
+	}
+
+	r := router.Group("/revenue").Use(middleware.JwtAuth())
+	r.GET("/", h.findByOwner)
+	r.GET("/export", h.exportByOwner)
+	r.GET("/platform", h.findAll)
+	r.GET("/platform/export", h.exportAll)
+}
+
+func (h *revenueHandler) findByOwner(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var query dto.RevenueQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report := h.service.FindByOwner(userID, query)
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *revenueHandler) exportByOwner(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var query dto.RevenueQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.export(c, h.service.FindByOwner(userID, query))
+}
+
+func (h *revenueHandler) findAll(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var query dto.RevenueQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.service.FindAll(userID, query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *revenueHandler) exportAll(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var query dto.RevenueQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.service.FindAll(userID, query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.export(c, report)
+}
+
+func (h *revenueHandler) export(c *gin.Context, report dto.RevenueReport) {
+	data, err := h.service.Export(report)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=revenue-%s-%s.csv",
+		report.From.Format("2006-01-02"), report.To.Format("2006-01-02")))
+	c.Data(http.StatusOK, "text/csv", data)
+}
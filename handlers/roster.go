@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/middleware"
+	"github.com/Marcel-MD/xmas-faf-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type rosterHandler struct {
+	service services.IRosterService
+}
+
+func routeRosterHandler(router *gin.RouterGroup) {
+	h := &rosterHandler{
+		service: services.GetRosterService(),
+	}
+
+	r := router.Group("/trainings").Use(middleware.JwtAuth())
+	r.POST("/:id/roster/import", h.importRoster)
+	r.GET("/:id/roster/export", h.exportRoster)
+}
+
+func (h *rosterHandler) importRoster(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var query dto.RosterImportQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	form, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, err := form.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	defer file.Close()
+
+	results, err := h.service.Import(trainingID, userID, file, query.CreateMissing)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+func (h *rosterHandler) exportRoster(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var query dto.RosterExportQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if query.Format == "" {
+		query.Format = "csv"
+	}
+
+	data, err := h.service.Export(trainingID, userID, query.Format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := "text/csv"
+	if query.Format == "xlsx" {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=roster-%s.%s", trainingID, query.Format))
+	c.Data(http.StatusOK, contentType, data)
+}
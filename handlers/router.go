@@ -26,6 +26,26 @@ func InitRouter() {
 		routePostHandler(r)
 		routeFileHandler(r)
 		routeCommentHandler(r)
+		routeCategoryHandler(r)
+		routeTagHandler(r)
+		routeEnrollmentHandler(r)
+		routeInviteHandler(r)
+		routeRosterHandler(r)
+		routeSessionHandler(r)
+		routeAttendanceHandler(r)
+		routeLessonHandler(r)
+		routeProgressHandler(r)
+		routeQuizHandler(r)
+		routeAssignmentHandler(r)
+		routeCertificateHandler(r)
+		routeReviewHandler(r)
+		routeOrderHandler(r)
+		routeCouponHandler(r)
+		routeInvoiceHandler(r)
+		routeRevenueHandler(r)
+		routeRefundHandler(r)
+		routePointsHandler(r)
+		routeLeaderboardHandler(r)
 
 		port := os.Getenv("PORT")
 		if port == "" {
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/middleware"
+	"github.com/Marcel-MD/xmas-faf-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type sessionHandler struct {
+	service services.ISessionService
+}
+
+const calendarContentType = "text/calendar; charset=utf-8"
+
+func routeSessionHandler(router *gin.RouterGroup) {
+	h := &sessionHandler{
+		service: services.GetSessionService(),
+	}
+
+	t := router.Group("/trainings")
+	t.GET("/:id/sessions", middleware.OptionalJwtAuth(), h.findByTraining)
+	t.GET("/:id/schedule", middleware.OptionalJwtAuth(), h.trainingSchedule)
+	t.GET("/:id/calendar.ics", middleware.OptionalJwtAuth(), h.trainingCalendar)
+	t.POST("/:id/sessions", middleware.JwtAuth(), h.create)
+
+	r := router.Group("/sessions")
+	r.GET("/:id", middleware.OptionalJwtAuth(), h.findOne)
+
+	p := r.Use(middleware.JwtAuth())
+	p.GET("/schedule", h.userSchedule)
+	p.PUT("/:id", h.update)
+	p.DELETE("/:id", h.delete)
+
+	c := router.Group("/calendar")
+	c.GET("/:token", h.userCalendar)
+
+	a := c.Use(middleware.JwtAuth())
+	a.GET("/current", h.calendarFeed)
+	a.POST("/current/reset", h.resetCalendarFeed)
+}
+
+func (h *sessionHandler) findByTraining(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	sessions, err := h.service.FindByTrainingID(trainingID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *sessionHandler) findOne(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	session, err := h.service.FindOne(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+func (h *sessionHandler) create(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.CreateSession
+	err := c.ShouldBindJSON(&dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.service.Create(trainingID, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+func (h *sessionHandler) update(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var dto dto.UpdateSession
+	err := c.ShouldBindJSON(&dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.service.Update(id, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+func (h *sessionHandler) delete(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	err := h.service.Delete(id, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "session deleted"})
+}
+
+func (h *sessionHandler) trainingSchedule(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var query dto.ScheduleQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	occurrences, err := h.service.TrainingSchedule(trainingID, userID, query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, occurrences)
+}
+
+func (h *sessionHandler) userSchedule(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var query dto.ScheduleQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	occurrences, err := h.service.UserSchedule(userID, query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, occurrences)
+}
+
+func (h *sessionHandler) trainingCalendar(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	data, err := h.service.TrainingCalendar(trainingID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "training not found"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=training-%s.ics", trainingID))
+	c.Data(http.StatusOK, calendarContentType, data)
+}
+
+func (h *sessionHandler) userCalendar(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	data, err := h.service.UserCalendar(token)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "calendar not found"})
+		return
+	}
+
+	c.Data(http.StatusOK, calendarContentType, data)
+}
+
+func (h *sessionHandler) calendarFeed(c *gin.Context) {
+	h.writeCalendarFeed(c, false)
+}
+
+func (h *sessionHandler) resetCalendarFeed(c *gin.Context) {
+	h.writeCalendarFeed(c, true)
+}
+
+func (h *sessionHandler) writeCalendarFeed(c *gin.Context, reset bool) {
+	userID := c.GetString("user_id")
+
+	token, err := h.service.CalendarToken(userID, reset)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	c.JSON(http.StatusOK, dto.CalendarFeed{
+		Url: fmt.Sprintf("%s://%s/api/calendar/%s.ics", scheme, c.Request.Host, token),
+	})
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type tagHandler struct {
+	service         services.ITagService
+	trainingService services.ITrainingService
+}
+
+func routeTagHandler(router *gin.RouterGroup) {
+	h := &tagHandler{
+		service:         services.GetTagService(),
+		trainingService: services.GetTrainingService(),
+	}
+
+	r := router.Group("/tags")
+	r.GET("/", h.findAll)
+	r.GET("/:name/trainings", h.findTrainings)
+}
+
+func (h *tagHandler) findAll(c *gin.Context) {
+	tags := h.service.FindAll()
+	c.JSON(http.StatusOK, tags)
+}
+
+func (h *tagHandler) findTrainings(c *gin.Context) {
+	var params dto.TrainingQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	params.Tag = c.Param("name")
+
+	trainings, err := h.trainingService.FindAll(params)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, trainings)
+}
@@ -22,7 +22,7 @@ func routeTrainingHandler(router *gin.RouterGroup) {
 
 	r := router.Group("/trainings")
 	r.GET("/", h.findAll)
-	r.GET("/:id", h.findOne)
+	r.GET("/:id", middleware.OptionalJwtAuth(), h.findOne)
 
 	p := r.Use(middleware.JwtAuth())
 	p.POST("/", h.create)
@@ -30,17 +30,34 @@ func routeTrainingHandler(router *gin.RouterGroup) {
 	p.DELETE("/:id", h.delete)
 	p.POST("/:id/users/:user_id", h.addUser)
 	p.DELETE("/:id/users/:user_id", h.removeUser)
+	p.GET("/:id/users", h.findMembers)
+	p.PUT("/:id/users/:user_id", h.changeRole)
+	p.POST("/:id/transfer/:user_id", h.transferOwnership)
+	p.GET("/:id/waitlist", h.findWaitlist)
+	p.DELETE("/:id/waitlist", h.leaveWaitlist)
 }
 
 func (h *trainingHandler) findAll(c *gin.Context) {
-	trainings := h.service.FindAll()
+	var params dto.TrainingQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trainings, err := h.service.FindAll(params)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, trainings)
 }
 
 func (h *trainingHandler) findOne(c *gin.Context) {
 	id := c.Param("id")
+	userID := c.GetString("user_id")
 
-	training, err := h.service.FindOne(id)
+	training, err := h.service.FindOne(id, userID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "training not found"})
 		return
@@ -106,13 +123,13 @@ func (h *trainingHandler) addUser(c *gin.Context) {
 	addUserID := c.Param("user_id")
 	userID := c.GetString("user_id")
 
-	err := h.service.AddUser(trainingID, addUserID, userID)
+	waitlisted, err := h.service.AddUser(trainingID, addUserID, userID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"user_id": addUserID})
+	c.JSON(http.StatusOK, gin.H{"user_id": addUserID, "waitlisted": waitlisted})
 }
 
 func (h *trainingHandler) removeUser(c *gin.Context) {
@@ -128,3 +145,77 @@ func (h *trainingHandler) removeUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"user_id": removeUserID})
 }
+
+func (h *trainingHandler) findMembers(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	members, err := h.service.FindMembers(trainingID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+func (h *trainingHandler) changeRole(c *gin.Context) {
+	trainingID := c.Param("id")
+	memberID := c.Param("user_id")
+	userID := c.GetString("user_id")
+
+	var dto dto.ChangeMemberRole
+	err := c.ShouldBindJSON(&dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.service.ChangeRole(trainingID, memberID, userID, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+func (h *trainingHandler) transferOwnership(c *gin.Context) {
+	trainingID := c.Param("id")
+	newOwnerID := c.Param("user_id")
+	userID := c.GetString("user_id")
+
+	training, err := h.service.TransferOwnership(trainingID, newOwnerID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, training)
+}
+
+func (h *trainingHandler) findWaitlist(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	entries, err := h.service.FindWaitlist(trainingID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+func (h *trainingHandler) leaveWaitlist(c *gin.Context) {
+	trainingID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	err := h.service.LeaveWaitlist(trainingID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user_id": userID})
+}
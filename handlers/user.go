@@ -33,6 +33,8 @@ func routeUserHandler(router *gin.RouterGroup) {
 	p.GET("/current", h.current)
 	p.PUT("/update", h.update)
 	p.PUT("/update-otp", h.updateOtp)
+	p.GET("/current/notifications", h.findNotifications)
+	p.PUT("/current/notifications", h.updateNotifications)
 
 	p.POST("/:id/roles/:role", h.addRole)
 	p.DELETE("/:id/roles/:role", h.removeRole)
@@ -131,7 +133,7 @@ func (h *userHandler) sendOtp(c *gin.Context) {
 func (h *userHandler) current(c *gin.Context) {
 	id := c.GetString("user_id")
 
-	user, err := h.service.FindOne(id)
+	user, err := h.service.FindCurrent(id)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "record not found"})
 		return
@@ -141,7 +143,18 @@ func (h *userHandler) current(c *gin.Context) {
 }
 
 func (h *userHandler) findAll(c *gin.Context) {
-	users := h.service.FindAll()
+	var params dto.PaginationQuery
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	users, err := h.service.FindAll(params)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, users)
 }
 
@@ -183,6 +196,33 @@ func (h *userHandler) update(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+func (h *userHandler) findNotifications(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	preference := h.service.FindNotificationPreference(userID)
+
+	c.JSON(http.StatusOK, preference)
+}
+
+func (h *userHandler) updateNotifications(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var dto dto.UpdateNotificationPreference
+	err := c.ShouldBindJSON(&dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preference, err := h.service.UpdateNotificationPreference(dto, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, preference)
+}
+
 func (h *userHandler) updateOtp(c *gin.Context) {
 	userID := c.GetString("user_id")
 
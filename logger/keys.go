@@ -1,8 +1,23 @@
 package logger
 
 const (
-	UserID     = "user_id"
-	TrainingID = "training_id"
-	PostID     = "post_id"
-	CommentId  = "comment_id"
+	UserID       = "user_id"
+	TrainingID   = "training_id"
+	PostID       = "post_id"
+	CommentId    = "comment_id"
+	CategoryID   = "category_id"
+	EnrollmentID = "enrollment_id"
+	InviteID     = "invite_id"
+	SessionID    = "session_id"
+	JobID        = "job_id"
+	ModuleID     = "module_id"
+	LessonID     = "lesson_id"
+	QuizID       = "quiz_id"
+	AssignmentID = "assignment_id"
+	SubmissionID = "submission_id"
+	ReviewID     = "review_id"
+	OrderID      = "order_id"
+	CouponID     = "coupon_id"
+	InvoiceID    = "invoice_id"
+	RefundID     = "refund_id"
 )
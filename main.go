@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/Marcel-MD/xmas-faf-api/handlers"
 	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/services"
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog/log"
 )
@@ -15,5 +16,6 @@ func main() {
 	}
 
 	logger.Config()
+	services.GetReminderService().Start()
 	handlers.InitRouter()
 }
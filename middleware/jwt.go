@@ -21,3 +21,14 @@ func JwtAuth() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// OptionalJwtAuth sets the user ID when a valid token is sent, but lets anonymous requests through.
+func OptionalJwtAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := token.ExtractID(c)
+		if err == nil {
+			c.Set("user_id", id)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+type Assignment struct {
+	Base
+	TrainingID  string                `json:"trainingId" gorm:"index"`
+	Training    Training              `json:"-" gorm:"foreignKey:TrainingID;constraint:OnDelete:CASCADE"`
+	Title       string                `json:"title"`
+	Description string                `json:"description"`
+	DueAt       *time.Time            `json:"dueAt"`
+	MaxScore    float64               `json:"maxScore"`
+	Rubric      List[RubricCriterion] `json:"rubric" gorm:"type:text"`
+	Published   bool                  `json:"published"`
+}
+
+// RubricCriterion is one graded part of an assignment, the points of a rubric add up to the max score.
+type RubricCriterion struct {
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Points      float64 `json:"points"`
+}
+
+// Submission is one version of the work of a user, resubmitting adds a new version
+// and keeps the previous ones with their grades.
+type Submission struct {
+	Base
+	AssignmentID string        `json:"assignmentId" gorm:"uniqueIndex:idx_submission_version"`
+	Assignment   Assignment    `json:"-" gorm:"foreignKey:AssignmentID;constraint:OnDelete:CASCADE"`
+	UserID       string        `json:"userId" gorm:"uniqueIndex:idx_submission_version"`
+	User         User          `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	TrainingID   string        `json:"trainingId" gorm:"index"`
+	Version      int           `json:"version" gorm:"uniqueIndex:idx_submission_version"`
+	Text         string        `json:"text"`
+	Files        []File        `json:"files" gorm:"foreignKey:SubmissionID;constraint:OnDelete:CASCADE"`
+	Late         bool          `json:"late"`
+	Score        *float64      `json:"score"`
+	RubricScores List[float64] `json:"rubricScores" gorm:"type:text"`
+	Feedback     string        `json:"feedback"`
+	GradedAt     *time.Time    `json:"gradedAt"`
+	GradedByID   *string       `json:"gradedById"`
+}
+
+const (
+	MissingSubmission   = "missing"
+	SubmittedSubmission = "submitted"
+	GradedSubmission    = "graded"
+)
+
+// Status tells whether the submission is waiting to be graded.
+func (s *Submission) Status() string {
+	if s.GradedAt != nil {
+		return GradedSubmission
+	}
+
+	return SubmittedSubmission
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Attendance records whether a user attended one occurrence of a session.
+type Attendance struct {
+	Base
+	SessionID    string    `json:"sessionId" gorm:"uniqueIndex:idx_attendance_occurrence_user"`
+	Session      Session   `json:"-" gorm:"foreignKey:SessionID;constraint:OnDelete:CASCADE"`
+	OccurrenceAt time.Time `json:"occurrenceAt" gorm:"uniqueIndex:idx_attendance_occurrence_user"`
+	UserID       string    `json:"userId" gorm:"uniqueIndex:idx_attendance_occurrence_user;index"`
+	User         User      `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Status       string    `json:"status"`
+	Method       string    `json:"method"`
+	MarkedByID   string    `json:"markedById"`
+}
+
+const (
+	PresentAttendance = "present"
+	AbsentAttendance  = "absent"
+	ExcusedAttendance = "excused"
+)
+
+const (
+	CodeCheckIn   = "code"
+	ManualCheckIn = "manual"
+)
@@ -20,3 +20,8 @@ func (b *Base) BeforeCreate(tx *gorm.DB) (err error) {
 	}
 	return
 }
+
+// PageKey returns the columns used for keyset pagination.
+func (b Base) PageKey() (time.Time, string) {
+	return b.CreatedAt, b.ID
+}
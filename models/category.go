@@ -0,0 +1,174 @@
+package models
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type Category struct {
+	Base
+	Slug     string     `json:"slug" gorm:"uniqueIndex"`
+	Name     string     `json:"name"`
+	Icon     string     `json:"icon"`
+	ParentID *string    `json:"parentId"`
+	Parent   *Category  `json:"parent,omitempty" gorm:"foreignKey:ParentID"`
+	Children []Category `json:"children,omitempty" gorm:"foreignKey:ParentID"`
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify turns a name into a lowercase, dash separated identifier.
+func Slugify(name string) string {
+	slug := nonSlugChars.ReplaceAllString(strings.ToLower(name), "-")
+	return strings.Trim(slug, "-")
+}
+
+// migrateCategories folds the free-form category strings trainings used to have
+// into Category rows. Spellings that only differ by case, punctuation or a single
+// typo end up in the same category, the most used spelling gives it its name.
+func migrateCategories(db *gorm.DB) {
+	if !db.Migrator().HasColumn(&Training{}, "category") {
+		return
+	}
+
+	log.Info().Msg("Migrating training categories")
+
+	var legacy []struct {
+		Category string
+		Count    int
+	}
+
+	err := db.Table("trainings").
+		Select("category, COUNT(*) AS count").
+		Where("category IS NOT NULL AND category <> ''").
+		Group("category").
+		Scan(&legacy).Error
+	if err != nil {
+		log.Err(err).Msg("Failed to read legacy categories")
+		return
+	}
+
+	// Group spellings by slug, so the most used slugs are created first
+	// and rarer near-duplicates get folded into them.
+	type spellings struct {
+		slug   string
+		count  int
+		values []string
+	}
+
+	bySlug := map[string]*spellings{}
+	var ordered []*spellings
+
+	sort.SliceStable(legacy, func(i, j int) bool {
+		return legacy[i].Count > legacy[j].Count
+	})
+
+	for _, l := range legacy {
+		slug := Slugify(l.Category)
+		if slug == "" {
+			continue
+		}
+
+		s, ok := bySlug[slug]
+		if !ok {
+			s = &spellings{slug: slug}
+			bySlug[slug] = s
+			ordered = append(ordered, s)
+		}
+
+		s.count += l.Count
+		s.values = append(s.values, l.Category)
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].count > ordered[j].count
+	})
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var categories []Category
+		if err := tx.Find(&categories).Error; err != nil {
+			return err
+		}
+
+		for _, s := range ordered {
+			category, ok := findSimilarCategory(categories, s.slug)
+			if !ok {
+				category = Category{Slug: s.slug, Name: strings.TrimSpace(s.values[0])}
+				if err := tx.Create(&category).Error; err != nil {
+					return err
+				}
+				categories = append(categories, category)
+			}
+
+			err := tx.Table("trainings").
+				Where("category IN ? AND category_id IS NULL", s.values).
+				Update("category_id", category.ID).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Migrator().DropColumn(&Training{}, "category")
+	})
+	if err != nil {
+		log.Err(err).Msg("Failed to migrate legacy categories")
+	}
+}
+
+func findSimilarCategory(categories []Category, slug string) (Category, bool) {
+	for _, c := range categories {
+		if c.Slug == slug {
+			return c, true
+		}
+	}
+
+	// Only fold typos in words long enough for a single edit to be a typo.
+	if len(slug) < 5 {
+		return Category{}, false
+	}
+
+	for _, c := range categories {
+		if len(c.Slug) >= 5 && editDistance(c.Slug, slug) <= 1 {
+			return c, true
+		}
+	}
+
+	return Category{}, false
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minOf(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+func minOf(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
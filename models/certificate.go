@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Certificate is issued once per user and training. The names are kept as they were
+// at issue time, so the certificate still verifies after a training or user is renamed.
+type Certificate struct {
+	Base
+	Serial       string    `json:"serial" gorm:"uniqueIndex"`
+	TrainingID   string    `json:"trainingId" gorm:"uniqueIndex:idx_certificate_training_user"`
+	Training     Training  `json:"-" gorm:"foreignKey:TrainingID;constraint:OnDelete:CASCADE"`
+	UserID       string    `json:"userId" gorm:"uniqueIndex:idx_certificate_training_user"`
+	User         User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	FullName     string    `json:"fullName"`
+	TrainingName string    `json:"trainingName"`
+	IssuedAt     time.Time `json:"issuedAt"`
+	IssuedByID   *string   `json:"issuedById"`
+	Url          string    `json:"url"`
+}
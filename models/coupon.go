@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Coupon gives a discount on the price of trainings. It applies to a single training,
+// to the trainings of a category or, when neither is set, to every training.
+type Coupon struct {
+	Base
+	Code           string     `json:"code" gorm:"uniqueIndex"`
+	Type           string     `json:"type"`
+	Value          int        `json:"value"`
+	TrainingID     *string    `json:"trainingId" gorm:"index"`
+	Training       *Training  `json:"-" gorm:"foreignKey:TrainingID;constraint:OnDelete:CASCADE"`
+	CategoryID     *string    `json:"categoryId" gorm:"index"`
+	Category       *Category  `json:"-" gorm:"foreignKey:CategoryID;constraint:OnDelete:CASCADE"`
+	CreatedByID    string     `json:"createdById"`
+	StartsAt       *time.Time `json:"startsAt"`
+	EndsAt         *time.Time `json:"endsAt"`
+	MaxUses        int        `json:"maxUses"`
+	MaxUsesPerUser int        `json:"maxUsesPerUser"`
+
+	// Usage of the coupon by paid orders.
+	Uses     int64 `json:"uses" gorm:"-"`
+	Revenue  int64 `json:"revenue" gorm:"-"`
+	Discount int64 `json:"discount" gorm:"-"`
+}
+
+const (
+	PercentCoupon = "percent"
+	FixedCoupon   = "fixed"
+)
+
+// DiscountOn returns the discount of the coupon on an amount, never more than the amount itself.
+func (c *Coupon) DiscountOn(amount int) int {
+	discount := c.Value
+	if c.Type == PercentCoupon {
+		discount = amount * c.Value / 100
+	}
+
+	if discount > amount {
+		return amount
+	}
+
+	return discount
+}
+
+// IsActive reports whether the coupon can be used at now.
+func (c *Coupon) IsActive(now time.Time) bool {
+	if c.StartsAt != nil && now.Before(*c.StartsAt) {
+		return false
+	}
+
+	return c.EndsAt == nil || now.Before(*c.EndsAt)
+}
+
+// AppliesTo reports whether the coupon can be used for a training.
+func (c *Coupon) AppliesTo(training Training) bool {
+	if c.TrainingID != nil {
+		return *c.TrainingID == training.ID
+	}
+
+	if c.CategoryID != nil {
+		return training.CategoryID != nil && *c.CategoryID == *training.CategoryID
+	}
+
+	return true
+}
@@ -0,0 +1,29 @@
+package models
+
+import "testing"
+
+func TestCouponDiscountOn(t *testing.T) {
+	tests := []struct {
+		name   string
+		coupon Coupon
+		amount int
+		want   int
+	}{
+		{"percent", Coupon{Type: PercentCoupon, Value: 20}, 1000, 200},
+		{"percent rounds down", Coupon{Type: PercentCoupon, Value: 15}, 999, 149},
+		{"whole percent", Coupon{Type: PercentCoupon, Value: 100}, 1000, 1000},
+		{"percent over the amount", Coupon{Type: PercentCoupon, Value: 150}, 1000, 1000},
+		{"fixed", Coupon{Type: FixedCoupon, Value: 300}, 1000, 300},
+		{"fixed over the amount", Coupon{Type: FixedCoupon, Value: 1500}, 1000, 1000},
+		{"free training", Coupon{Type: FixedCoupon, Value: 300}, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.coupon.DiscountOn(test.amount)
+			if got != test.want {
+				t.Errorf("DiscountOn(%d) = %d, want %d", test.amount, got, test.want)
+			}
+		})
+	}
+}
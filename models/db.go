@@ -2,13 +2,17 @@ package models
 
 import (
 	"os"
+	"strings"
 	"sync"
 
+	"github.com/glebarez/sqlite"
 	"github.com/rs/zerolog/log"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+const sqlitePrefix = "sqlite://"
+
 var once sync.Once
 var database *gorm.DB
 
@@ -24,16 +28,91 @@ func initDB() *gorm.DB {
 
 	dsn := os.Getenv("DATABASE_URL")
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(dialector(dsn), &gorm.Config{})
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to connect to database")
 	}
 
+	db.SetupJoinTable(&Training{}, "Users", &Member{})
+	db.SetupJoinTable(&User{}, "Trainings", &Member{})
+
 	db.AutoMigrate(&User{})
+	db.AutoMigrate(&Category{})
+	db.AutoMigrate(&Tag{})
 	db.AutoMigrate(&Training{})
 	db.AutoMigrate(&Post{})
 	db.AutoMigrate(&File{})
 	db.AutoMigrate(&Comment{})
+	db.AutoMigrate(&Enrollment{})
+	db.AutoMigrate(&EnrollmentEvent{})
+	db.AutoMigrate(&Invite{})
+	db.AutoMigrate(&WaitlistEntry{})
+	db.AutoMigrate(&Session{})
+	db.AutoMigrate(&Attendance{})
+	db.AutoMigrate(&NotificationPreference{})
+	db.AutoMigrate(&Module{})
+	db.AutoMigrate(&Lesson{})
+	db.AutoMigrate(&LessonProgress{})
+	db.AutoMigrate(&Quiz{})
+	db.AutoMigrate(&Question{})
+	db.AutoMigrate(&QuizAttempt{})
+	db.AutoMigrate(&Assignment{})
+	db.AutoMigrate(&Submission{})
+	db.AutoMigrate(&Certificate{})
+	db.AutoMigrate(&Review{})
+	db.AutoMigrate(&ReviewReport{})
+	db.AutoMigrate(&Coupon{})
+	db.AutoMigrate(&Order{})
+	db.AutoMigrate(&PaymentEvent{})
+	db.AutoMigrate(&Invoice{})
+	db.AutoMigrate(&Refund{})
+	db.AutoMigrate(&PointsEntry{})
+	db.AutoMigrate(&PointsRule{})
+
+	migrateCategories(db)
+	migrateOwnerMembers(db)
+	migratePointsRules(db)
+	migratePointsLedger(db)
+
+	if db.Dialector.Name() == "postgres" {
+		db.Exec("CREATE INDEX IF NOT EXISTS idx_trainings_search ON trainings USING GIN (" + TrainingSearchVector + ")")
+	}
 
 	return db
 }
+
+// dialector picks the database driver based on the connection string.
+// Connection strings starting with sqlite:// open a SQLite file database,
+// everything else is treated as a Postgres DSN.
+func dialector(dsn string) gorm.Dialector {
+	if strings.HasPrefix(dsn, sqlitePrefix) {
+		log.Info().Msg("Using sqlite database")
+		return sqlite.Open(sqliteDSN(strings.TrimPrefix(dsn, sqlitePrefix)))
+	}
+
+	return postgres.Open(dsn)
+}
+
+// sqliteDSN enables foreign keys and makes concurrent writers wait for each other
+// instead of failing, unless the connection string already configures them.
+func sqliteDSN(dsn string) string {
+	defaults := []struct{ key, param string }{
+		{"foreign_keys", "_pragma=foreign_keys(1)"},
+		{"busy_timeout", "_pragma=busy_timeout(5000)"},
+		{"_txlock", "_txlock=immediate"},
+	}
+
+	for _, d := range defaults {
+		if strings.Contains(dsn, d.key) {
+			continue
+		}
+
+		if strings.Contains(dsn, "?") {
+			dsn += "&" + d.param
+		} else {
+			dsn += "?" + d.param
+		}
+	}
+
+	return dsn
+}
@@ -0,0 +1,30 @@
+package models
+
+type Enrollment struct {
+	Base
+	TrainingID string            `json:"trainingId" gorm:"index"`
+	Training   Training          `json:"training" gorm:"foreignKey:TrainingID;constraint:OnDelete:CASCADE"`
+	UserID     string            `json:"userId" gorm:"index"`
+	User       User              `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Status     string            `json:"status" gorm:"index"`
+	Message    string            `json:"message"`
+	Reason     string            `json:"reason"`
+	Events     []EnrollmentEvent `json:"events,omitempty" gorm:"foreignKey:EnrollmentID;constraint:OnDelete:CASCADE"`
+}
+
+// EnrollmentEvent records a single status change of an enrollment request.
+type EnrollmentEvent struct {
+	Base
+	EnrollmentID string `json:"enrollmentId" gorm:"index"`
+	ActorID      string `json:"actorId"`
+	FromStatus   string `json:"fromStatus"`
+	ToStatus     string `json:"toStatus"`
+	Reason       string `json:"reason"`
+}
+
+const (
+	PendingEnrollment   = "pending"
+	ApprovedEnrollment  = "approved"
+	RejectedEnrollment  = "rejected"
+	WithdrawnEnrollment = "withdrawn"
+)
@@ -2,9 +2,13 @@ package models
 
 type File struct {
 	Base
-	PostID string `json:"postId"`
-	Post   Post   `json:"post" gorm:"foreignKey:PostID"`
-	Name   string `json:"name"`
-	Url    string `json:"url"`
-	Ext    string `json:"ext"`
+	PostID       *string `json:"postId"`
+	Post         Post    `json:"post" gorm:"foreignKey:PostID"`
+	LessonID     *string `json:"lessonId" gorm:"index"`
+	SubmissionID *string `json:"submissionId" gorm:"index"`
+	Name         string  `json:"name"`
+	Url          string  `json:"url"`
+	Ext          string  `json:"ext"`
+	// Key is the name of the blob when it differs from the name of the file.
+	Key string `json:"-"`
 }
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+type Invite struct {
+	Base
+	TrainingID  string     `json:"trainingId" gorm:"index"`
+	Training    Training   `json:"training" gorm:"foreignKey:TrainingID;constraint:OnDelete:CASCADE"`
+	Code        string     `json:"code" gorm:"uniqueIndex"`
+	Email       string     `json:"email"`
+	Role        string     `json:"role"`
+	MaxUses     int        `json:"maxUses"`
+	Uses        int        `json:"uses"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	RevokedAt   *time.Time `json:"revokedAt"`
+	CreatedByID string     `json:"createdById"`
+}
+
+// IsUsable reports whether the invite can still be redeemed.
+func (i *Invite) IsUsable() bool {
+	if i.RevokedAt != nil {
+		return false
+	}
+
+	if i.ExpiresAt != nil && i.ExpiresAt.Before(time.Now()) {
+		return false
+	}
+
+	return i.MaxUses == 0 || i.Uses < i.MaxUses
+}
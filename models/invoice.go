@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Invoice is issued once for every paid order. It keeps a copy of the buyer and training
+// details, so it reads the same after they change.
+type Invoice struct {
+	Base
+	Number       string    `json:"number" gorm:"uniqueIndex"`
+	OrderID      string    `json:"orderId" gorm:"uniqueIndex"`
+	Order        Order     `json:"-" gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE"`
+	UserID       string    `json:"userId" gorm:"index"`
+	TrainingID   string    `json:"trainingId" gorm:"index"`
+	SellerName   string    `json:"sellerName"`
+	BuyerName    string    `json:"buyerName"`
+	BuyerEmail   string    `json:"buyerEmail"`
+	TrainingName string    `json:"trainingName"`
+	Price        int       `json:"price"`
+	Discount     int       `json:"discount"`
+	Amount       int       `json:"amount"`
+	Currency     string    `json:"currency"`
+	IssuedAt     time.Time `json:"issuedAt"`
+	// Key is the blob the PDF is stored under, only the PDF endpoint serves it.
+	Key string `json:"-"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Module groups the lessons of a training, modules and their lessons are ordered by Position.
+type Module struct {
+	Base
+	TrainingID  string   `json:"trainingId" gorm:"index"`
+	Training    Training `json:"-" gorm:"foreignKey:TrainingID;constraint:OnDelete:CASCADE"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Position    int      `json:"position"`
+	Lessons     []Lesson `json:"lessons" gorm:"foreignKey:ModuleID;constraint:OnDelete:CASCADE"`
+}
+
+type Lesson struct {
+	Base
+	ModuleID         string     `json:"moduleId" gorm:"index"`
+	TrainingID       string     `json:"trainingId" gorm:"index"`
+	Title            string     `json:"title"`
+	Body             string     `json:"body"`
+	Files            []File     `json:"files" gorm:"foreignKey:LessonID;constraint:OnDelete:CASCADE"`
+	Duration         int        `json:"duration"`
+	Published        bool       `json:"published" gorm:"index"`
+	Position         int        `json:"position"`
+	ReleaseAt        *time.Time `json:"releaseAt"`
+	RequiresPrevious bool       `json:"requiresPrevious"`
+
+	// Locked is set for learners when the lesson isn't released to them yet.
+	Locked bool `json:"locked" gorm:"-"`
+}
+
+// LessonProgress records how far a user got with a lesson.
+type LessonProgress struct {
+	Base
+	LessonID    string     `json:"lessonId" gorm:"uniqueIndex:idx_lesson_progress_user"`
+	Lesson      Lesson     `json:"-" gorm:"foreignKey:LessonID;constraint:OnDelete:CASCADE"`
+	UserID      string     `json:"userId" gorm:"uniqueIndex:idx_lesson_progress_user;index:idx_lesson_progress_training_user"`
+	User        User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	TrainingID  string     `json:"trainingId" gorm:"index:idx_lesson_progress_training_user"`
+	StartedAt   time.Time  `json:"startedAt"`
+	OpenedAt    time.Time  `json:"openedAt"`
+	CompletedAt *time.Time `json:"completedAt"`
+}
+
+// IsReleased reports whether the lesson is published and its release date, if any, has passed.
+func (l *Lesson) IsReleased(now time.Time) bool {
+	return l.Published && (l.ReleaseAt == nil || !l.ReleaseAt.After(now))
+}
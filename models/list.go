@@ -0,0 +1,37 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// List is a slice stored as a JSON text column.
+type List[T any] []T
+
+func (l List[T]) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+
+	data, err := json.Marshal([]T(l))
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func (l *List[T]) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*l = List[T]{}
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), (*[]T)(l))
+	case []byte:
+		return json.Unmarshal(v, (*[]T)(l))
+	default:
+		return errors.New("unsupported list value")
+	}
+}
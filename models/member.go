@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// Member is the training_users join table, it holds the role a user has in a training.
+type Member struct {
+	TrainingID string    `json:"trainingId" gorm:"primaryKey"`
+	UserID     string    `json:"userId" gorm:"primaryKey"`
+	User       User      `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Role       string    `json:"role" gorm:"default:student"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func (Member) TableName() string {
+	return "training_users"
+}
+
+const (
+	OwnerMember      = "owner"
+	CoOwnerMember    = "co-owner"
+	InstructorMember = "instructor"
+	AssistantMember  = "assistant"
+	StudentMember    = "student"
+)
+
+// CanManage reports whether the member can manage the training and its members.
+func (m *Member) CanManage() bool {
+	return m.Role == OwnerMember || m.Role == CoOwnerMember
+}
+
+// CanPost reports whether the member can write posts in the training.
+func (m *Member) CanPost() bool {
+	return m.CanManage() || m.Role == InstructorMember
+}
+
+// CanModerate reports whether the member can remove posts and comments of others.
+func (m *Member) CanModerate() bool {
+	return m.CanPost() || m.Role == AssistantMember
+}
+
+// migrateOwnerMembers gives training owners the owner role on their membership,
+// rows created before members had roles default to student.
+func migrateOwnerMembers(db *gorm.DB) {
+	err := db.Exec("UPDATE training_users SET role = ? "+
+		"WHERE role <> ? AND user_id = (SELECT owner_id FROM trainings WHERE trainings.id = training_users.training_id)",
+		OwnerMember, OwnerMember).Error
+	if err != nil {
+		log.Err(err).Msg("Failed to migrate training owners")
+	}
+}
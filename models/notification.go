@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// NotificationPreference holds the emails a user opted in to, users without a row get the defaults.
+type NotificationPreference struct {
+	UserID       string    `json:"userId" gorm:"primaryKey"`
+	User         User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	DayReminder  bool      `json:"dayReminder"`
+	HourReminder bool      `json:"hourReminder"`
+	OwnerDigest  bool      `json:"ownerDigest"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+func DefaultNotificationPreference(userID string) NotificationPreference {
+	return NotificationPreference{
+		UserID:       userID,
+		DayReminder:  true,
+		HourReminder: true,
+		OwnerDigest:  true,
+	}
+}
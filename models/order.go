@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+type Order struct {
+	Base
+	TrainingID  string     `json:"trainingId" gorm:"index"`
+	Training    Training   `json:"training" gorm:"foreignKey:TrainingID;constraint:OnDelete:CASCADE"`
+	UserID      string     `json:"userId" gorm:"index"`
+	User        User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Price       int        `json:"price"`
+	CouponID    *string    `json:"couponId" gorm:"index"`
+	Coupon      *Coupon    `json:"coupon,omitempty" gorm:"foreignKey:CouponID;constraint:OnDelete:SET NULL"`
+	Discount    int        `json:"discount"`
+	Amount      int        `json:"amount"`
+	Refunded    int        `json:"refunded"`
+	Currency    string     `json:"currency"`
+	Status      string     `json:"status" gorm:"index"`
+	Provider    string     `json:"provider"`
+	ProviderRef string     `json:"-" gorm:"index"`
+	CheckoutUrl string     `json:"checkoutUrl"`
+	PaidAt      *time.Time `json:"paidAt"`
+	EnrolledAt  *time.Time `json:"enrolledAt"`
+}
+
+// PaymentEvent records a webhook event of the payment provider once it was handled,
+// so retried deliveries of the same event are ignored.
+type PaymentEvent struct {
+	Base
+	EventID string `json:"eventId" gorm:"uniqueIndex"`
+	OrderID string `json:"orderId" gorm:"index"`
+	Type    string `json:"type"`
+}
+
+const (
+	PendingOrder  = "pending"
+	PaidOrder     = "paid"
+	FailedOrder   = "failed"
+	RefundedOrder = "refunded"
+)
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PointsEntry is a line of the append-only points ledger, User.Points caches the sum of the entries of a user.
+// A source is awarded once for the same reason, so replayed events don't add up.
+type PointsEntry struct {
+	Base
+	UserID     string  `json:"userId" gorm:"uniqueIndex:idx_points_source;index"`
+	User       User    `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	TrainingID *string `json:"trainingId" gorm:"index"`
+	Delta      int     `json:"delta"`
+	Reason     string  `json:"reason" gorm:"uniqueIndex:idx_points_source"`
+	SourceID   string  `json:"sourceId" gorm:"uniqueIndex:idx_points_source"`
+}
+
+// PointsRule configures how many points an event is worth. DailyLimit caps
+// how many times a user is awarded for the event per day, 0 means no limit.
+type PointsRule struct {
+	Event      string `json:"event" gorm:"primaryKey"`
+	Points     int    `json:"points"`
+	DailyLimit int    `json:"dailyLimit"`
+}
+
+const (
+	PostPoints       = "post"
+	CommentPoints    = "comment"
+	LessonPoints     = "lesson"
+	AttendancePoints = "attendance"
+	QuizPoints       = "quiz"
+	OpeningPoints    = "opening_balance"
+)
+
+// defaultPointsRules are created once, admins can change them afterwards.
+var defaultPointsRules = []PointsRule{
+	{Event: PostPoints, Points: 10, DailyLimit: 5},
+	{Event: CommentPoints, Points: 2, DailyLimit: 20},
+	{Event: LessonPoints, Points: 5},
+	{Event: AttendancePoints, Points: 10},
+}
+
+func migratePointsRules(db *gorm.DB) {
+	err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&defaultPointsRules).Error
+	if err != nil {
+		log.Err(err).Msg("Failed to create points rules")
+	}
+}
+
+// migratePointsLedger opens the ledger of users that got points before it existed,
+// so their balance still adds up to the sum of their entries.
+func migratePointsLedger(db *gorm.DB) {
+	var users []User
+	db.Where("points <> 0 AND NOT EXISTS (SELECT 1 FROM points_entries WHERE points_entries.user_id = users.id)").Find(&users)
+
+	for _, user := range users {
+		entry := PointsEntry{
+			UserID:   user.ID,
+			Delta:    user.Points,
+			Reason:   OpeningPoints,
+			SourceID: user.ID,
+		}
+
+		err := db.Omit("User").Create(&entry).Error
+		if err != nil {
+			log.Err(err).Msg("Failed to open points ledger")
+		}
+	}
+}
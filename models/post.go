@@ -10,4 +10,6 @@ type Post struct {
 	Text       string    `json:"text"`
 	Files      []File    `json:"files" gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
 	Comments   []Comment `json:"comments" gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+
+	CommentCount int `json:"commentCount" gorm:"-"`
 }
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// Quiz belongs to a training and optionally to one of its lessons.
+type Quiz struct {
+	Base
+	TrainingID  string     `json:"trainingId" gorm:"index"`
+	Training    Training   `json:"-" gorm:"foreignKey:TrainingID;constraint:OnDelete:CASCADE"`
+	LessonID    *string    `json:"lessonId" gorm:"index"`
+	Lesson      *Lesson    `json:"-" gorm:"foreignKey:LessonID;constraint:OnDelete:SET NULL"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	TimeLimit   int        `json:"timeLimit"`
+	MaxAttempts int        `json:"maxAttempts"`
+	Shuffle     bool       `json:"shuffle"`
+	PassPercent float64    `json:"passPercent"`
+	Points      int        `json:"points"`
+	Published   bool       `json:"published"`
+	Questions   []Question `json:"questions" gorm:"foreignKey:QuizID;constraint:OnDelete:CASCADE"`
+
+	QuestionCount int `json:"questionCount" gorm:"-"`
+}
+
+// Question holds its answer key next to the question, it is cleared before a quiz is shown to learners.
+type Question struct {
+	Base
+	QuizID   string       `json:"quizId" gorm:"index"`
+	Type     string       `json:"type"`
+	Text     string       `json:"text"`
+	Position int          `json:"position"`
+	Points   float64      `json:"points"`
+	Options  List[string] `json:"options" gorm:"type:text"`
+
+	Correct   List[int]    `json:"correct,omitempty" gorm:"type:text"`
+	Accepted  List[string] `json:"accepted,omitempty" gorm:"type:text"`
+	Answer    *float64     `json:"answer,omitempty"`
+	Tolerance float64      `json:"tolerance,omitempty"`
+}
+
+const (
+	SingleChoiceQuestion   = "single"
+	MultipleChoiceQuestion = "multiple"
+	TrueFalseQuestion      = "true_false"
+	ShortTextQuestion      = "short_text"
+	NumericQuestion        = "numeric"
+)
+
+// HideAnswers clears the answer key of the question.
+func (q *Question) HideAnswers() {
+	q.Correct = nil
+	q.Accepted = nil
+	q.Answer = nil
+	q.Tolerance = 0
+}
+
+// Grade reports whether the answer is right. Multiple choice questions have to be answered
+// with exactly the correct options, short text answers ignore case and extra spaces.
+func (q *Question) Grade(answer Answer) bool {
+	switch q.Type {
+	case SingleChoiceQuestion, TrueFalseQuestion, MultipleChoiceQuestion:
+		if len(answer.Choices) != len(q.Correct) {
+			return false
+		}
+
+		chosen := map[int]bool{}
+		for _, choice := range answer.Choices {
+			chosen[choice] = true
+		}
+
+		for _, correct := range q.Correct {
+			if !chosen[correct] {
+				return false
+			}
+		}
+
+		return len(chosen) == len(q.Correct)
+	case ShortTextQuestion:
+		text := normalizeAnswer(answer.Text)
+		for _, accepted := range q.Accepted {
+			if text != "" && text == normalizeAnswer(accepted) {
+				return true
+			}
+		}
+
+		return false
+	case NumericQuestion:
+		return q.Answer != nil && answer.Number != nil && math.Abs(*answer.Number-*q.Answer) <= q.Tolerance
+	}
+
+	return false
+}
+
+func normalizeAnswer(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+// Answer is what a learner answered to a question, graded when the attempt is submitted.
+type Answer struct {
+	QuestionID string   `json:"questionId"`
+	Choices    []int    `json:"choices,omitempty"`
+	Text       string   `json:"text,omitempty"`
+	Number     *float64 `json:"number,omitempty"`
+	Correct    bool     `json:"correct"`
+	Points     float64  `json:"points"`
+}
+
+// IsGiven reports whether the question was answered at all.
+func (a *Answer) IsGiven() bool {
+	return len(a.Choices) > 0 || strings.TrimSpace(a.Text) != "" || a.Number != nil
+}
+
+// QuizAttempt keeps the questions in the order they were shown, so a shuffled quiz
+// looks the same when the attempt is opened again.
+type QuizAttempt struct {
+	Base
+	QuizID      string       `json:"quizId" gorm:"index:idx_quiz_attempt_user"`
+	Quiz        Quiz         `json:"-" gorm:"foreignKey:QuizID;constraint:OnDelete:CASCADE"`
+	UserID      string       `json:"userId" gorm:"index:idx_quiz_attempt_user"`
+	User        User         `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Order       List[string] `json:"order" gorm:"type:text"`
+	Answers     List[Answer] `json:"answers" gorm:"type:text"`
+	DeadlineAt  *time.Time   `json:"deadlineAt"`
+	SubmittedAt *time.Time   `json:"submittedAt"`
+	Expired     bool         `json:"expired"`
+	Score       float64      `json:"score"`
+	MaxScore    float64      `json:"maxScore"`
+	Percent     float64      `json:"percent"`
+	Passed      bool         `json:"passed"`
+
+	Questions []Question `json:"questions,omitempty" gorm:"-"`
+}
+
+// IsOpen reports whether answers can still be submitted, a short grace period covers the trip to the server.
+func (a *QuizAttempt) IsOpen(now time.Time) bool {
+	return a.SubmittedAt == nil && (a.DeadlineAt == nil || now.Before(a.DeadlineAt.Add(30*time.Second)))
+}
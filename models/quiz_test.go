@@ -0,0 +1,58 @@
+package models
+
+import "testing"
+
+func TestQuestionGrade(t *testing.T) {
+	number := func(n float64) *float64 {
+		return &n
+	}
+
+	single := Question{Type: SingleChoiceQuestion, Correct: List[int]{1}}
+	multiple := Question{Type: MultipleChoiceQuestion, Correct: List[int]{0, 2}}
+	trueFalse := Question{Type: TrueFalseQuestion, Correct: List[int]{0}}
+	shortText := Question{Type: ShortTextQuestion, Accepted: List[string]{"Merry Christmas", "xmas"}}
+	numeric := Question{Type: NumericQuestion, Answer: number(3.14), Tolerance: 0.01}
+	exact := Question{Type: NumericQuestion, Answer: number(42)}
+
+	tests := []struct {
+		name     string
+		question Question
+		answer   Answer
+		want     bool
+	}{
+		{"single right", single, Answer{Choices: []int{1}}, true},
+		{"single wrong", single, Answer{Choices: []int{0}}, false},
+		{"single unanswered", single, Answer{}, false},
+		{"single with extra choice", single, Answer{Choices: []int{1, 0}}, false},
+		{"multiple right", multiple, Answer{Choices: []int{0, 2}}, true},
+		{"multiple in any order", multiple, Answer{Choices: []int{2, 0}}, true},
+		{"multiple partly right", multiple, Answer{Choices: []int{0}}, false},
+		{"multiple with wrong choice", multiple, Answer{Choices: []int{0, 1}}, false},
+		{"multiple with repeated choice", multiple, Answer{Choices: []int{0, 0}}, false},
+		{"multiple with every choice", multiple, Answer{Choices: []int{0, 1, 2}}, false},
+		{"true false right", trueFalse, Answer{Choices: []int{0}}, true},
+		{"true false wrong", trueFalse, Answer{Choices: []int{1}}, false},
+		{"short text right", shortText, Answer{Text: "merry christmas"}, true},
+		{"short text with extra spaces", shortText, Answer{Text: "  Merry   CHRISTMAS "}, true},
+		{"short text other accepted", shortText, Answer{Text: "XMAS"}, true},
+		{"short text wrong", shortText, Answer{Text: "happy new year"}, false},
+		{"short text empty", shortText, Answer{Text: "   "}, false},
+		{"numeric exact", numeric, Answer{Number: number(3.14)}, true},
+		{"numeric within tolerance", numeric, Answer{Number: number(3.145)}, true},
+		{"numeric outside tolerance", numeric, Answer{Number: number(3.2)}, false},
+		{"numeric unanswered", numeric, Answer{}, false},
+		{"numeric without tolerance", exact, Answer{Number: number(42)}, true},
+		{"numeric off without tolerance", exact, Answer{Number: number(42.5)}, false},
+		{"numeric without answer key", Question{Type: NumericQuestion}, Answer{Number: number(0)}, false},
+		{"unknown type", Question{Type: "essay", Correct: List[int]{0}}, Answer{Choices: []int{0}}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.question.Grade(test.answer)
+			if got != test.want {
+				t.Errorf("Grade() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Refund is a request of a buyer to get the money of an order back and leave the training.
+type Refund struct {
+	Base
+	OrderID     string     `json:"orderId" gorm:"index"`
+	Order       Order      `json:"order" gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE"`
+	TrainingID  string     `json:"trainingId" gorm:"index"`
+	UserID      string     `json:"userId" gorm:"index"`
+	User        User       `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Amount      int        `json:"amount"`
+	Reason      string     `json:"reason"`
+	Status      string     `json:"status" gorm:"index"`
+	Note        string     `json:"note"`
+	ProviderRef string     `json:"-"`
+	DecidedByID *string    `json:"decidedById"`
+	DecidedAt   *time.Time `json:"decidedAt"`
+}
+
+const (
+	PendingRefund  = "pending"
+	ApprovedRefund = "approved"
+	RejectedRefund = "rejected"
+)
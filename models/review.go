@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type Review struct {
+	Base
+	TrainingID  string     `json:"trainingId" gorm:"uniqueIndex:idx_review_training_user"`
+	Training    Training   `json:"-" gorm:"foreignKey:TrainingID;constraint:OnDelete:CASCADE"`
+	UserID      string     `json:"userId" gorm:"uniqueIndex:idx_review_training_user"`
+	User        User       `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Rating      int        `json:"rating"`
+	Text        string     `json:"text"`
+	Reply       string     `json:"reply"`
+	RepliedAt   *time.Time `json:"repliedAt"`
+	ReportCount int        `json:"reportCount" gorm:"index"`
+}
+
+// ReviewReport flags a review for admins, a user can report a review once.
+type ReviewReport struct {
+	Base
+	ReviewID string `json:"reviewId" gorm:"uniqueIndex:idx_review_report_user"`
+	Review   Review `json:"-" gorm:"foreignKey:ReviewID;constraint:OnDelete:CASCADE"`
+	UserID   string `json:"userId" gorm:"uniqueIndex:idx_review_report_user"`
+	User     User   `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Reason   string `json:"reason"`
+}
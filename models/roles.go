@@ -0,0 +1,64 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Roles is a list of user roles stored as a JSON array,
+// so it works the same way on every supported database.
+type Roles []string
+
+func (r Roles) Value() (driver.Value, error) {
+	if r == nil {
+		return "[]", nil
+	}
+
+	data, err := json.Marshal([]string(r))
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func (r *Roles) Scan(value interface{}) error {
+	var data string
+
+	switch v := value.(type) {
+	case nil:
+		*r = Roles{}
+		return nil
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		return errors.New("unsupported roles value")
+	}
+
+	// Rows written before roles were stored as JSON contain
+	// a Postgres array literal like {user,admin}.
+	if strings.HasPrefix(data, "{") {
+		*r = parseArrayLiteral(data)
+		return nil
+	}
+
+	return json.Unmarshal([]byte(data), (*[]string)(r))
+}
+
+func parseArrayLiteral(data string) Roles {
+	data = strings.Trim(data, "{}")
+	if data == "" {
+		return Roles{}
+	}
+
+	roles := Roles{}
+	for _, role := range strings.Split(data, ",") {
+		roles = append(roles, strings.Trim(role, `"`))
+	}
+
+	return roles
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/teambition/rrule-go"
+)
+
+// Session is a scheduled meeting of a training. StartsAt and EndsAt are the first occurrence,
+// RRule repeats it in TimeZone so weekly classes keep their local time across DST changes.
+type Session struct {
+	Base
+	TrainingID  string    `json:"trainingId" gorm:"index"`
+	Training    Training  `json:"-" gorm:"foreignKey:TrainingID;constraint:OnDelete:CASCADE"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	StartsAt    time.Time `json:"startsAt" gorm:"index"`
+	EndsAt      time.Time `json:"endsAt"`
+	TimeZone    string    `json:"timeZone"`
+	Location    string    `json:"location"`
+	MeetingURL  string    `json:"meetingUrl"`
+	RRule       string    `json:"rrule"`
+}
+
+// TimeLocation returns the time zone of the session, falling back to UTC.
+func (s *Session) TimeLocation() *time.Location {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// Recurrence parses the recurrence rule of the session, it returns nil for one-off sessions.
+func (s *Session) Recurrence() (*rrule.RRule, error) {
+	if s.RRule == "" {
+		return nil, nil
+	}
+
+	option, err := rrule.StrToROption(strings.TrimPrefix(s.RRule, "RRULE:"))
+	if err != nil {
+		return nil, err
+	}
+
+	option.Dtstart = s.StartsAt.In(s.TimeLocation())
+
+	return rrule.NewRRule(*option)
+}
+
+// Occurrences returns the start times of the session that begin within [from, to).
+func (s *Session) Occurrences(from, to time.Time) ([]time.Time, error) {
+	rule, err := s.Recurrence()
+	if err != nil {
+		return nil, err
+	}
+
+	if rule == nil {
+		if !s.StartsAt.Before(from) && s.StartsAt.Before(to) {
+			return []time.Time{s.StartsAt}, nil
+		}
+		return []time.Time{}, nil
+	}
+
+	occurrences := rule.Between(from, to, true)
+	if len(occurrences) > 0 && !occurrences[len(occurrences)-1].Before(to) {
+		occurrences = occurrences[:len(occurrences)-1]
+	}
+
+	return occurrences, nil
+}
+
+// Duration returns how long each occurrence of the session lasts.
+func (s *Session) Duration() time.Duration {
+	return s.EndsAt.Sub(s.StartsAt)
+}
@@ -0,0 +1,6 @@
+package models
+
+type Tag struct {
+	Base
+	Name string `json:"name" gorm:"uniqueIndex"`
+}
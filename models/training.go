@@ -1,12 +1,55 @@
 package models
 
+import "time"
+
 type Training struct {
 	Base
-	Name     string `json:"name"`
-	OwnerID  string `json:"ownerId"`
-	Price    int    `json:"price"`
-	Category string `json:"category"`
-	Users    []User `json:"users" gorm:"many2many:training_users;constraint:OnDelete:CASCADE"`
-	Posts    []Post `json:"posts" gorm:"foreignKey:TrainingID;constraint:OnDelete:CASCADE"`
-	Image    string `json:"image"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	OwnerID     string    `json:"ownerId"`
+	Price       int       `json:"price" gorm:"index"`
+	CategoryID  *string   `json:"categoryId" gorm:"index"`
+	Category    *Category `json:"category,omitempty" gorm:"foreignKey:CategoryID;constraint:OnDelete:SET NULL"`
+	Tags        []Tag     `json:"tags" gorm:"many2many:training_tags;constraint:OnDelete:CASCADE"`
+	Users       []User    `json:"users" gorm:"many2many:training_users;constraint:OnDelete:CASCADE"`
+	Posts       []Post    `json:"posts" gorm:"foreignKey:TrainingID;constraint:OnDelete:CASCADE"`
+	Image       string    `json:"image"`
+	Visibility  string    `json:"visibility" gorm:"default:public;index"`
+	AutoApprove bool      `json:"autoApprove"`
+	Capacity    int       `json:"capacity"`
+
+	// Refund policy of paid enrollments, see RefundAmount.
+	RefundDays        int  `json:"refundDays"`
+	RefundPercent     int  `json:"refundPercent"`
+	RefundBeforeStart bool `json:"refundBeforeStart"`
+
+	// Rating aggregates are kept up to date with the reviews, so trainings can be sorted by rating.
+	RatingAverage float64 `json:"ratingAverage" gorm:"index"`
+	RatingCount   int     `json:"ratingCount"`
 }
+
+// RefundAmount applies the refund policy to an amount paid at paidAt. Buyers get everything back
+// within RefundDays of the payment and RefundPercent of it afterwards. With RefundBeforeStart
+// nothing is refunded once the first session started.
+func (t *Training) RefundAmount(amount int, paidAt time.Time, firstSession *time.Time, now time.Time) int {
+	if t.RefundBeforeStart && firstSession != nil && !now.Before(*firstSession) {
+		return 0
+	}
+
+	if now.Before(paidAt.AddDate(0, 0, t.RefundDays)) {
+		return amount
+	}
+
+	return amount * t.RefundPercent / 100
+}
+
+const (
+	DraftVisibility    = "draft"
+	PrivateVisibility  = "private"
+	UnlistedVisibility = "unlisted"
+	PublicVisibility   = "public"
+)
+
+// TrainingSearchVector is the full-text document of a training on Postgres.
+// It has to match the expression of the search index to be used by the planner.
+const TrainingSearchVector = "to_tsvector('simple', coalesce(trainings.name, '') || ' ' || coalesce(trainings.description, ''))"
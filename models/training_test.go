@@ -0,0 +1,86 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrainingRefundAmount(t *testing.T) {
+	paidAt := time.Date(2022, 12, 1, 12, 0, 0, 0, time.UTC)
+	firstSession := paidAt.AddDate(0, 0, 20)
+
+	tests := []struct {
+		name         string
+		training     Training
+		firstSession *time.Time
+		now          time.Time
+		want         int
+	}{
+		{
+			name:     "within refund days",
+			training: Training{RefundDays: 14, RefundPercent: 50},
+			now:      paidAt.AddDate(0, 0, 13),
+			want:     1000,
+		},
+		{
+			name:     "on the last refund day",
+			training: Training{RefundDays: 14, RefundPercent: 50},
+			now:      paidAt.AddDate(0, 0, 14),
+			want:     500,
+		},
+		{
+			name:     "after refund days",
+			training: Training{RefundDays: 14, RefundPercent: 50},
+			now:      paidAt.AddDate(0, 0, 30),
+			want:     500,
+		},
+		{
+			name:     "no refund days",
+			training: Training{RefundPercent: 25},
+			now:      paidAt,
+			want:     250,
+		},
+		{
+			name:     "no refunds",
+			training: Training{},
+			now:      paidAt.AddDate(0, 0, 1),
+			want:     0,
+		},
+		{
+			name:         "before the first session",
+			training:     Training{RefundDays: 30, RefundBeforeStart: true},
+			firstSession: &firstSession,
+			now:          firstSession.Add(-time.Second),
+			want:         1000,
+		},
+		{
+			name:         "once the first session started",
+			training:     Training{RefundDays: 30, RefundPercent: 100, RefundBeforeStart: true},
+			firstSession: &firstSession,
+			now:          firstSession,
+			want:         0,
+		},
+		{
+			name:     "without sessions",
+			training: Training{RefundDays: 30, RefundBeforeStart: true},
+			now:      paidAt.AddDate(0, 0, 1),
+			want:     1000,
+		},
+		{
+			name:         "started without the policy",
+			training:     Training{RefundDays: 30},
+			firstSession: &firstSession,
+			now:          firstSession.Add(time.Hour),
+			want:         1000,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.training.RefundAmount(1000, paidAt, test.firstSession, test.now)
+			if got != test.want {
+				t.Errorf("RefundAmount() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
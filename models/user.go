@@ -1,7 +1,5 @@
 package models
 
-import "github.com/lib/pq"
-
 type User struct {
 	Base
 	FirstName string `json:"firstName"`
@@ -10,12 +8,17 @@ type User struct {
 	Phone     string `json:"-"`
 	Password  string `json:"-"`
 
-	Roles pq.StringArray `json:"roles" gorm:"type:text[]"`
+	Roles Roles `json:"roles" gorm:"type:text"`
 
 	Trainings []Training `json:"trainings" gorm:"many2many:training_users;constraint:OnDelete:CASCADE"`
 	Comments  []Comment  `json:"comments" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 
+	// Points caches the balance of the points ledger of the user.
 	Points int `json:"points"`
+	// LeaderboardHidden keeps the user out of every leaderboard.
+	LeaderboardHidden bool `json:"leaderboardHidden"`
+
+	CalendarToken *string `json:"-" gorm:"uniqueIndex"`
 }
 
 func (u *User) HasRole(role string) bool {
@@ -0,0 +1,12 @@
+package models
+
+type WaitlistEntry struct {
+	Base
+	TrainingID string   `json:"trainingId" gorm:"uniqueIndex:idx_waitlist_training_user"`
+	Training   Training `json:"-" gorm:"foreignKey:TrainingID;constraint:OnDelete:CASCADE"`
+	UserID     string   `json:"userId" gorm:"uniqueIndex:idx_waitlist_training_user"`
+	User       User     `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Position   int      `json:"position"`
+	// Role is given to the user when they are promoted from the waitlist.
+	Role string `json:"role" gorm:"default:student"`
+}
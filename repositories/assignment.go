@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"sync"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IAssignmentRepository interface {
+	FindByTrainingID(trainingID string) []models.Assignment
+	FindByID(id string) (models.Assignment, error)
+	Create(assignment *models.Assignment) error
+	Update(assignment *models.Assignment) error
+	Delete(assignment *models.Assignment) error
+	FindSubmissions(assignmentID string) []models.Submission
+	FindUserSubmissions(assignmentID, userID string) []models.Submission
+	FindSubmissionsByTrainingID(trainingID string) []models.Submission
+	FindSubmissionByID(id string) (models.Submission, error)
+	CreateSubmission(submission *models.Submission) error
+	UpdateSubmission(submission *models.Submission) error
+}
+
+type AssignmentRepository struct {
+	DB *gorm.DB
+}
+
+var (
+	assignmentOnce       sync.Once
+	assignmentRepository IAssignmentRepository
+)
+
+func GetAssignmentRepository() IAssignmentRepository {
+	assignmentOnce.Do(func() {
+		log.Info().Msg("Initializing assignment repository")
+		assignmentRepository = &AssignmentRepository{
+			DB: models.GetDB(),
+		}
+	})
+	return assignmentRepository
+}
+
+func (r *AssignmentRepository) FindByTrainingID(trainingID string) []models.Assignment {
+	var assignments []models.Assignment
+
+	r.DB.Order("due_at IS NULL, due_at, created_at").Find(&assignments, "training_id = ?", trainingID)
+
+	return assignments
+}
+
+func (r *AssignmentRepository) FindByID(id string) (models.Assignment, error) {
+	var assignment models.Assignment
+	err := r.DB.First(&assignment, "id = ?", id).Error
+
+	return assignment, err
+}
+
+func (r *AssignmentRepository) Create(assignment *models.Assignment) error {
+	return r.DB.Omit("Training").Create(assignment).Error
+}
+
+func (r *AssignmentRepository) Update(assignment *models.Assignment) error {
+	return r.DB.Omit("Training").Save(assignment).Error
+}
+
+func (r *AssignmentRepository) Delete(assignment *models.Assignment) error {
+	return r.DB.Delete(assignment).Error
+}
+
+// FindSubmissions returns every version submitted for the assignment, oldest first.
+func (r *AssignmentRepository) FindSubmissions(assignmentID string) []models.Submission {
+	var submissions []models.Submission
+
+	r.DB.Model(&models.Submission{}).Preload("User").Preload("Files").
+		Order("version").
+		Find(&submissions, "assignment_id = ?", assignmentID)
+
+	return submissions
+}
+
+func (r *AssignmentRepository) FindUserSubmissions(assignmentID, userID string) []models.Submission {
+	var submissions []models.Submission
+
+	r.DB.Model(&models.Submission{}).Preload("User").Preload("Files").
+		Order("version").
+		Find(&submissions, "assignment_id = ? AND user_id = ?", assignmentID, userID)
+
+	return submissions
+}
+
+func (r *AssignmentRepository) FindSubmissionsByTrainingID(trainingID string) []models.Submission {
+	var submissions []models.Submission
+
+	r.DB.Order("version").Find(&submissions, "training_id = ?", trainingID)
+
+	return submissions
+}
+
+func (r *AssignmentRepository) FindSubmissionByID(id string) (models.Submission, error) {
+	var submission models.Submission
+	err := r.DB.Model(&models.Submission{}).Preload("User").Preload("Files").First(&submission, "id = ?", id).Error
+
+	return submission, err
+}
+
+// CreateSubmission saves the submission with its files as the next version of the work of the user.
+// The assignment row is locked so concurrent submissions get distinct versions.
+func (r *AssignmentRepository) CreateSubmission(submission *models.Submission) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&models.Assignment{})
+		if tx.Dialector.Name() == "postgres" {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+
+		err := query.First(&models.Assignment{}, "id = ?", submission.AssignmentID).Error
+		if err != nil {
+			return err
+		}
+
+		var last struct{ Version int }
+		err = tx.Model(&models.Submission{}).Select("COALESCE(MAX(version), 0) AS version").
+			Where("assignment_id = ? AND user_id = ?", submission.AssignmentID, submission.UserID).
+			Scan(&last).Error
+		if err != nil {
+			return err
+		}
+
+		submission.Version = last.Version + 1
+
+		return tx.Omit("Assignment", "User").Create(submission).Error
+	})
+}
+
+func (r *AssignmentRepository) UpdateSubmission(submission *models.Submission) error {
+	return r.DB.Omit("Assignment", "User", "Files").Save(submission).Error
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IAttendanceRepository interface {
+	FindByOccurrence(sessionID string, occurrenceAt time.Time) []models.Attendance
+	FindByTrainingID(trainingID string, from, to time.Time) []models.Attendance
+	Save(attendance *models.Attendance) error
+}
+
+type AttendanceRepository struct {
+	DB *gorm.DB
+}
+
+var (
+	attendanceOnce       sync.Once
+	attendanceRepository IAttendanceRepository
+)
+
+func GetAttendanceRepository() IAttendanceRepository {
+	attendanceOnce.Do(func() {
+		log.Info().Msg("Initializing attendance repository")
+		attendanceRepository = &AttendanceRepository{
+			DB: models.GetDB(),
+		}
+	})
+	return attendanceRepository
+}
+
+func (r *AttendanceRepository) FindByOccurrence(sessionID string, occurrenceAt time.Time) []models.Attendance {
+	var attendances []models.Attendance
+
+	r.DB.Model(&models.Attendance{}).Preload("User").
+		Find(&attendances, "session_id = ? AND occurrence_at = ?", sessionID, occurrenceAt.UTC())
+
+	return attendances
+}
+
+func (r *AttendanceRepository) FindByTrainingID(trainingID string, from, to time.Time) []models.Attendance {
+	var attendances []models.Attendance
+
+	r.DB.Model(&models.Attendance{}).
+		Where("session_id IN (?)", r.DB.Model(&models.Session{}).Select("id").Where("training_id = ?", trainingID)).
+		Where("occurrence_at >= ? AND occurrence_at < ?", from.UTC(), to.UTC()).
+		Find(&attendances)
+
+	return attendances
+}
+
+// Save creates the attendance of a user for an occurrence or overwrites the existing one.
+// Code check-ins don't overwrite attendance marked by staff.
+func (r *AttendanceRepository) Save(attendance *models.Attendance) error {
+	attendance.OccurrenceAt = attendance.OccurrenceAt.UTC()
+
+	onConflict := clause.OnConflict{
+		Columns:   []clause.Column{{Name: "session_id"}, {Name: "occurrence_at"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "method", "marked_by_id", "updated_at"}),
+	}
+
+	if attendance.Method == models.CodeCheckIn {
+		onConflict.Where = clause.Where{Exprs: []clause.Expression{
+			clause.Neq{Column: clause.Column{Table: "attendances", Name: "method"}, Value: models.ManualCheckIn},
+		}}
+	}
+
+	result := r.DB.Omit("Session", "User").Clauses(onConflict).Create(attendance)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.New("attendance was already marked by staff")
+	}
+
+	// On a conflict the existing row was updated, it keeps its ID and creation time.
+	var stored models.Attendance
+	err := r.DB.First(&stored, "session_id = ? AND occurrence_at = ? AND user_id = ?",
+		attendance.SessionID, attendance.OccurrenceAt, attendance.UserID).Error
+	if err != nil {
+		return err
+	}
+
+	attendance.Base = stored.Base
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"sync"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type ICategoryRepository interface {
+	FindAll() []models.Category
+	FindByID(id string) (models.Category, error)
+	FindBySlug(slug string) (models.Category, error)
+	Create(category *models.Category) error
+	Update(category *models.Category) error
+	Delete(category *models.Category) error
+	CountChildren(id string) (int64, error)
+}
+
+type CategoryRepository struct {
+	DB *gorm.DB
+}
+
+var (
+	categoryOnce       sync.Once
+	categoryRepository ICategoryRepository
+)
+
+func GetCategoryRepository() ICategoryRepository {
+	categoryOnce.Do(func() {
+		log.Info().Msg("Initializing category repository")
+		categoryRepository = &CategoryRepository{
+			DB: models.GetDB(),
+		}
+	})
+	return categoryRepository
+}
+
+func (r *CategoryRepository) FindAll() []models.Category {
+	var categories []models.Category
+	r.DB.Order("name").Find(&categories)
+	return categories
+}
+
+func (r *CategoryRepository) FindByID(id string) (models.Category, error) {
+	var category models.Category
+	err := r.DB.First(&category, "id = ?", id).Error
+
+	return category, err
+}
+
+func (r *CategoryRepository) FindBySlug(slug string) (models.Category, error) {
+	var category models.Category
+	err := r.DB.Model(&models.Category{}).Preload("Parent").Preload("Children").First(&category, "slug = ?", slug).Error
+
+	return category, err
+}
+
+func (r *CategoryRepository) Create(category *models.Category) error {
+	return r.DB.Create(category).Error
+}
+
+func (r *CategoryRepository) Update(category *models.Category) error {
+	return r.DB.Omit("Parent", "Children").Save(category).Error
+}
+
+func (r *CategoryRepository) Delete(category *models.Category) error {
+	return r.DB.Delete(category).Error
+}
+
+func (r *CategoryRepository) CountChildren(id string) (int64, error) {
+	var count int64
+	err := r.DB.Model(&models.Category{}).Where("parent_id = ?", id).Count(&count).Error
+
+	return count, err
+}
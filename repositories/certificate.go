@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"sync"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type ICertificateRepository interface {
+	FindBySerial(serial string) (models.Certificate, error)
+	FindByUserID(userID string) []models.Certificate
+	FindByTrainingID(trainingID string) []models.Certificate
+	FindOne(trainingID, userID string) (models.Certificate, error)
+	Create(certificate *models.Certificate) error
+}
+
+type CertificateRepository struct {
+	DB *gorm.DB
+}
+
+var (
+	certificateOnce       sync.Once
+	certificateRepository ICertificateRepository
+)
+
+func GetCertificateRepository() ICertificateRepository {
+	certificateOnce.Do(func() {
+		log.Info().Msg("Initializing certificate repository")
+		certificateRepository = &CertificateRepository{
+			DB: models.GetDB(),
+		}
+	})
+	return certificateRepository
+}
+
+func (r *CertificateRepository) FindBySerial(serial string) (models.Certificate, error) {
+	var certificate models.Certificate
+	err := r.DB.First(&certificate, "serial = ?", serial).Error
+
+	return certificate, err
+}
+
+func (r *CertificateRepository) FindByUserID(userID string) []models.Certificate {
+	var certificates []models.Certificate
+
+	r.DB.Order("issued_at desc").Find(&certificates, "user_id = ?", userID)
+
+	return certificates
+}
+
+func (r *CertificateRepository) FindByTrainingID(trainingID string) []models.Certificate {
+	var certificates []models.Certificate
+
+	r.DB.Order("issued_at desc").Find(&certificates, "training_id = ?", trainingID)
+
+	return certificates
+}
+
+func (r *CertificateRepository) FindOne(trainingID, userID string) (models.Certificate, error) {
+	var certificate models.Certificate
+	err := r.DB.First(&certificate, "training_id = ? AND user_id = ?", trainingID, userID).Error
+
+	return certificate, err
+}
+
+func (r *CertificateRepository) Create(certificate *models.Certificate) error {
+	return r.DB.Omit("Training", "User").Create(certificate).Error
+}
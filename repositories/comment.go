@@ -3,13 +3,14 @@ package repositories
 import (
 	"sync"
 
+	"github.com/Marcel-MD/xmas-faf-api/dto"
 	"github.com/Marcel-MD/xmas-faf-api/models"
 	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
 )
 
 type ICommentRepository interface {
-	FindByPostID(postID string) []models.Comment
+	FindByPostID(postID string, params dto.PaginationQuery) (dto.Page[models.Comment], error)
 	FindByID(commentId string) (models.Comment, error)
 	Create(comment *models.Comment) error
 	Update(comment *models.Comment) error
@@ -35,12 +36,10 @@ func GetCommentRepository() ICommentRepository {
 	return commentRepository
 }
 
-func (r *CommentRepository) FindByPostID(postID string) []models.Comment {
-	var comments []models.Comment
+func (r *CommentRepository) FindByPostID(postID string, params dto.PaginationQuery) (dto.Page[models.Comment], error) {
+	query := r.DB.Model(&models.Comment{}).Where("post_id = ?", postID)
 
-	r.DB.Model(&models.Comment{}).Order("created_at desc").Find(&comments, "post_id = ?", postID)
-
-	return comments
+	return findPage[models.Comment](query, params)
 }
 
 func (r *CommentRepository) FindByID(commentId string) (models.Comment, error) {
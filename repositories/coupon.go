@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"sync"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type ICouponRepository interface {
+	FindAll() []models.Coupon
+	FindManagedBy(userID string) []models.Coupon
+	FindByID(id string) (models.Coupon, error)
+	FindByCode(code string) (models.Coupon, error)
+	Create(coupon *models.Coupon) error
+	Update(coupon *models.Coupon) error
+	Delete(coupon *models.Coupon) error
+}
+
+type CouponRepository struct {
+	DB *gorm.DB
+}
+
+var (
+	couponOnce       sync.Once
+	couponRepository ICouponRepository
+)
+
+func GetCouponRepository() ICouponRepository {
+	couponOnce.Do(func() {
+		log.Info().Msg("Initializing coupon repository")
+		couponRepository = &CouponRepository{
+			DB: models.GetDB(),
+		}
+	})
+	return couponRepository
+}
+
+func (r *CouponRepository) FindAll() []models.Coupon {
+	var coupons []models.Coupon
+	r.DB.Model(&models.Coupon{}).Order("created_at desc").Find(&coupons)
+	r.fillUsage(coupons)
+
+	return coupons
+}
+
+// FindManagedBy finds the coupons of the trainings a user owns or co-owns.
+func (r *CouponRepository) FindManagedBy(userID string) []models.Coupon {
+	managed := r.DB.Model(&models.Member{}).Select("training_id").
+		Where("user_id = ? AND role IN ?", userID, []string{models.OwnerMember, models.CoOwnerMember})
+
+	var coupons []models.Coupon
+	r.DB.Model(&models.Coupon{}).Where("training_id IN (?)", managed).Order("created_at desc").Find(&coupons)
+	r.fillUsage(coupons)
+
+	return coupons
+}
+
+func (r *CouponRepository) FindByID(id string) (models.Coupon, error) {
+	var coupon models.Coupon
+	err := r.DB.First(&coupon, "id = ?", id).Error
+	if err != nil {
+		return coupon, err
+	}
+
+	coupons := []models.Coupon{coupon}
+	r.fillUsage(coupons)
+
+	return coupons[0], nil
+}
+
+func (r *CouponRepository) FindByCode(code string) (models.Coupon, error) {
+	var coupon models.Coupon
+	err := r.DB.First(&coupon, "code = ?", code).Error
+
+	return coupon, err
+}
+
+func (r *CouponRepository) Create(coupon *models.Coupon) error {
+	return r.DB.Omit("Training", "Category").Create(coupon).Error
+}
+
+func (r *CouponRepository) Update(coupon *models.Coupon) error {
+	return r.DB.Omit("Training", "Category").Save(coupon).Error
+}
+
+func (r *CouponRepository) Delete(coupon *models.Coupon) error {
+	return r.DB.Delete(coupon).Error
+}
+
+// fillUsage counts the paid orders of the coupons with the revenue they brought in and the discount they gave.
+func (r *CouponRepository) fillUsage(coupons []models.Coupon) {
+	if len(coupons) == 0 {
+		return
+	}
+
+	ids := make([]string, len(coupons))
+	for i, coupon := range coupons {
+		ids[i] = coupon.ID
+	}
+
+	var usages []struct {
+		CouponID string
+		Uses     int64
+		Revenue  int64
+		Discount int64
+	}
+
+	r.DB.Model(&models.Order{}).
+		Select("coupon_id, COUNT(*) AS uses, SUM(amount) AS revenue, SUM(discount) AS discount").
+		Where("coupon_id IN ? AND status = ?", ids, models.PaidOrder).
+		Group("coupon_id").
+		Scan(&usages)
+
+	for _, usage := range usages {
+		for i := range coupons {
+			if coupons[i].ID == usage.CouponID {
+				coupons[i].Uses = usage.Uses
+				coupons[i].Revenue = usage.Revenue
+				coupons[i].Discount = usage.Discount
+			}
+		}
+	}
+}
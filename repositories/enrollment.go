@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"sync"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type IEnrollmentRepository interface {
+	FindByID(id string) (models.Enrollment, error)
+	FindByTrainingID(trainingID, status string) []models.Enrollment
+	FindByUserID(userID string) []models.Enrollment
+	FindPending(trainingID, userID string) (models.Enrollment, error)
+	CountPending() map[string]int64
+	Create(enrollment *models.Enrollment) error
+	Update(enrollment *models.Enrollment) error
+	AddEvent(event *models.EnrollmentEvent) error
+}
+
+type EnrollmentRepository struct {
+	DB *gorm.DB
+}
+
+var (
+	enrollmentOnce       sync.Once
+	enrollmentRepository IEnrollmentRepository
+)
+
+func GetEnrollmentRepository() IEnrollmentRepository {
+	enrollmentOnce.Do(func() {
+		log.Info().Msg("Initializing enrollment repository")
+		enrollmentRepository = &EnrollmentRepository{
+			DB: models.GetDB(),
+		}
+	})
+	return enrollmentRepository
+}
+
+func (r *EnrollmentRepository) FindByID(id string) (models.Enrollment, error) {
+	var enrollment models.Enrollment
+	err := r.DB.Model(&models.Enrollment{}).
+		Preload("User").
+		Preload("Training").
+		Preload("Events", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		First(&enrollment, "id = ?", id).Error
+
+	return enrollment, err
+}
+
+func (r *EnrollmentRepository) FindByTrainingID(trainingID, status string) []models.Enrollment {
+	var enrollments []models.Enrollment
+
+	query := r.DB.Model(&models.Enrollment{}).Preload("User").Where("training_id = ?", trainingID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	query.Order("created_at desc").Find(&enrollments)
+
+	return enrollments
+}
+
+func (r *EnrollmentRepository) FindByUserID(userID string) []models.Enrollment {
+	var enrollments []models.Enrollment
+
+	r.DB.Model(&models.Enrollment{}).Preload("Training").Order("created_at desc").Find(&enrollments, "user_id = ?", userID)
+
+	return enrollments
+}
+
+func (r *EnrollmentRepository) FindPending(trainingID, userID string) (models.Enrollment, error) {
+	var enrollment models.Enrollment
+	err := r.DB.First(&enrollment, "training_id = ? AND user_id = ? AND status = ?", trainingID, userID, models.PendingEnrollment).Error
+
+	return enrollment, err
+}
+
+// CountPending returns the number of pending enrollment requests of every training that has some.
+func (r *EnrollmentRepository) CountPending() map[string]int64 {
+	var rows []struct {
+		TrainingID string
+		Count      int64
+	}
+
+	r.DB.Model(&models.Enrollment{}).
+		Select("training_id, COUNT(*) AS count").
+		Where("status = ?", models.PendingEnrollment).
+		Group("training_id").
+		Scan(&rows)
+
+	counts := map[string]int64{}
+	for _, row := range rows {
+		counts[row.TrainingID] = row.Count
+	}
+
+	return counts
+}
+
+func (r *EnrollmentRepository) Create(enrollment *models.Enrollment) error {
+	return r.DB.Omit("Training", "User").Create(enrollment).Error
+}
+
+func (r *EnrollmentRepository) Update(enrollment *models.Enrollment) error {
+	return r.DB.Omit("Training", "User", "Events").Save(enrollment).Error
+}
+
+func (r *EnrollmentRepository) AddEvent(event *models.EnrollmentEvent) error {
+	return r.DB.Create(event).Error
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type IInviteRepository interface {
+	FindByTrainingID(trainingID string) []models.Invite
+	FindByID(id string) (models.Invite, error)
+	FindByCode(code string) (models.Invite, error)
+	Create(invite *models.Invite) error
+	Update(invite *models.Invite) error
+	IncrementUses(invite *models.Invite) error
+	DecrementUses(invite *models.Invite) error
+}
+
+type InviteRepository struct {
+	DB *gorm.DB
+}
+
+var (
+	inviteOnce       sync.Once
+	inviteRepository IInviteRepository
+)
+
+func GetInviteRepository() IInviteRepository {
+	inviteOnce.Do(func() {
+		log.Info().Msg("Initializing invite repository")
+		inviteRepository = &InviteRepository{
+			DB: models.GetDB(),
+		}
+	})
+	return inviteRepository
+}
+
+func (r *InviteRepository) FindByTrainingID(trainingID string) []models.Invite {
+	var invites []models.Invite
+
+	r.DB.Order("created_at desc").Find(&invites, "training_id = ?", trainingID)
+
+	return invites
+}
+
+func (r *InviteRepository) FindByID(id string) (models.Invite, error) {
+	var invite models.Invite
+	err := r.DB.Model(&models.Invite{}).Preload("Training").First(&invite, "id = ?", id).Error
+
+	return invite, err
+}
+
+func (r *InviteRepository) FindByCode(code string) (models.Invite, error) {
+	var invite models.Invite
+	err := r.DB.Model(&models.Invite{}).Preload("Training").First(&invite, "code = ?", code).Error
+
+	return invite, err
+}
+
+func (r *InviteRepository) Create(invite *models.Invite) error {
+	return r.DB.Omit("Training").Create(invite).Error
+}
+
+func (r *InviteRepository) Update(invite *models.Invite) error {
+	return r.DB.Omit("Training").Save(invite).Error
+}
+
+// IncrementUses counts a redemption, failing if the invite ran out of uses in the meantime.
+func (r *InviteRepository) IncrementUses(invite *models.Invite) error {
+	result := r.DB.Model(&models.Invite{}).
+		Where("id = ? AND (max_uses = 0 OR uses < max_uses)", invite.ID).
+		Update("uses", gorm.Expr("uses + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.New("invite has no uses left")
+	}
+
+	invite.Uses++
+
+	return nil
+}
+
+// DecrementUses gives back a use counted for a redemption that didn't go through.
+func (r *InviteRepository) DecrementUses(invite *models.Invite) error {
+	err := r.DB.Model(&models.Invite{}).
+		Where("id = ? AND uses > 0", invite.ID).
+		Update("uses", gorm.Expr("uses - 1")).Error
+	if err != nil {
+		return err
+	}
+
+	invite.Uses--
+
+	return nil
+}
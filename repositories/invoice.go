@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type IInvoiceRepository interface {
+	FindByID(id string) (models.Invoice, error)
+	FindByOrderID(orderID string) (models.Invoice, error)
+	FindByUserID(userID string) []models.Invoice
+	Create(invoice *models.Invoice) error
+	Update(invoice *models.Invoice) error
+}
+
+type InvoiceRepository struct {
+	DB *gorm.DB
+}
+
+var (
+	invoiceOnce       sync.Once
+	invoiceRepository IInvoiceRepository
+)
+
+func GetInvoiceRepository() IInvoiceRepository {
+	invoiceOnce.Do(func() {
+		log.Info().Msg("Initializing invoice repository")
+		invoiceRepository = &InvoiceRepository{
+			DB: models.GetDB(),
+		}
+	})
+	return invoiceRepository
+}
+
+func (r *InvoiceRepository) FindByID(id string) (models.Invoice, error) {
+	var invoice models.Invoice
+	err := r.DB.First(&invoice, "id = ?", id).Error
+
+	return invoice, err
+}
+
+func (r *InvoiceRepository) FindByOrderID(orderID string) (models.Invoice, error) {
+	var invoice models.Invoice
+	err := r.DB.First(&invoice, "order_id = ?", orderID).Error
+
+	return invoice, err
+}
+
+func (r *InvoiceRepository) FindByUserID(userID string) []models.Invoice {
+	var invoices []models.Invoice
+	r.DB.Model(&models.Invoice{}).Order("issued_at desc").Find(&invoices, "user_id = ?", userID)
+
+	return invoices
+}
+
+// Create numbers the invoice after the last one issued in the same year, like INV-2023-000042.
+// Numbers are unique, when two invoices get the same number at once the later one is numbered again.
+func (r *InvoiceRepository) Create(invoice *models.Invoice) error {
+	prefix := fmt.Sprintf("INV-%d-", invoice.IssuedAt.Year())
+
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		err = r.DB.Transaction(func(tx *gorm.DB) error {
+			var last string
+			err := tx.Model(&models.Invoice{}).Select("COALESCE(MAX(number), '')").
+				Where("number LIKE ?", prefix+"%").Scan(&last).Error
+			if err != nil {
+				return err
+			}
+
+			next := 1
+			if last != "" {
+				fmt.Sscanf(strings.TrimPrefix(last, prefix), "%d", &next)
+				next++
+			}
+
+			invoice.Number = fmt.Sprintf("%s%06d", prefix, next)
+
+			return tx.Omit("Order").Create(invoice).Error
+		})
+		if err == nil {
+			return nil
+		}
+
+		// The order was invoiced by someone else, numbering again won't help.
+		if _, findErr := r.FindByOrderID(invoice.OrderID); findErr == nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (r *InvoiceRepository) Update(invoice *models.Invoice) error {
+	return r.DB.Omit("Order").Save(invoice).Error
+}
@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ILessonRepository interface {
+	FindByID(id string) (models.Lesson, error)
+	Create(lesson *models.Lesson) error
+	Update(lesson *models.Lesson) error
+	Delete(lesson *models.Lesson) error
+	FindProgress(trainingID, userID string) []models.LessonProgress
+	FindProgressByTrainingID(trainingID string) []models.LessonProgress
+	Open(lesson *models.Lesson, userID string) error
+	Complete(lesson *models.Lesson, userID string) (models.LessonProgress, error)
+}
+
+type LessonRepository struct {
+	DB *gorm.DB
+}
+
+var (
+	lessonOnce       sync.Once
+	lessonRepository ILessonRepository
+)
+
+func GetLessonRepository() ILessonRepository {
+	lessonOnce.Do(func() {
+		log.Info().Msg("Initializing lesson repository")
+		lessonRepository = &LessonRepository{
+			DB: models.GetDB(),
+		}
+	})
+	return lessonRepository
+}
+
+func (r *LessonRepository) FindByID(id string) (models.Lesson, error) {
+	var lesson models.Lesson
+	err := r.DB.Model(&models.Lesson{}).Preload("Files").First(&lesson, "id = ?", id).Error
+
+	return lesson, err
+}
+
+// Create appends the lesson at the end of its module.
+func (r *LessonRepository) Create(lesson *models.Lesson) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var last struct{ Position int }
+		err := tx.Model(&models.Lesson{}).Select("COALESCE(MAX(position), 0) AS position").Where("module_id = ?", lesson.ModuleID).Scan(&last).Error
+		if err != nil {
+			return err
+		}
+
+		lesson.Position = last.Position + 1
+
+		return tx.Omit("Files").Create(lesson).Error
+	})
+}
+
+func (r *LessonRepository) Update(lesson *models.Lesson) error {
+	return r.DB.Omit("Files").Save(lesson).Error
+}
+
+func (r *LessonRepository) Delete(lesson *models.Lesson) error {
+	return r.DB.Delete(lesson).Error
+}
+
+func (r *LessonRepository) FindProgress(trainingID, userID string) []models.LessonProgress {
+	var progress []models.LessonProgress
+
+	r.DB.Find(&progress, "training_id = ? AND user_id = ?", trainingID, userID)
+
+	return progress
+}
+
+func (r *LessonRepository) FindProgressByTrainingID(trainingID string) []models.LessonProgress {
+	var progress []models.LessonProgress
+
+	r.DB.Find(&progress, "training_id = ?", trainingID)
+
+	return progress
+}
+
+// Open records that the user opened the lesson, the first time it is opened the lesson is started.
+func (r *LessonRepository) Open(lesson *models.Lesson, userID string) error {
+	now := time.Now()
+
+	progress := models.LessonProgress{
+		LessonID:   lesson.ID,
+		UserID:     userID,
+		TrainingID: lesson.TrainingID,
+		StartedAt:  now,
+		OpenedAt:   now,
+	}
+
+	return r.DB.Omit("Lesson", "User").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "lesson_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"opened_at": now}),
+	}).Create(&progress).Error
+}
+
+// Complete marks the lesson as completed by the user, completing it again keeps the first date.
+func (r *LessonRepository) Complete(lesson *models.Lesson, userID string) (models.LessonProgress, error) {
+	now := time.Now()
+
+	progress := models.LessonProgress{
+		LessonID:    lesson.ID,
+		UserID:      userID,
+		TrainingID:  lesson.TrainingID,
+		StartedAt:   now,
+		OpenedAt:    now,
+		CompletedAt: &now,
+	}
+
+	err := r.DB.Omit("Lesson", "User").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "lesson_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"completed_at": gorm.Expr("COALESCE(lesson_progresses.completed_at, ?)", now)}),
+	}).Create(&progress).Error
+	if err != nil {
+		return progress, err
+	}
+
+	// On conflict the stored row keeps its own ID and completion date.
+	var saved models.LessonProgress
+	err = r.DB.First(&saved, "lesson_id = ? AND user_id = ?", lesson.ID, userID).Error
+
+	return saved, err
+}
@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"sync"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type IModuleRepository interface {
+	FindByTrainingID(trainingID string) []models.Module
+	FindByID(id string) (models.Module, error)
+	Create(module *models.Module) error
+	Update(module *models.Module) error
+	Delete(module *models.Module) error
+	Reorder(trainingID string, order dto.ReorderCurriculum) error
+}
+
+type ModuleRepository struct {
+	DB *gorm.DB
+}
+
+var (
+	moduleOnce       sync.Once
+	moduleRepository IModuleRepository
+)
+
+func GetModuleRepository() IModuleRepository {
+	moduleOnce.Do(func() {
+		log.Info().Msg("Initializing module repository")
+		moduleRepository = &ModuleRepository{
+			DB: models.GetDB(),
+		}
+	})
+	return moduleRepository
+}
+
+// FindByTrainingID returns the curriculum of a training, modules and lessons in order.
+func (r *ModuleRepository) FindByTrainingID(trainingID string) []models.Module {
+	var modules []models.Module
+
+	r.DB.Model(&models.Module{}).
+		Preload("Lessons", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position")
+		}).
+		Preload("Lessons.Files").
+		Order("position").
+		Find(&modules, "training_id = ?", trainingID)
+
+	return modules
+}
+
+func (r *ModuleRepository) FindByID(id string) (models.Module, error) {
+	var module models.Module
+	err := r.DB.First(&module, "id = ?", id).Error
+
+	return module, err
+}
+
+// Create appends the module at the end of the curriculum.
+func (r *ModuleRepository) Create(module *models.Module) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var last struct{ Position int }
+		err := tx.Model(&models.Module{}).Select("COALESCE(MAX(position), 0) AS position").Where("training_id = ?", module.TrainingID).Scan(&last).Error
+		if err != nil {
+			return err
+		}
+
+		module.Position = last.Position + 1
+
+		return tx.Omit("Training", "Lessons").Create(module).Error
+	})
+}
+
+func (r *ModuleRepository) Update(module *models.Module) error {
+	return r.DB.Omit("Training", "Lessons").Save(module).Error
+}
+
+func (r *ModuleRepository) Delete(module *models.Module) error {
+	return r.DB.Delete(module).Error
+}
+
+// Reorder saves the positions of every module and lesson of the training in one go.
+func (r *ModuleRepository) Reorder(trainingID string, order dto.ReorderCurriculum) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		for i, module := range order.Modules {
+			err := tx.Model(&models.Module{}).
+				Where("id = ? AND training_id = ?", module.ID, trainingID).
+				Update("position", i+1).Error
+			if err != nil {
+				return err
+			}
+
+			for j, lessonID := range module.Lessons {
+				err = tx.Model(&models.Lesson{}).
+					Where("id = ? AND training_id = ?", lessonID, trainingID).
+					Updates(map[string]interface{}{"module_id": module.ID, "position": j + 1}).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"sync"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type INotificationRepository interface {
+	FindByUserID(userID string) models.NotificationPreference
+	FindByUserIDs(userIDs []string) map[string]models.NotificationPreference
+	Save(preference *models.NotificationPreference) error
+}
+
+type NotificationRepository struct {
+	DB *gorm.DB
+}
+
+var (
+	notificationOnce       sync.Once
+	notificationRepository INotificationRepository
+)
+
+func GetNotificationRepository() INotificationRepository {
+	notificationOnce.Do(func() {
+		log.Info().Msg("Initializing notification repository")
+		notificationRepository = &NotificationRepository{
+			DB: models.GetDB(),
+		}
+	})
+	return notificationRepository
+}
+
+func (r *NotificationRepository) FindByUserID(userID string) models.NotificationPreference {
+	return r.FindByUserIDs([]string{userID})[userID]
+}
+
+// FindByUserIDs returns the preferences of every user, filling in the defaults for users that never changed them.
+func (r *NotificationRepository) FindByUserIDs(userIDs []string) map[string]models.NotificationPreference {
+	var preferences []models.NotificationPreference
+
+	r.DB.Find(&preferences, "user_id IN ?", userIDs)
+
+	result := map[string]models.NotificationPreference{}
+	for _, userID := range userIDs {
+		result[userID] = models.DefaultNotificationPreference(userID)
+	}
+
+	for _, preference := range preferences {
+		result[preference.UserID] = preference
+	}
+
+	return result
+}
+
+func (r *NotificationRepository) Save(preference *models.NotificationPreference) error {
+	return r.DB.Omit("User").Save(preference).Error
+}
@@ -0,0 +1,256 @@
+package repositories
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IOrderRepository interface {
+	FindByID(id string) (models.Order, error)
+	FindByUserID(userID string) []models.Order
+	FindByProviderRef(provider, ref string) (models.Order, error)
+	FindSales(managerID string, from, to time.Time) []models.Order
+	FindPending(trainingID, userID string, since time.Time) (models.Order, error)
+	Create(order *models.Order) error
+	CreateWithCoupon(order *models.Order, coupon models.Coupon, since time.Time) error
+	Update(order *models.Order) error
+	Resolve(order *models.Order, eventID, status string) (bool, error)
+	MarkEnrolled(order *models.Order) (bool, error)
+	UnmarkEnrolled(order *models.Order) error
+	MarkRefunded(order *models.Order, amount int) error
+}
+
+type OrderRepository struct {
+	DB *gorm.DB
+}
+
+var (
+	orderOnce       sync.Once
+	orderRepository IOrderRepository
+)
+
+func GetOrderRepository() IOrderRepository {
+	orderOnce.Do(func() {
+		log.Info().Msg("Initializing order repository")
+		orderRepository = &OrderRepository{
+			DB: models.GetDB(),
+		}
+	})
+	return orderRepository
+}
+
+func (r *OrderRepository) FindByID(id string) (models.Order, error) {
+	var order models.Order
+	err := r.DB.Model(&models.Order{}).Preload("Training").First(&order, "id = ?", id).Error
+
+	return order, err
+}
+
+func (r *OrderRepository) FindByUserID(userID string) []models.Order {
+	var orders []models.Order
+	r.DB.Model(&models.Order{}).Preload("Training").Order("created_at desc").Find(&orders, "user_id = ?", userID)
+
+	return orders
+}
+
+func (r *OrderRepository) FindByProviderRef(provider, ref string) (models.Order, error) {
+	var order models.Order
+	err := r.DB.First(&order, "provider = ? AND provider_ref = ?", provider, ref).Error
+
+	return order, err
+}
+
+// FindSales finds the orders paid between from and to, refunded ones included, for the trainings
+// a user owns or co-owns, or for every training when managerID is empty.
+func (r *OrderRepository) FindSales(managerID string, from, to time.Time) []models.Order {
+	query := r.DB.Model(&models.Order{}).Preload("Training").
+		Where("status IN ? AND paid_at >= ? AND paid_at < ?", []string{models.PaidOrder, models.RefundedOrder}, from, to)
+
+	if managerID != "" {
+		managed := r.DB.Model(&models.Member{}).Select("training_id").
+			Where("user_id = ? AND role IN ?", managerID, []string{models.OwnerMember, models.CoOwnerMember})
+		query = query.Where("training_id IN (?)", managed)
+	}
+
+	var orders []models.Order
+	query.Order("paid_at").Find(&orders)
+
+	return orders
+}
+
+// FindPending finds the latest pending order of a user for a training created after since.
+func (r *OrderRepository) FindPending(trainingID, userID string, since time.Time) (models.Order, error) {
+	var order models.Order
+	err := r.DB.Model(&models.Order{}).Preload("Training").
+		Where("training_id = ? AND user_id = ? AND status = ? AND created_at > ?", trainingID, userID, models.PendingOrder, since).
+		Order("created_at desc").
+		First(&order).Error
+
+	return order, err
+}
+
+// Create creates an order in place of the pending orders of the buyer for the training, they fail.
+func (r *OrderRepository) Create(order *models.Order) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err := r.failPending(tx, order)
+		if err != nil {
+			return err
+		}
+
+		return tx.Omit("Training", "User", "Coupon").Create(order).Error
+	})
+}
+
+// CreateWithCoupon creates an order that redeems a coupon, as long as the coupon has uses left overall and for the buyer.
+// Paid orders and the pending ones created after since count as uses, the previous pending orders of the buyer
+// for the training fail so only their latest checkout holds a use.
+func (r *OrderRepository) CreateWithCoupon(order *models.Order, coupon models.Coupon, since time.Time) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&models.Coupon{})
+		if tx.Dialector.Name() == "postgres" {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+
+		err := query.First(&coupon, "id = ?", coupon.ID).Error
+		if err != nil {
+			return err
+		}
+
+		err = r.failPending(tx, order)
+		if err != nil {
+			return err
+		}
+
+		uses := tx.Model(&models.Order{}).
+			Where("coupon_id = ?", coupon.ID).
+			Where("status = ? OR (status = ? AND created_at > ?)", models.PaidOrder, models.PendingOrder, since)
+
+		if coupon.MaxUses > 0 {
+			var count int64
+			err = uses.Session(&gorm.Session{}).Count(&count).Error
+			if err != nil {
+				return err
+			}
+
+			if count >= int64(coupon.MaxUses) {
+				return errors.New("coupon has no uses left")
+			}
+		}
+
+		if coupon.MaxUsesPerUser > 0 {
+			var count int64
+			err = uses.Session(&gorm.Session{}).Where("user_id = ?", order.UserID).Count(&count).Error
+			if err != nil {
+				return err
+			}
+
+			if count >= int64(coupon.MaxUsesPerUser) {
+				return errors.New("coupon was already used")
+			}
+		}
+
+		return tx.Omit("Training", "User", "Coupon").Create(order).Error
+	})
+}
+
+func (r *OrderRepository) Update(order *models.Order) error {
+	return r.DB.Omit("Training", "User", "Coupon").Save(order).Error
+}
+
+// Resolve records the webhook event and moves a pending order to status in a single transaction.
+// It reports false when the event was already handled or the order isn't pending anymore,
+// so retried deliveries never change the order twice.
+func (r *OrderRepository) Resolve(order *models.Order, eventID, status string) (bool, error) {
+	changed := false
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		event := models.PaymentEvent{
+			EventID: eventID,
+			OrderID: order.ID,
+			Type:    status,
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&event)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		updates := map[string]interface{}{"status": status}
+		if status == models.PaidOrder {
+			updates["paid_at"] = time.Now()
+		}
+
+		// Failed orders can still be paid, e.g. when an asynchronous payment settles late.
+		result = tx.Model(&models.Order{}).
+			Where("id = ? AND status IN ?", order.ID, []string{models.PendingOrder, models.FailedOrder}).
+			Where("status <> ?", status).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		changed = result.RowsAffected > 0
+
+		return tx.First(order, "id = ?", order.ID).Error
+	})
+
+	return changed, err
+}
+
+// MarkEnrolled claims the enrollment of the buyer of a paid order. It reports false when
+// the order was already claimed, so concurrent deliveries of its payment enroll the buyer once.
+func (r *OrderRepository) MarkEnrolled(order *models.Order) (bool, error) {
+	now := time.Now()
+
+	result := r.DB.Model(&models.Order{}).Where("id = ? AND enrolled_at IS NULL", order.ID).Update("enrolled_at", now)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+
+	order.EnrolledAt = &now
+
+	return true, nil
+}
+
+// UnmarkEnrolled gives up the claim of MarkEnrolled when the enrollment failed, so it is tried again.
+func (r *OrderRepository) UnmarkEnrolled(order *models.Order) error {
+	err := r.DB.Model(&models.Order{}).Where("id = ?", order.ID).Update("enrolled_at", nil).Error
+	if err != nil {
+		return err
+	}
+
+	order.EnrolledAt = nil
+
+	return nil
+}
+
+// MarkRefunded records that amount of a paid order was given back to the buyer.
+func (r *OrderRepository) MarkRefunded(order *models.Order, amount int) error {
+	err := r.DB.Model(&models.Order{}).Where("id = ? AND status = ?", order.ID, models.PaidOrder).
+		Updates(map[string]interface{}{
+			"status":   models.RefundedOrder,
+			"refunded": gorm.Expr("refunded + ?", amount),
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	order.Status = models.RefundedOrder
+	order.Refunded += amount
+
+	return nil
+}
+
+// failPending fails the pending orders of the buyer of an order for its training, a new checkout replaces them.
+// Their payments can still settle late, the order is paid then.
+func (r *OrderRepository) failPending(tx *gorm.DB, order *models.Order) error {
+	return tx.Model(&models.Order{}).
+		Where("training_id = ? AND user_id = ? AND status = ?", order.TrainingID, order.UserID, models.PendingOrder).
+		Update("status", models.FailedOrder).Error
+}
@@ -0,0 +1,164 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	offsetPrefix    = "offset:"
+)
+
+type pageable interface {
+	PageKey() (time.Time, string)
+}
+
+// findPage counts the rows matched by query and loads a single page of them.
+// Rows are ordered by (created_at, id), a cursor continues right after the
+// row it was generated from, otherwise page and size are used as an offset.
+func findPage[T pageable](query *gorm.DB, params dto.PaginationQuery, preloads ...string) (dto.Page[T], error) {
+	page := dto.Page[T]{Items: []T{}}
+
+	err := query.Session(&gorm.Session{}).Count(&page.Total).Error
+	if err != nil {
+		return page, err
+	}
+
+	size := pageSize(params)
+
+	order, cmp := "desc", "<"
+	if params.Order == "asc" {
+		order, cmp = "asc", ">"
+	}
+
+	q := query.Session(&gorm.Session{}).Order("created_at " + order).Order("id " + order).Limit(size + 1)
+
+	if params.Cursor != "" {
+		createdAt, id, err := decodeCursor(params.Cursor)
+		if err != nil {
+			return page, err
+		}
+		q = q.Where("created_at "+cmp+" ? OR (created_at = ? AND id "+cmp+" ?)", createdAt, createdAt, id)
+	} else if params.Page > 1 {
+		q = q.Offset((params.Page - 1) * size)
+	}
+
+	for _, p := range preloads {
+		q = q.Preload(p)
+	}
+
+	err = q.Find(&page.Items).Error
+	if err != nil {
+		return page, err
+	}
+
+	if len(page.Items) > size {
+		page.Items = page.Items[:size]
+		page.NextCursor = encodeCursor(page.Items[size-1].PageKey())
+	}
+
+	return page, nil
+}
+
+// findSortedPage is like findPage but orders rows by an arbitrary expression.
+// Keyset pagination doesn't work for such orders, so the cursor is an encoded offset.
+func findSortedPage[T any](query *gorm.DB, params dto.PaginationQuery, order string, preloads ...string) (dto.Page[T], error) {
+	page := dto.Page[T]{Items: []T{}}
+
+	err := query.Session(&gorm.Session{}).Count(&page.Total).Error
+	if err != nil {
+		return page, err
+	}
+
+	size := pageSize(params)
+
+	offset := 0
+	if params.Cursor != "" {
+		offset, err = decodeOffsetCursor(params.Cursor)
+		if err != nil {
+			return page, err
+		}
+	} else if params.Page > 1 {
+		offset = (params.Page - 1) * size
+	}
+
+	q := query.Session(&gorm.Session{}).Order(order).Order("id").Offset(offset).Limit(size + 1)
+
+	for _, p := range preloads {
+		q = q.Preload(p)
+	}
+
+	err = q.Find(&page.Items).Error
+	if err != nil {
+		return page, err
+	}
+
+	if len(page.Items) > size {
+		page.Items = page.Items[:size]
+		page.NextCursor = encodeOffsetCursor(offset + size)
+	}
+
+	return page, nil
+}
+
+func pageSize(params dto.PaginationQuery) int {
+	if params.Size <= 0 {
+		return defaultPageSize
+	}
+
+	if params.Size > maxPageSize {
+		return maxPageSize
+	}
+
+	return params.Size
+}
+
+func encodeCursor(createdAt time.Time, id string) string {
+	raw := strconv.FormatInt(createdAt.UnixNano(), 10) + ":" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", errors.New("invalid cursor")
+	}
+
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 {
+		return time.Time{}, "", errors.New("invalid cursor")
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, "", errors.New("invalid cursor")
+	}
+
+	return time.Unix(0, nanos), parts[1], nil
+}
+
+func encodeOffsetCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(offsetPrefix + strconv.Itoa(offset)))
+}
+
+func decodeOffsetCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), offsetPrefix) {
+		return 0, errors.New("invalid cursor")
+	}
+
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), offsetPrefix))
+	if err != nil || offset < 0 {
+		return 0, errors.New("invalid cursor")
+	}
+
+	return offset, nil
+}
@@ -0,0 +1,177 @@
+package repositories
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IPointsRepository interface {
+	FindByUserID(userID string, params dto.PaginationQuery) (dto.Page[models.PointsEntry], error)
+	FindRules() []models.PointsRule
+	FindRule(event string) (models.PointsRule, error)
+	UpdateRule(rule *models.PointsRule) error
+	Award(entry *models.PointsEntry, dailyLimit int) (bool, error)
+	SumByUser(trainingID string, from, to, until time.Time) []dto.PointsScore
+	FindSince(trainingID string, from, to, since time.Time) []models.PointsEntry
+	SumByTraining(userID string, from, to time.Time) []dto.PointsScore
+}
+
+type PointsRepository struct {
+	DB *gorm.DB
+}
+
+var (
+	pointsOnce       sync.Once
+	pointsRepository IPointsRepository
+)
+
+func GetPointsRepository() IPointsRepository {
+	pointsOnce.Do(func() {
+		log.Info().Msg("Initializing points repository")
+		pointsRepository = &PointsRepository{
+			DB: models.GetDB(),
+		}
+	})
+	return pointsRepository
+}
+
+func (r *PointsRepository) FindByUserID(userID string, params dto.PaginationQuery) (dto.Page[models.PointsEntry], error) {
+	query := r.DB.Model(&models.PointsEntry{}).Where("user_id = ?", userID)
+
+	return findPage[models.PointsEntry](query, params)
+}
+
+func (r *PointsRepository) FindRules() []models.PointsRule {
+	var rules []models.PointsRule
+	r.DB.Model(&models.PointsRule{}).Order("event").Find(&rules)
+
+	return rules
+}
+
+func (r *PointsRepository) FindRule(event string) (models.PointsRule, error) {
+	var rule models.PointsRule
+	err := r.DB.First(&rule, "event = ?", event).Error
+
+	return rule, err
+}
+
+func (r *PointsRepository) UpdateRule(rule *models.PointsRule) error {
+	return r.DB.Save(rule).Error
+}
+
+// Award appends the entry to the ledger unless the user already got points for its source or
+// reached the daily limit of its reason, it reports whether the points were awarded.
+func (r *PointsRepository) Award(entry *models.PointsEntry, dailyLimit int) (bool, error) {
+	awarded := false
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if dailyLimit > 0 {
+			// Lock the user row so concurrent awards can't both count below the limit.
+			query := tx.Model(&models.User{})
+			if tx.Dialector.Name() == "postgres" {
+				query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+			}
+
+			err := query.Select("id").First(&models.User{}, "id = ?", entry.UserID).Error
+			if err != nil {
+				return err
+			}
+
+			var today int64
+			err = tx.Model(&models.PointsEntry{}).
+				Where("user_id = ? AND reason = ? AND created_at >= ?", entry.UserID, entry.Reason, time.Now().UTC().Truncate(24*time.Hour)).
+				Count(&today).Error
+			if err != nil || today >= int64(dailyLimit) {
+				return err
+			}
+		}
+
+		var err error
+		awarded, err = addPoints(tx, entry)
+
+		return err
+	})
+
+	return awarded, err
+}
+
+// SumByUser sums the points of every user who didn't opt out of leaderboards, within a training
+// if trainingID is set and between from and to unless from is zero. Opening balances only count all time.
+// Only entries created up to until are summed.
+func (r *PointsRepository) SumByUser(trainingID string, from, to, until time.Time) []dto.PointsScore {
+	query := r.leaderboardEntries(trainingID, from, to).
+		Select("points_entries.user_id, SUM(points_entries.delta) AS points").
+		Where("points_entries.created_at <= ?", until).
+		Group("points_entries.user_id")
+
+	var scores []dto.PointsScore
+	query.Scan(&scores)
+
+	return scores
+}
+
+// FindSince finds the entries SumByUser counts that were created after since.
+func (r *PointsRepository) FindSince(trainingID string, from, to, since time.Time) []models.PointsEntry {
+	var entries []models.PointsEntry
+
+	r.leaderboardEntries(trainingID, from, to).
+		Where("points_entries.created_at > ?", since).
+		Find(&entries)
+
+	return entries
+}
+
+func (r *PointsRepository) leaderboardEntries(trainingID string, from, to time.Time) *gorm.DB {
+	query := r.DB.Model(&models.PointsEntry{}).
+		Joins("JOIN users ON users.id = points_entries.user_id").
+		Where("users.leaderboard_hidden = ?", false)
+
+	if trainingID != "" {
+		query = query.Where("points_entries.training_id = ?", trainingID)
+	}
+
+	return betweenPeriod(query, from, to)
+}
+
+// SumByTraining sums the points of a user per training, entries outside of trainings are summed with a nil training.
+func (r *PointsRepository) SumByTraining(userID string, from, to time.Time) []dto.PointsScore {
+	query := r.DB.Model(&models.PointsEntry{}).
+		Select("user_id, training_id, SUM(delta) AS points").
+		Where("user_id = ?", userID).
+		Group("user_id, training_id")
+
+	query = betweenPeriod(query, from, to)
+
+	var scores []dto.PointsScore
+	query.Scan(&scores)
+
+	return scores
+}
+
+func betweenPeriod(query *gorm.DB, from, to time.Time) *gorm.DB {
+	if from.IsZero() {
+		return query
+	}
+
+	return query.Where("points_entries.created_at >= ? AND points_entries.created_at < ? AND points_entries.reason <> ?",
+		from, to, models.OpeningPoints)
+}
+
+// addPoints appends an entry to the ledger and updates the cached balance of the user in the same transaction.
+func addPoints(tx *gorm.DB, entry *models.PointsEntry) (bool, error) {
+	result := tx.Omit("User").Clauses(clause.OnConflict{DoNothing: true}).Create(entry)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+
+	err := tx.Model(&models.User{}).Where("id = ?", entry.UserID).
+		Update("points", gorm.Expr("points + ?", entry.Delta)).Error
+
+	return err == nil, err
+}
@@ -3,13 +3,14 @@ package repositories
 import (
 	"sync"
 
+	"github.com/Marcel-MD/xmas-faf-api/dto"
 	"github.com/Marcel-MD/xmas-faf-api/models"
 	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
 )
 
 type IPostRepository interface {
-	FindByTrainingID(trainingID string) []models.Post
+	FindByTrainingID(trainingID string, params dto.PostQueryParams) (dto.Page[models.Post], error)
 	FindByID(id string) (models.Post, error)
 	Create(post *models.Post) error
 	Update(post *models.Post) error
@@ -35,12 +36,61 @@ func GetPostRepository() IPostRepository {
 	return postRepository
 }
 
-func (r *PostRepository) FindByTrainingID(trainingID string) []models.Post {
-	var posts []models.Post
+func (r *PostRepository) FindByTrainingID(trainingID string, params dto.PostQueryParams) (dto.Page[models.Post], error) {
+	query := r.DB.Model(&models.Post{}).Where("training_id = ?", trainingID)
 
-	r.DB.Model(&models.Post{}).Preload("Files").Preload("Comments").Order("created_at desc").Find(&posts, "training_id = ?", trainingID)
+	if params.UserID != "" {
+		query = query.Where("user_id = ?", params.UserID)
+	}
 
-	return posts
+	if !params.From.IsZero() {
+		query = query.Where("created_at >= ?", params.From)
+	}
+
+	if !params.To.IsZero() {
+		query = query.Where("created_at <= ?", params.To)
+	}
+
+	if params.HasFiles != nil {
+		exists := "EXISTS (SELECT 1 FROM files WHERE files.post_id = posts.id)"
+		if *params.HasFiles {
+			query = query.Where(exists)
+		} else {
+			query = query.Where("NOT " + exists)
+		}
+	}
+
+	page, err := findPage[models.Post](query, params.PaginationQuery, "Files")
+	if err != nil || len(page.Items) == 0 {
+		return page, err
+	}
+
+	// Comments are paged on their own, posts only tell how many they have.
+	ids := make([]string, len(page.Items))
+	for i, post := range page.Items {
+		ids[i] = post.ID
+	}
+
+	var counts []struct {
+		PostID string
+		Count  int
+	}
+
+	err = r.DB.Model(&models.Comment{}).Select("post_id, COUNT(*) AS count").
+		Where("post_id IN ?", ids).Group("post_id").Scan(&counts).Error
+	if err != nil {
+		return page, err
+	}
+
+	for _, count := range counts {
+		for i := range page.Items {
+			if page.Items[i].ID == count.PostID {
+				page.Items[i].CommentCount = count.Count
+			}
+		}
+	}
+
+	return page, nil
 }
 
 func (r *PostRepository) FindByID(id string) (models.Post, error) {
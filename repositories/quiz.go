@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"sync"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type IQuizRepository interface {
+	FindByTrainingID(trainingID string) []models.Quiz
+	FindByID(id string) (models.Quiz, error)
+	Create(quiz *models.Quiz) error
+	Update(quiz *models.Quiz) error
+	Delete(quiz *models.Quiz) error
+	FindAttempts(quizID, userID string) []models.QuizAttempt
+	FindAttemptsByQuizID(quizID string) []models.QuizAttempt
+	FindAttemptByID(id string) (models.QuizAttempt, error)
+	CreateAttempt(attempt *models.QuizAttempt) error
+	SubmitAttempt(attempt *models.QuizAttempt, award *models.PointsEntry) (bool, error)
+}
+
+type QuizRepository struct {
+	DB *gorm.DB
+}
+
+var (
+	quizOnce       sync.Once
+	quizRepository IQuizRepository
+)
+
+func GetQuizRepository() IQuizRepository {
+	quizOnce.Do(func() {
+		log.Info().Msg("Initializing quiz repository")
+		quizRepository = &QuizRepository{
+			DB: models.GetDB(),
+		}
+	})
+	return quizRepository
+}
+
+func (r *QuizRepository) FindByTrainingID(trainingID string) []models.Quiz {
+	var quizzes []models.Quiz
+
+	r.DB.Model(&models.Quiz{}).
+		Preload("Questions", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position")
+		}).
+		Order("created_at").
+		Find(&quizzes, "training_id = ?", trainingID)
+
+	return quizzes
+}
+
+func (r *QuizRepository) FindByID(id string) (models.Quiz, error) {
+	var quiz models.Quiz
+	err := r.DB.Model(&models.Quiz{}).
+		Preload("Questions", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position")
+		}).
+		First(&quiz, "id = ?", id).Error
+
+	return quiz, err
+}
+
+func (r *QuizRepository) Create(quiz *models.Quiz) error {
+	return r.DB.Omit("Training", "Lesson").Create(quiz).Error
+}
+
+// Update saves the quiz and its questions, questions with an ID are updated in place, the ones without are
+// added and the ones left out are deleted. Past attempts keep their graded answers.
+func (r *QuizRepository) Update(quiz *models.Quiz) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Omit("Training", "Lesson", "Questions").Save(quiz).Error
+		if err != nil {
+			return err
+		}
+
+		kept := []string{}
+		for _, question := range quiz.Questions {
+			if question.ID != "" {
+				kept = append(kept, question.ID)
+			}
+		}
+
+		removed := tx.Where("quiz_id = ?", quiz.ID)
+		if len(kept) > 0 {
+			removed = removed.Where("id NOT IN ?", kept)
+		}
+
+		err = removed.Delete(&models.Question{}).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range quiz.Questions {
+			err = tx.Save(&quiz.Questions[i]).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *QuizRepository) Delete(quiz *models.Quiz) error {
+	return r.DB.Delete(quiz).Error
+}
+
+func (r *QuizRepository) FindAttempts(quizID, userID string) []models.QuizAttempt {
+	var attempts []models.QuizAttempt
+
+	r.DB.Order("created_at").Find(&attempts, "quiz_id = ? AND user_id = ?", quizID, userID)
+
+	return attempts
+}
+
+func (r *QuizRepository) FindAttemptsByQuizID(quizID string) []models.QuizAttempt {
+	var attempts []models.QuizAttempt
+
+	r.DB.Order("created_at").Find(&attempts, "quiz_id = ?", quizID)
+
+	return attempts
+}
+
+func (r *QuizRepository) FindAttemptByID(id string) (models.QuizAttempt, error) {
+	var attempt models.QuizAttempt
+	err := r.DB.First(&attempt, "id = ?", id).Error
+
+	return attempt, err
+}
+
+func (r *QuizRepository) CreateAttempt(attempt *models.QuizAttempt) error {
+	return r.DB.Omit("Quiz", "User").Create(attempt).Error
+}
+
+// SubmitAttempt saves the graded attempt and adds the award to the points ledger the first time
+// the user passes the quiz, it reports whether points were awarded.
+func (r *QuizRepository) SubmitAttempt(attempt *models.QuizAttempt, award *models.PointsEntry) (bool, error) {
+	awarded := false
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		// Only one submission of the attempt goes through, a second one finds it submitted.
+		result := tx.Model(&models.QuizAttempt{}).
+			Where("id = ? AND submitted_at IS NULL", attempt.ID).
+			Updates(map[string]interface{}{
+				"answers":      attempt.Answers,
+				"submitted_at": attempt.SubmittedAt,
+				"expired":      attempt.Expired,
+				"score":        attempt.Score,
+				"max_score":    attempt.MaxScore,
+				"percent":      attempt.Percent,
+				"passed":       attempt.Passed,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if !attempt.Passed || award == nil {
+			return nil
+		}
+
+		var passed int64
+		err := tx.Model(&models.QuizAttempt{}).
+			Where("quiz_id = ? AND user_id = ? AND passed AND id <> ?", attempt.QuizID, attempt.UserID, attempt.ID).
+			Count(&passed).Error
+		if err != nil || passed > 0 {
+			return err
+		}
+
+		awarded, err = addPoints(tx, award)
+
+		return err
+	})
+
+	return awarded, err
+}
@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IRefundRepository interface {
+	FindByID(id string) (models.Refund, error)
+	FindByUserID(userID string) []models.Refund
+	FindByTrainingID(trainingID, status string) []models.Refund
+	FindPending(orderID string) (models.Refund, error)
+	Create(refund *models.Refund) error
+	Decide(refund *models.Refund, from, to string, userID *string, note string) (bool, error)
+	Complete(refund *models.Refund, providerRef string) error
+}
+
+type RefundRepository struct {
+	DB *gorm.DB
+}
+
+var (
+	refundOnce       sync.Once
+	refundRepository IRefundRepository
+)
+
+func GetRefundRepository() IRefundRepository {
+	refundOnce.Do(func() {
+		log.Info().Msg("Initializing refund repository")
+		refundRepository = &RefundRepository{
+			DB: models.GetDB(),
+		}
+	})
+	return refundRepository
+}
+
+func (r *RefundRepository) FindByID(id string) (models.Refund, error) {
+	var refund models.Refund
+	err := r.DB.Model(&models.Refund{}).Preload("Order.Training").Preload("User").First(&refund, "id = ?", id).Error
+
+	return refund, err
+}
+
+func (r *RefundRepository) FindByUserID(userID string) []models.Refund {
+	var refunds []models.Refund
+	r.DB.Model(&models.Refund{}).Preload("Order.Training").Order("created_at desc").Find(&refunds, "user_id = ?", userID)
+
+	return refunds
+}
+
+func (r *RefundRepository) FindByTrainingID(trainingID, status string) []models.Refund {
+	query := r.DB.Model(&models.Refund{}).Preload("Order").Preload("User").Where("training_id = ?", trainingID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var refunds []models.Refund
+	query.Order("created_at desc").Find(&refunds)
+
+	return refunds
+}
+
+func (r *RefundRepository) FindPending(orderID string) (models.Refund, error) {
+	var refund models.Refund
+	err := r.DB.First(&refund, "order_id = ? AND status = ?", orderID, models.PendingRefund).Error
+
+	return refund, err
+}
+
+// Create creates a refund for a paid order that has no pending or approved refund yet.
+// The order row is locked so concurrent requests can't both create one.
+func (r *RefundRepository) Create(refund *models.Refund) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&models.Order{})
+		if tx.Dialector.Name() == "postgres" {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+
+		var order models.Order
+		err := query.First(&order, "id = ?", refund.OrderID).Error
+		if err != nil {
+			return err
+		}
+
+		if order.Status != models.PaidOrder {
+			return errors.New("only paid orders can be refunded")
+		}
+
+		var open int64
+		err = tx.Model(&models.Refund{}).
+			Where("order_id = ? AND status IN ?", refund.OrderID, []string{models.PendingRefund, models.ApprovedRefund}).
+			Count(&open).Error
+		if err != nil {
+			return err
+		}
+
+		if open > 0 {
+			return errors.New("refund request already pending")
+		}
+
+		return tx.Omit("Order", "User").Create(refund).Error
+	})
+}
+
+// Decide moves a refund from one status to another. It reports false when the refund
+// isn't in the from status anymore, so a refund is never decided twice.
+// Moving a refund back to pending clears the decision.
+func (r *RefundRepository) Decide(refund *models.Refund, from, to string, userID *string, note string) (bool, error) {
+	var decidedAt *time.Time
+	if to != models.PendingRefund {
+		now := time.Now()
+		decidedAt = &now
+	} else {
+		userID = nil
+	}
+
+	result := r.DB.Model(&models.Refund{}).
+		Where("id = ? AND status = ?", refund.ID, from).
+		Updates(map[string]interface{}{"status": to, "note": note, "decided_by_id": userID, "decided_at": decidedAt})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+
+	refund.Status = to
+	refund.Note = note
+	refund.DecidedByID = userID
+	refund.DecidedAt = decidedAt
+
+	return true, nil
+}
+
+// Complete records the refund of the provider and gives its amount back on the order.
+func (r *RefundRepository) Complete(refund *models.Refund, providerRef string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Refund{}).Where("id = ?", refund.ID).Update("provider_ref", providerRef).Error
+		if err != nil {
+			return err
+		}
+
+		refund.ProviderRef = providerRef
+
+		return tx.Model(&models.Order{}).Where("id = ?", refund.OrderID).
+			Updates(map[string]interface{}{
+				"status":   models.RefundedOrder,
+				"refunded": gorm.Expr("refunded + ?", refund.Amount),
+			}).Error
+	})
+}
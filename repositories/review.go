@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"sync"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IReviewRepository interface {
+	FindByTrainingID(trainingID string, params dto.PaginationQuery) (dto.Page[models.Review], error)
+	FindReported(params dto.PaginationQuery) (dto.Page[models.Review], error)
+	FindByID(id string) (models.Review, error)
+	Create(review *models.Review) error
+	Update(review *models.Review) error
+	Delete(review *models.Review) error
+	Report(report *models.ReviewReport) error
+}
+
+type ReviewRepository struct {
+	DB *gorm.DB
+}
+
+var (
+	reviewOnce       sync.Once
+	reviewRepository IReviewRepository
+)
+
+func GetReviewRepository() IReviewRepository {
+	reviewOnce.Do(func() {
+		log.Info().Msg("Initializing review repository")
+		reviewRepository = &ReviewRepository{
+			DB: models.GetDB(),
+		}
+	})
+	return reviewRepository
+}
+
+func (r *ReviewRepository) FindByTrainingID(trainingID string, params dto.PaginationQuery) (dto.Page[models.Review], error) {
+	query := r.DB.Model(&models.Review{}).Where("training_id = ?", trainingID)
+
+	return findPage[models.Review](query, params, "User")
+}
+
+func (r *ReviewRepository) FindReported(params dto.PaginationQuery) (dto.Page[models.Review], error) {
+	query := r.DB.Model(&models.Review{}).Where("report_count > 0")
+
+	return findSortedPage[models.Review](query, params, "report_count desc", "User")
+}
+
+func (r *ReviewRepository) FindByID(id string) (models.Review, error) {
+	var review models.Review
+	err := r.DB.Model(&models.Review{}).Preload("User").First(&review, "id = ?", id).Error
+
+	return review, err
+}
+
+func (r *ReviewRepository) Create(review *models.Review) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Omit("Training", "User").Create(review).Error
+		if err != nil {
+			return err
+		}
+
+		return r.updateRating(tx, review.TrainingID)
+	})
+}
+
+func (r *ReviewRepository) Update(review *models.Review) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Omit("Training", "User").Save(review).Error
+		if err != nil {
+			return err
+		}
+
+		return r.updateRating(tx, review.TrainingID)
+	})
+}
+
+func (r *ReviewRepository) Delete(review *models.Review) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Delete(review).Error
+		if err != nil {
+			return err
+		}
+
+		return r.updateRating(tx, review.TrainingID)
+	})
+}
+
+// Report saves the report and counts it on the review, reporting the same review again does nothing.
+func (r *ReviewRepository) Report(report *models.ReviewReport) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Omit("Review", "User").Clauses(clause.OnConflict{DoNothing: true}).Create(report)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		return tx.Model(&models.Review{}).Where("id = ?", report.ReviewID).
+			Update("report_count", gorm.Expr("report_count + 1")).Error
+	})
+}
+
+// updateRating recomputes the rating aggregates of a training from its reviews,
+// so they can't drift however reviews are edited.
+func (r *ReviewRepository) updateRating(tx *gorm.DB, trainingID string) error {
+	var rating struct {
+		Average float64
+		Count   int
+	}
+
+	err := tx.Model(&models.Review{}).
+		Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count").
+		Where("training_id = ?", trainingID).
+		Scan(&rating).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Training{}).Where("id = ?", trainingID).
+		Updates(map[string]interface{}{"rating_average": rating.Average, "rating_count": rating.Count}).Error
+}
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type ISessionRepository interface {
+	FindByTrainingID(trainingID string) []models.Session
+	FindByUserID(userID string) []models.Session
+	FindUpcoming(from, to time.Time) []models.Session
+	FindByID(id string) (models.Session, error)
+	Create(session *models.Session) error
+	Update(session *models.Session) error
+	Delete(session *models.Session) error
+}
+
+type SessionRepository struct {
+	DB *gorm.DB
+}
+
+var (
+	sessionOnce       sync.Once
+	sessionRepository ISessionRepository
+)
+
+func GetSessionRepository() ISessionRepository {
+	sessionOnce.Do(func() {
+		log.Info().Msg("Initializing session repository")
+		sessionRepository = &SessionRepository{
+			DB: models.GetDB(),
+		}
+	})
+	return sessionRepository
+}
+
+func (r *SessionRepository) FindByTrainingID(trainingID string) []models.Session {
+	var sessions []models.Session
+
+	r.DB.Order("starts_at").Find(&sessions, "training_id = ?", trainingID)
+
+	return sessions
+}
+
+// FindByUserID returns the sessions of every training the user is a member of, with their training loaded.
+func (r *SessionRepository) FindByUserID(userID string) []models.Session {
+	var sessions []models.Session
+
+	r.DB.Model(&models.Session{}).Preload("Training").
+		Where("training_id IN (?)", r.DB.Table("training_users").Select("training_id").Where("user_id = ?", userID)).
+		Order("starts_at").
+		Find(&sessions)
+
+	return sessions
+}
+
+// FindUpcoming returns the sessions that may have an occurrence between from and to,
+// the recurring ones still have to be expanded to tell.
+func (r *SessionRepository) FindUpcoming(from, to time.Time) []models.Session {
+	var sessions []models.Session
+
+	r.DB.Model(&models.Session{}).Preload("Training").
+		Where("starts_at < ? AND (r_rule <> '' OR starts_at >= ?)", to, from).
+		Find(&sessions)
+
+	return sessions
+}
+
+func (r *SessionRepository) FindByID(id string) (models.Session, error) {
+	var session models.Session
+	err := r.DB.Model(&models.Session{}).Preload("Training").First(&session, "id = ?", id).Error
+
+	return session, err
+}
+
+func (r *SessionRepository) Create(session *models.Session) error {
+	return r.DB.Omit("Training").Create(session).Error
+}
+
+func (r *SessionRepository) Update(session *models.Session) error {
+	return r.DB.Omit("Training").Save(session).Error
+}
+
+func (r *SessionRepository) Delete(session *models.Session) error {
+	return r.DB.Delete(session).Error
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"sync"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ITagRepository interface {
+	FindAll() []models.Tag
+	FindOrCreate(names []string) ([]models.Tag, error)
+}
+
+type TagRepository struct {
+	DB *gorm.DB
+}
+
+var (
+	tagOnce       sync.Once
+	tagRepository ITagRepository
+)
+
+func GetTagRepository() ITagRepository {
+	tagOnce.Do(func() {
+		log.Info().Msg("Initializing tag repository")
+		tagRepository = &TagRepository{
+			DB: models.GetDB(),
+		}
+	})
+	return tagRepository
+}
+
+func (r *TagRepository) FindAll() []models.Tag {
+	var tags []models.Tag
+	r.DB.Order("name").Find(&tags)
+	return tags
+}
+
+func (r *TagRepository) FindOrCreate(names []string) ([]models.Tag, error) {
+	tags := []models.Tag{}
+	if len(names) == 0 {
+		return tags, nil
+	}
+
+	for _, name := range names {
+		tag := models.Tag{Name: name}
+		err := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&tag).Error
+		if err != nil {
+			return tags, err
+		}
+	}
+
+	err := r.DB.Where("name IN ?", names).Find(&tags).Error
+
+	return tags, err
+}
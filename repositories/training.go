@@ -2,23 +2,38 @@ package repositories
 
 import (
 	"errors"
+	"strings"
 	"sync"
 
+	"github.com/Marcel-MD/xmas-faf-api/dto"
 	"github.com/Marcel-MD/xmas-faf-api/models"
 	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ITrainingRepository interface {
-	FindAll() []models.Training
+	FindAll(params dto.TrainingQueryParams) (dto.Page[models.Training], error)
+	CountFacets(params dto.TrainingQueryParams) (dto.TrainingFacets, error)
 	FindByID(id string) (models.Training, error)
 	FindByIdWithUsers(id string) (models.Training, error)
+	FindByIDs(ids []string) []models.Training
 	Create(training *models.Training) error
 	Update(training *models.Training) error
 	Delete(training *models.Training) error
 	AddUser(training *models.Training, user *models.User) error
 	RemoveUser(training *models.Training, user *models.User) error
+	ReplaceTags(training *models.Training, tags []models.Tag) error
+	Enroll(training *models.Training, user *models.User, role string) (bool, error)
+	ImportMembers(training *models.Training, rows []dto.RosterRow, createMissing bool) ([]dto.RosterRowResult, error)
+	PromoteWaitlist(training *models.Training) ([]models.User, error)
+	FindWaitlist(trainingID string) []models.WaitlistEntry
+	RemoveFromWaitlist(trainingID, userID string) error
 	VerifyUserInTraining(trainingID, userID string) error
+	FindMember(trainingID, userID string) (models.Member, error)
+	FindMembers(trainingID string) []models.Member
+	UpdateMemberRole(trainingID, userID, role string) error
+	TransferOwnership(training *models.Training, newOwnerID string) error
 }
 
 type TrainingRepository struct {
@@ -40,10 +55,117 @@ func GetTrainingRepository() ITrainingRepository {
 	return trainingRepository
 }
 
-func (r *TrainingRepository) FindAll() []models.Training {
-	var trainings []models.Training
-	r.DB.Find(&trainings).Preload("Users")
-	return trainings
+func (r *TrainingRepository) FindAll(params dto.TrainingQueryParams) (dto.Page[models.Training], error) {
+	query := r.search(params, true, true)
+
+	switch params.Sort {
+	case "price":
+		// Cheapest first unless asked otherwise.
+		order := "price asc"
+		if params.Order == "desc" {
+			order = "price desc"
+		}
+		return findSortedPage[models.Training](query, params.PaginationQuery, order, "Category", "Tags")
+	case "popularity":
+		order := "(SELECT COUNT(*) FROM training_users WHERE training_users.training_id = trainings.id) desc"
+		if params.Order == "asc" {
+			order = "(SELECT COUNT(*) FROM training_users WHERE training_users.training_id = trainings.id) asc"
+		}
+		return findSortedPage[models.Training](query, params.PaginationQuery, order, "Category", "Tags")
+	case "rating":
+		// Best rated first, ties go to the training with more reviews.
+		order := "rating_average desc, rating_count desc"
+		if params.Order == "asc" {
+			order = "rating_average asc, rating_count asc"
+		}
+		return findSortedPage[models.Training](query, params.PaginationQuery, order, "Category", "Tags")
+	default:
+		return findPage[models.Training](query, params.PaginationQuery, "Category", "Tags")
+	}
+}
+
+func (r *TrainingRepository) CountFacets(params dto.TrainingQueryParams) (dto.TrainingFacets, error) {
+	facets := dto.TrainingFacets{
+		Categories:   []dto.FacetCount{},
+		PriceBuckets: []dto.FacetCount{},
+	}
+
+	// Each facet ignores its own filter, so the counts show what selecting another value would return.
+	err := r.search(params, false, true).
+		Joins("JOIN categories ON categories.id = trainings.category_id").
+		Select("categories.slug AS value, COUNT(*) AS count").
+		Group("categories.slug").
+		Order("count desc").
+		Scan(&facets.Categories).Error
+	if err != nil {
+		return facets, err
+	}
+
+	var buckets []dto.FacetCount
+	err = r.search(params, true, false).
+		Select("CASE " +
+			"WHEN trainings.price = 0 THEN 'free' " +
+			"WHEN trainings.price <= 50 THEN '1-50' " +
+			"WHEN trainings.price <= 100 THEN '51-100' " +
+			"WHEN trainings.price <= 200 THEN '101-200' " +
+			"ELSE '200+' END AS value, COUNT(*) AS count").
+		Group("value").
+		Scan(&buckets).Error
+	if err != nil {
+		return facets, err
+	}
+
+	counts := map[string]int64{}
+	for _, b := range buckets {
+		counts[b.Value] = b.Count
+	}
+
+	for _, value := range priceBuckets {
+		facets.PriceBuckets = append(facets.PriceBuckets, dto.FacetCount{Value: value, Count: counts[value]})
+	}
+
+	return facets, nil
+}
+
+var priceBuckets = []string{"free", "1-50", "51-100", "101-200", "200+"}
+
+// search builds the filtered training query, the category and price filters can be left out for facet counts.
+func (r *TrainingRepository) search(params dto.TrainingQueryParams, byCategory, byPrice bool) *gorm.DB {
+	// Only public trainings are listed in the catalog.
+	query := r.DB.Model(&models.Training{}).Where("trainings.visibility = ?", models.PublicVisibility)
+
+	if params.Query != "" {
+		if r.DB.Dialector.Name() == "postgres" {
+			query = query.Where(models.TrainingSearchVector+" @@ plainto_tsquery('simple', ?)", params.Query)
+		} else {
+			like := "%" + strings.ToLower(params.Query) + "%"
+			query = query.Where("LOWER(trainings.name) LIKE ? OR LOWER(trainings.description) LIKE ?", like, like)
+		}
+	}
+
+	// A category matches trainings of all its subcategories too.
+	if byCategory && params.Category != "" {
+		query = query.Where("trainings.category_id IN ("+
+			"WITH RECURSIVE tree AS ("+
+			"SELECT id FROM categories WHERE slug = ? "+
+			"UNION ALL SELECT categories.id FROM categories JOIN tree ON categories.parent_id = tree.id"+
+			") SELECT id FROM tree)", params.Category)
+	}
+
+	if params.Tag != "" {
+		query = query.Where("trainings.id IN (SELECT training_tags.training_id FROM training_tags "+
+			"JOIN tags ON tags.id = training_tags.tag_id WHERE tags.name = ?)", params.Tag)
+	}
+
+	if byPrice && params.MinPrice != nil {
+		query = query.Where("trainings.price >= ?", *params.MinPrice)
+	}
+
+	if byPrice && params.MaxPrice != nil {
+		query = query.Where("trainings.price <= ?", *params.MaxPrice)
+	}
+
+	return query
 }
 
 func (r *TrainingRepository) FindByID(id string) (models.Training, error) {
@@ -55,17 +177,26 @@ func (r *TrainingRepository) FindByID(id string) (models.Training, error) {
 
 func (r *TrainingRepository) FindByIdWithUsers(id string) (models.Training, error) {
 	var training models.Training
-	err := r.DB.Model(&models.Training{}).Preload("Users").Preload("Posts.Comments").Preload("Posts.Files").First(&training, "id = ?", id).Error
+	err := r.DB.Model(&models.Training{}).Preload("Category").Preload("Tags").Preload("Users").Preload("Posts.Comments").Preload("Posts.Files").First(&training, "id = ?", id).Error
 
 	return training, err
 }
 
+func (r *TrainingRepository) FindByIDs(ids []string) []models.Training {
+	var trainings []models.Training
+
+	r.DB.Find(&trainings, "id IN ?", ids)
+
+	return trainings
+}
+
 func (r *TrainingRepository) Create(training *models.Training) error {
 	return r.DB.Create(training).Error
 }
 
+// Update saves the training except for its rating aggregates, they are only written by the reviews.
 func (r *TrainingRepository) Update(training *models.Training) error {
-	return r.DB.Save(training).Error
+	return r.DB.Omit("RatingAverage", "RatingCount").Save(training).Error
 }
 
 func (r *TrainingRepository) Delete(training *models.Training) error {
@@ -80,18 +211,298 @@ func (r *TrainingRepository) RemoveUser(training *models.Training, user *models.
 	return r.DB.Model(training).Association("Users").Delete(user)
 }
 
-func (r *TrainingRepository) VerifyUserInTraining(trainingID, userID string) error {
-	var training models.Training
-	err := r.DB.Model(&models.Training{}).Preload("Users").First(&training, "id = ?", trainingID).Error
+func (r *TrainingRepository) ReplaceTags(training *models.Training, tags []models.Tag) error {
+	return r.DB.Model(training).Association("Tags").Replace(tags)
+}
+
+// Enroll adds the user to the training with the role if there is a free spot, otherwise it puts them at the end of the
+// waitlist and they get the role once promoted. It returns true when the user was waitlisted.
+// The training row is locked so concurrent enrollments can't overfill it.
+func (r *TrainingRepository) Enroll(training *models.Training, user *models.User, role string) (bool, error) {
+	waitlisted := false
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		current, err := r.lock(tx, training.ID)
+		if err != nil {
+			return err
+		}
+
+		var queued int64
+		err = tx.Model(&models.WaitlistEntry{}).Where("training_id = ? AND user_id = ?", training.ID, user.ID).Count(&queued).Error
+		if err != nil {
+			return err
+		}
+
+		if queued > 0 {
+			return errors.New("user already on the waitlist")
+		}
+
+		full, err := r.isFull(tx, current)
+		if err != nil {
+			return err
+		}
+
+		if !full {
+			return tx.Omit("User").Create(&models.Member{TrainingID: training.ID, UserID: user.ID, Role: role}).Error
+		}
+
+		waitlisted = true
+
+		return r.appendToWaitlist(tx, training.ID, user.ID, role)
+	})
+
+	return waitlisted, err
+}
+
+// ImportMembers enrolls the users of a roster in a single transaction. Rows are matched by email,
+// users that don't exist are created when createMissing is set and reported as not found otherwise.
+func (r *TrainingRepository) ImportMembers(training *models.Training, rows []dto.RosterRow, createMissing bool) ([]dto.RosterRowResult, error) {
+	results := []dto.RosterRowResult{}
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		current, err := r.lock(tx, training.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			result := dto.RosterRowResult{Row: row.Line, Email: row.Email}
+
+			var user models.User
+			err = tx.Where("LOWER(email) = ?", row.Email).Limit(1).Find(&user).Error
+			if err != nil {
+				return err
+			}
+
+			if user.ID == "" {
+				if !createMissing {
+					result.Status = dto.RosterNotFound
+					results = append(results, result)
+					continue
+				}
+
+				user = models.User{
+					FirstName: row.FirstName,
+					LastName:  row.LastName,
+					Email:     row.Email,
+					Roles:     []string{models.UserRole},
+				}
+
+				err = tx.Create(&user).Error
+				if err != nil {
+					return err
+				}
+
+				result.Created = true
+			}
+
+			result.UserID = user.ID
+
+			var members, queued int64
+			err = tx.Model(&models.Member{}).Where("training_id = ? AND user_id = ?", training.ID, user.ID).Count(&members).Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.Model(&models.WaitlistEntry{}).Where("training_id = ? AND user_id = ?", training.ID, user.ID).Count(&queued).Error
+			if err != nil {
+				return err
+			}
+
+			if members > 0 || queued > 0 {
+				result.Status = dto.RosterAlreadyEnrolled
+				if members == 0 {
+					result.Status = dto.RosterAlreadyWaitlisted
+				}
+				results = append(results, result)
+				continue
+			}
+
+			full, err := r.isFull(tx, current)
+			if err != nil {
+				return err
+			}
+
+			if full {
+				err = r.appendToWaitlist(tx, training.ID, user.ID, row.Role)
+				result.Status = dto.RosterWaitlisted
+			} else {
+				err = tx.Omit("User").Create(&models.Member{TrainingID: training.ID, UserID: user.ID, Role: row.Role}).Error
+				result.Status = dto.RosterAdded
+			}
+			if err != nil {
+				return err
+			}
+
+			results = append(results, result)
+		}
+
+		return nil
+	})
+
+	return results, err
+}
+
+// PromoteWaitlist moves users from the front of the waitlist into the training while there are free spots.
+func (r *TrainingRepository) PromoteWaitlist(training *models.Training) ([]models.User, error) {
+	promoted := []models.User{}
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		current, err := r.lock(tx, training.ID)
+		if err != nil {
+			return err
+		}
+
+		for {
+			full, err := r.isFull(tx, current)
+			if err != nil || full {
+				return err
+			}
+
+			var next models.WaitlistEntry
+			err = tx.Model(&models.WaitlistEntry{}).Preload("User").
+				Where("training_id = ?", training.ID).
+				Order("position").
+				Limit(1).
+				Find(&next).Error
+			if err != nil || next.ID == "" {
+				return err
+			}
+
+			err = tx.Omit("User").Create(&models.Member{TrainingID: training.ID, UserID: next.UserID, Role: next.Role}).Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.Delete(&next).Error
+			if err != nil {
+				return err
+			}
+
+			promoted = append(promoted, next.User)
+		}
+	})
+
+	return promoted, err
+}
+
+func (r *TrainingRepository) FindWaitlist(trainingID string) []models.WaitlistEntry {
+	var entries []models.WaitlistEntry
+
+	r.DB.Model(&models.WaitlistEntry{}).Preload("User").Order("position").Find(&entries, "training_id = ?", trainingID)
+
+	return entries
+}
+
+func (r *TrainingRepository) RemoveFromWaitlist(trainingID, userID string) error {
+	result := r.DB.Where("training_id = ? AND user_id = ?", trainingID, userID).Delete(&models.WaitlistEntry{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.New("user is not on the waitlist")
+	}
+
+	return nil
+}
+
+func (r *TrainingRepository) appendToWaitlist(tx *gorm.DB, trainingID, userID, role string) error {
+	var last struct{ Position int }
+	err := tx.Model(&models.WaitlistEntry{}).Select("COALESCE(MAX(position), 0) AS position").Where("training_id = ?", trainingID).Scan(&last).Error
 	if err != nil {
 		return err
 	}
 
-	for _, user := range training.Users {
-		if user.ID == userID {
-			return nil
-		}
+	return tx.Omit("Training", "User").Create(&models.WaitlistEntry{
+		TrainingID: trainingID,
+		UserID:     userID,
+		Position:   last.Position + 1,
+		Role:       role,
+	}).Error
+}
+
+// lock reloads the training inside tx, locking its row on databases that support it.
+func (r *TrainingRepository) lock(tx *gorm.DB, id string) (models.Training, error) {
+	var training models.Training
+
+	query := tx.Model(&models.Training{})
+	if tx.Dialector.Name() == "postgres" {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	err := query.First(&training, "id = ?", id).Error
+
+	return training, err
+}
+
+// isFull reports whether a training reached its capacity, the owner doesn't take up a spot.
+func (r *TrainingRepository) isFull(tx *gorm.DB, training models.Training) (bool, error) {
+	if training.Capacity <= 0 {
+		return false, nil
+	}
+
+	var members int64
+	err := tx.Table("training_users").Where("training_id = ? AND user_id <> ?", training.ID, training.OwnerID).Count(&members).Error
+	if err != nil {
+		return false, err
 	}
 
-	return errors.New("user is not in training")
+	return members >= int64(training.Capacity), nil
+}
+
+func (r *TrainingRepository) VerifyUserInTraining(trainingID, userID string) error {
+	_, err := r.FindMember(trainingID, userID)
+	if err != nil {
+		return errors.New("user is not in training")
+	}
+
+	return nil
+}
+
+func (r *TrainingRepository) FindMember(trainingID, userID string) (models.Member, error) {
+	var member models.Member
+	err := r.DB.First(&member, "training_id = ? AND user_id = ?", trainingID, userID).Error
+
+	return member, err
+}
+
+func (r *TrainingRepository) FindMembers(trainingID string) []models.Member {
+	var members []models.Member
+
+	r.DB.Model(&models.Member{}).Preload("User").Order("created_at").Find(&members, "training_id = ?", trainingID)
+
+	return members
+}
+
+func (r *TrainingRepository) UpdateMemberRole(trainingID, userID, role string) error {
+	return r.DB.Model(&models.Member{}).
+		Where("training_id = ? AND user_id = ?", trainingID, userID).
+		Update("role", role).Error
+}
+
+// TransferOwnership makes another member the owner, the previous owner stays in the training as co-owner.
+func (r *TrainingRepository) TransferOwnership(training *models.Training, newOwnerID string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Member{}).
+			Where("training_id = ? AND user_id = ?", training.ID, training.OwnerID).
+			Update("role", models.CoOwnerMember).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.Member{}).
+			Where("training_id = ? AND user_id = ?", training.ID, newOwnerID).
+			Update("role", models.OwnerMember).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(training).Update("owner_id", newOwnerID).Error
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
 }
@@ -3,17 +3,21 @@ package repositories
 import (
 	"sync"
 
+	"github.com/Marcel-MD/xmas-faf-api/dto"
 	"github.com/Marcel-MD/xmas-faf-api/models"
 	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
 )
 
 type IUserRepository interface {
-	FindAll() []models.User
+	FindAll(params dto.PaginationQuery) (dto.Page[models.User], error)
 	SearchByEmail(email string) []models.User
 	FindByID(id string) (models.User, error)
+	FindByIDs(ids []string) []models.User
 	FindByIdWithTrainings(id string) (models.User, error)
+	FindByIdWithPublicTrainings(id string) (models.User, error)
 	FindByEmail(email string) (models.User, error)
+	FindByCalendarToken(token string) (models.User, error)
 	Create(user *models.User) error
 	Update(user *models.User) error
 }
@@ -37,10 +41,10 @@ func GetUserRepository() IUserRepository {
 	return userRepository
 }
 
-func (r *UserRepository) FindAll() []models.User {
-	var users []models.User
-	r.DB.Find(&users)
-	return users
+func (r *UserRepository) FindAll(params dto.PaginationQuery) (dto.Page[models.User], error) {
+	query := r.DB.Model(&models.User{})
+
+	return findPage[models.User](query, params)
 }
 
 func (r *UserRepository) FindByID(id string) (models.User, error) {
@@ -50,6 +54,12 @@ func (r *UserRepository) FindByID(id string) (models.User, error) {
 	return user, err
 }
 
+func (r *UserRepository) FindByIDs(ids []string) []models.User {
+	var users []models.User
+	r.DB.Where("id IN ?", ids).Find(&users)
+	return users
+}
+
 func (r *UserRepository) SearchByEmail(email string) []models.User {
 	var users []models.User
 	r.DB.Where("email LIKE ?", "%"+email+"%").Find(&users)
@@ -63,6 +73,14 @@ func (r *UserRepository) FindByIdWithTrainings(id string) (models.User, error) {
 	return user, err
 }
 
+// FindByIdWithPublicTrainings finds a user with only the public trainings they are in, for showing them to others.
+func (r *UserRepository) FindByIdWithPublicTrainings(id string) (models.User, error) {
+	var user models.User
+	err := r.DB.Model(&models.User{}).Preload("Trainings", "visibility = ?", models.PublicVisibility).First(&user, "id = ?", id).Error
+
+	return user, err
+}
+
 func (r *UserRepository) FindByEmail(email string) (models.User, error) {
 	var user models.User
 	err := r.DB.First(&user, "email = ?", email).Error
@@ -70,10 +88,19 @@ func (r *UserRepository) FindByEmail(email string) (models.User, error) {
 	return user, err
 }
 
+func (r *UserRepository) FindByCalendarToken(token string) (models.User, error) {
+	var user models.User
+	err := r.DB.First(&user, "calendar_token = ?", token).Error
+
+	return user, err
+}
+
 func (r *UserRepository) Create(user *models.User) error {
 	return r.DB.Create(user).Error
 }
 
+// Update saves the user except their points, which only change through the ledger so awards made
+// since the user was read aren't overwritten.
 func (r *UserRepository) Update(user *models.User) error {
-	return r.DB.Save(user).Error
+	return r.DB.Omit("Points").Save(user).Error
 }
@@ -0,0 +1,526 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+type IAssignmentService interface {
+	FindByTrainingID(trainingID, userID string) ([]models.Assignment, error)
+	FindOne(id, userID string) (models.Assignment, error)
+	Create(trainingID, userID string, dto dto.CreateAssignment) (models.Assignment, error)
+	Update(id, userID string, dto dto.UpdateAssignment) (models.Assignment, error)
+	Delete(id, userID string) error
+	Submit(id, userID, text string, uploads []dto.Upload) (models.Submission, error)
+	FindSubmissions(id, userID string) ([]models.Submission, error)
+	FindUserSubmissions(id, memberID, userID string) ([]models.Submission, error)
+	Grade(submissionID, userID string, dto dto.GradeSubmission) (models.Submission, error)
+	Gradebook(trainingID, userID string) (dto.Gradebook, error)
+	ExportGradebook(trainingID, userID string) ([]byte, error)
+}
+
+type AssignmentService struct {
+	assignmentRepository repositories.IAssignmentRepository
+	trainingRepository   repositories.ITrainingRepository
+	fileService          IFileService
+}
+
+const maxSubmissionFiles = 10
+
+var (
+	assignmentOnce    sync.Once
+	assignmentService IAssignmentService
+)
+
+func GetAssignmentService() IAssignmentService {
+	assignmentOnce.Do(func() {
+		log.Info().Msg("Initializing assignment service")
+		assignmentService = &AssignmentService{
+			assignmentRepository: repositories.GetAssignmentRepository(),
+			trainingRepository:   repositories.GetTrainingRepository(),
+			fileService:          GetFileService(),
+		}
+	})
+	return assignmentService
+}
+
+func (s *AssignmentService) FindByTrainingID(trainingID, userID string) ([]models.Assignment, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Msg("Finding assignments")
+
+	member, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err != nil {
+		return nil, errors.New("user is not in training")
+	}
+
+	assignments := s.assignmentRepository.FindByTrainingID(trainingID)
+	if member.CanPost() {
+		return assignments, nil
+	}
+
+	return publishedAssignments(assignments), nil
+}
+
+func (s *AssignmentService) FindOne(id, userID string) (models.Assignment, error) {
+	log.Debug().Str(logger.AssignmentID, id).Msg("Finding assignment")
+
+	assignment, _, err := s.findAssignment(id, userID)
+
+	return assignment, err
+}
+
+func (s *AssignmentService) Create(trainingID, userID string, dto dto.CreateAssignment) (models.Assignment, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Creating assignment")
+
+	err := s.verifyCanPost(trainingID, userID)
+	if err != nil {
+		return models.Assignment{}, err
+	}
+
+	assignment := models.Assignment{TrainingID: trainingID}
+
+	err = fillAssignment(&assignment, dto)
+	if err != nil {
+		return assignment, err
+	}
+
+	err = s.assignmentRepository.Create(&assignment)
+	if err != nil {
+		return assignment, err
+	}
+
+	return assignment, nil
+}
+
+func (s *AssignmentService) Update(id, userID string, dto dto.UpdateAssignment) (models.Assignment, error) {
+	log.Debug().Str(logger.AssignmentID, id).Str(logger.UserID, userID).Msg("Updating assignment")
+
+	assignment, err := s.assignmentRepository.FindByID(id)
+	if err != nil {
+		return assignment, err
+	}
+
+	err = s.verifyCanPost(assignment.TrainingID, userID)
+	if err != nil {
+		return assignment, err
+	}
+
+	err = fillAssignment(&assignment, createAssignment(dto))
+	if err != nil {
+		return assignment, err
+	}
+
+	err = s.assignmentRepository.Update(&assignment)
+	if err != nil {
+		return assignment, err
+	}
+
+	return assignment, nil
+}
+
+func (s *AssignmentService) Delete(id, userID string) error {
+	log.Debug().Str(logger.AssignmentID, id).Str(logger.UserID, userID).Msg("Deleting assignment")
+
+	assignment, err := s.assignmentRepository.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.verifyCanPost(assignment.TrainingID, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.assignmentRepository.Delete(&assignment)
+}
+
+// Submit adds a new version of the work of a learner, submissions after the due date are flagged as late.
+func (s *AssignmentService) Submit(id, userID, text string, uploads []dto.Upload) (models.Submission, error) {
+	log.Debug().Str(logger.AssignmentID, id).Str(logger.UserID, userID).Msg("Submitting assignment")
+
+	assignment, member, err := s.findAssignment(id, userID)
+	if err != nil {
+		return models.Submission{}, err
+	}
+
+	if member.CanPost() {
+		return models.Submission{}, errors.New("only learners can submit assignments")
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" && len(uploads) == 0 {
+		return models.Submission{}, errors.New("submission has no text or files")
+	}
+
+	if len(uploads) > maxSubmissionFiles {
+		return models.Submission{}, errors.New("submission can't have more than 10 files")
+	}
+
+	// The ID is known before the files are uploaded so they are stored under their submission.
+	submissionID := uuid.New().String()
+
+	files := []models.File{}
+	for _, upload := range uploads {
+		file, err := s.fileService.UploadTo("submissions/"+submissionID, upload.Name, upload.Data)
+		if err != nil {
+			return models.Submission{}, err
+		}
+
+		files = append(files, file)
+	}
+
+	now := time.Now()
+	submission := models.Submission{
+		Base:         models.Base{ID: submissionID},
+		AssignmentID: assignment.ID,
+		UserID:       userID,
+		TrainingID:   assignment.TrainingID,
+		Text:         text,
+		Files:        files,
+		Late:         assignment.DueAt != nil && now.After(*assignment.DueAt),
+		RubricScores: models.List[float64]{},
+	}
+
+	err = s.assignmentRepository.CreateSubmission(&submission)
+	if err != nil {
+		return submission, err
+	}
+
+	return submission, nil
+}
+
+// FindSubmissions returns the latest version submitted by every learner.
+func (s *AssignmentService) FindSubmissions(id, userID string) ([]models.Submission, error) {
+	log.Debug().Str(logger.AssignmentID, id).Msg("Finding submissions")
+
+	assignment, err := s.assignmentRepository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.verifyCanModerate(assignment.TrainingID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := map[string]int{}
+	submissions := []models.Submission{}
+	for _, submission := range s.assignmentRepository.FindSubmissions(assignment.ID) {
+		if i, ok := latest[submission.UserID]; ok {
+			submissions[i] = submission
+			continue
+		}
+
+		latest[submission.UserID] = len(submissions)
+		submissions = append(submissions, submission)
+	}
+
+	return submissions, nil
+}
+
+// FindUserSubmissions returns every version submitted by a user, to themselves or to staff.
+func (s *AssignmentService) FindUserSubmissions(id, memberID, userID string) ([]models.Submission, error) {
+	log.Debug().Str(logger.AssignmentID, id).Str(logger.UserID, memberID).Msg("Finding user submissions")
+
+	assignment, err := s.assignmentRepository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if memberID != userID {
+		err = s.verifyCanModerate(assignment.TrainingID, userID)
+	} else {
+		err = s.trainingRepository.VerifyUserInTraining(assignment.TrainingID, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.assignmentRepository.FindUserSubmissions(assignment.ID, memberID), nil
+}
+
+func (s *AssignmentService) Grade(submissionID, userID string, dto dto.GradeSubmission) (models.Submission, error) {
+	log.Debug().Str(logger.SubmissionID, submissionID).Str(logger.UserID, userID).Msg("Grading submission")
+
+	submission, err := s.assignmentRepository.FindSubmissionByID(submissionID)
+	if err != nil {
+		return submission, err
+	}
+
+	member, err := s.trainingRepository.FindMember(submission.TrainingID, userID)
+	if err != nil || !member.CanPost() {
+		return submission, errors.New("you are not allowed to grade this assignment")
+	}
+
+	assignment, err := s.assignmentRepository.FindByID(submission.AssignmentID)
+	if err != nil {
+		return submission, err
+	}
+
+	score, err := gradeScore(assignment, dto)
+	if err != nil {
+		return submission, err
+	}
+
+	now := time.Now()
+	submission.Score = &score
+	submission.RubricScores = dto.RubricScores
+	submission.Feedback = dto.Feedback
+	submission.GradedAt = &now
+	submission.GradedByID = &userID
+
+	err = s.assignmentRepository.UpdateSubmission(&submission)
+	if err != nil {
+		return submission, err
+	}
+
+	return submission, nil
+}
+
+// Gradebook lists the grades of every learner for the published assignments of a training,
+// missing and ungraded work counts as zero in the total.
+func (s *AssignmentService) Gradebook(trainingID, userID string) (dto.Gradebook, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Msg("Finding gradebook")
+
+	err := s.verifyCanModerate(trainingID, userID)
+	if err != nil {
+		return dto.Gradebook{}, err
+	}
+
+	assignments := publishedAssignments(s.assignmentRepository.FindByTrainingID(trainingID))
+
+	// Submissions come ordered by version, so later versions replace earlier ones.
+	latest := map[string]models.Submission{}
+	graded := map[string]models.Submission{}
+	for _, submission := range s.assignmentRepository.FindSubmissionsByTrainingID(trainingID) {
+		key := submission.AssignmentID + ":" + submission.UserID
+		latest[key] = submission
+		if submission.GradedAt != nil {
+			graded[key] = submission
+		}
+	}
+
+	gradebook := dto.Gradebook{
+		Assignments: []dto.GradebookAssignment{},
+		Rows:        []dto.GradebookRow{},
+	}
+
+	maxScore := 0.0
+	for _, assignment := range assignments {
+		gradebook.Assignments = append(gradebook.Assignments, dto.GradebookAssignment{
+			ID:       assignment.ID,
+			Title:    assignment.Title,
+			DueAt:    assignment.DueAt,
+			MaxScore: assignment.MaxScore,
+		})
+		maxScore += assignment.MaxScore
+	}
+
+	for _, member := range s.trainingRepository.FindMembers(trainingID) {
+		if member.CanPost() {
+			continue
+		}
+
+		row := dto.GradebookRow{
+			UserID:    member.UserID,
+			FirstName: member.User.FirstName,
+			LastName:  member.User.LastName,
+			Email:     member.User.Email,
+			Grades:    []dto.GradebookGrade{},
+			MaxScore:  maxScore,
+		}
+
+		for _, assignment := range assignments {
+			key := assignment.ID + ":" + member.UserID
+			grade := dto.GradebookGrade{
+				AssignmentID: assignment.ID,
+				Status:       models.MissingSubmission,
+			}
+
+			if submission, ok := latest[key]; ok {
+				grade.Status = submission.Status()
+				grade.Late = submission.Late
+				grade.Version = submission.Version
+			}
+
+			if submission, ok := graded[key]; ok {
+				grade.Score = submission.Score
+				row.Score += *submission.Score
+			}
+
+			row.Grades = append(row.Grades, grade)
+		}
+
+		if row.MaxScore > 0 {
+			row.Percent = roundPercent(row.Score / row.MaxScore * 100)
+		}
+
+		gradebook.Rows = append(gradebook.Rows, row)
+	}
+
+	return gradebook, nil
+}
+
+func (s *AssignmentService) ExportGradebook(trainingID, userID string) ([]byte, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Exporting gradebook")
+
+	gradebook, err := s.Gradebook(trainingID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	header := []string{"Email", "First Name", "Last Name"}
+	for _, assignment := range gradebook.Assignments {
+		header = append(header, assignment.Title+" ("+formatScore(assignment.MaxScore)+")")
+	}
+	header = append(header, "Total", "Percent")
+
+	records := [][]string{header}
+	for _, row := range gradebook.Rows {
+		record := []string{row.Email, row.FirstName, row.LastName}
+
+		for _, grade := range row.Grades {
+			cell := grade.Status
+			if grade.Score != nil {
+				cell = formatScore(*grade.Score)
+			}
+
+			if grade.Late {
+				cell += " (late)"
+			}
+
+			record = append(record, cell)
+		}
+
+		record = append(record, formatScore(row.Score), formatScore(row.Percent))
+		records = append(records, record)
+	}
+
+	return writeCsv(records)
+}
+
+// findAssignment returns an assignment the user can see along with their membership.
+func (s *AssignmentService) findAssignment(id, userID string) (models.Assignment, models.Member, error) {
+	assignment, err := s.assignmentRepository.FindByID(id)
+	if err != nil {
+		return assignment, models.Member{}, err
+	}
+
+	member, err := s.trainingRepository.FindMember(assignment.TrainingID, userID)
+	if err != nil {
+		return models.Assignment{}, member, errors.New("user is not in training")
+	}
+
+	if !assignment.Published && !member.CanPost() {
+		return models.Assignment{}, member, errors.New("assignment not found")
+	}
+
+	return assignment, member, nil
+}
+
+func (s *AssignmentService) verifyCanPost(trainingID, userID string) error {
+	member, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err != nil || !member.CanPost() {
+		return errors.New("you are not allowed to edit the content of this training")
+	}
+
+	return nil
+}
+
+func (s *AssignmentService) verifyCanModerate(trainingID, userID string) error {
+	member, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err != nil || !member.CanModerate() {
+		return errors.New("you are not allowed to see submissions of this training")
+	}
+
+	return nil
+}
+
+func createAssignment(update dto.UpdateAssignment) dto.CreateAssignment {
+	return dto.CreateAssignment(update)
+}
+
+// fillAssignment sets the max score to the sum of the rubric when there is one.
+func fillAssignment(assignment *models.Assignment, dto dto.CreateAssignment) error {
+	rubric := models.List[models.RubricCriterion]{}
+	total := 0.0
+	for _, criterion := range dto.Rubric {
+		rubric = append(rubric, models.RubricCriterion{
+			Title:       criterion.Title,
+			Description: criterion.Description,
+			Points:      criterion.Points,
+		})
+		total += criterion.Points
+	}
+
+	if len(rubric) > 0 {
+		dto.MaxScore = total
+	}
+
+	if dto.MaxScore <= 0 {
+		return errors.New("assignment needs a max score or a rubric")
+	}
+
+	assignment.Title = dto.Title
+	assignment.Description = dto.Description
+	assignment.MaxScore = dto.MaxScore
+	assignment.Rubric = rubric
+	assignment.Published = dto.Published
+	assignment.DueAt = nil
+
+	if dto.DueAt != nil {
+		dueAt := dto.DueAt.UTC()
+		assignment.DueAt = &dueAt
+	}
+
+	return nil
+}
+
+func gradeScore(assignment models.Assignment, dto dto.GradeSubmission) (float64, error) {
+	if len(assignment.Rubric) == 0 {
+		if dto.Score == nil || *dto.Score < 0 || *dto.Score > assignment.MaxScore {
+			return 0, errors.New("score has to be between 0 and the max score")
+		}
+
+		return *dto.Score, nil
+	}
+
+	if len(dto.RubricScores) != len(assignment.Rubric) {
+		return 0, errors.New("every rubric criterion has to be scored")
+	}
+
+	score := 0.0
+	for i, criterion := range assignment.Rubric {
+		if dto.RubricScores[i] < 0 || dto.RubricScores[i] > criterion.Points {
+			return 0, errors.New("rubric score has to be between 0 and the points of the criterion")
+		}
+		score += dto.RubricScores[i]
+	}
+
+	return score, nil
+}
+
+func publishedAssignments(assignments []models.Assignment) []models.Assignment {
+	published := []models.Assignment{}
+	for _, assignment := range assignments {
+		if assignment.Published {
+			published = append(published, assignment)
+		}
+	}
+
+	return published
+}
+
+func formatScore(score float64) string {
+	return strconv.FormatFloat(math.Round(score*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,374 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+type IAttendanceService interface {
+	CheckInCode(sessionID, userID string) (dto.CheckInCode, error)
+	CheckIn(sessionID, userID string, dto dto.CheckIn) (models.Attendance, error)
+	Mark(sessionID, memberID, userID string, dto dto.MarkAttendance) (models.Attendance, error)
+	FindByOccurrence(sessionID, userID string, query dto.AttendanceQuery) ([]models.Attendance, error)
+	TrainingReport(trainingID, userID string) ([]dto.AttendanceSummary, error)
+	UserReport(trainingID, memberID, userID string) ([]dto.AttendanceRecord, error)
+}
+
+type AttendanceService struct {
+	attendanceRepository repositories.IAttendanceRepository
+	sessionRepository    repositories.ISessionRepository
+	trainingRepository   repositories.ITrainingRepository
+	pointsService        IPointsService
+	secret               []byte
+}
+
+const (
+	// Check-in opens a bit before an occurrence starts and closes when it ends.
+	checkInEarly = 15 * time.Minute
+	// Codes rotate every period, the previous code is still accepted to allow for typing.
+	checkInCodePeriod = time.Minute
+	// Reports cover the occurrences of the last year.
+	attendanceReportRange = 366 * 24 * time.Hour
+)
+
+var (
+	attendanceOnce    sync.Once
+	attendanceService IAttendanceService
+)
+
+func GetAttendanceService() IAttendanceService {
+	attendanceOnce.Do(func() {
+		log.Info().Msg("Initializing attendance service")
+		attendanceService = &AttendanceService{
+			attendanceRepository: repositories.GetAttendanceRepository(),
+			sessionRepository:    repositories.GetSessionRepository(),
+			trainingRepository:   repositories.GetTrainingRepository(),
+			pointsService:        GetPointsService(),
+			secret:               []byte(os.Getenv("API_SECRET")),
+		}
+	})
+	return attendanceService
+}
+
+func (s *AttendanceService) CheckInCode(sessionID, userID string) (dto.CheckInCode, error) {
+	log.Debug().Str(logger.SessionID, sessionID).Str(logger.UserID, userID).Msg("Generating check-in code")
+
+	session, err := s.sessionRepository.FindByID(sessionID)
+	if err != nil {
+		return dto.CheckInCode{}, err
+	}
+
+	member, err := s.trainingRepository.FindMember(session.TrainingID, userID)
+	if err != nil || !member.CanPost() {
+		return dto.CheckInCode{}, errors.New("you are not allowed to take attendance")
+	}
+
+	occurrence, err := currentOccurrence(session, time.Now())
+	if err != nil {
+		return dto.CheckInCode{}, err
+	}
+
+	step := time.Now().Unix() / int64(checkInCodePeriod.Seconds())
+	code := s.code(session.ID, occurrence, step)
+
+	return dto.CheckInCode{
+		Code:         code,
+		QrPayload:    session.ID + ":" + code,
+		OccurrenceAt: occurrence,
+		ExpiresAt:    time.Unix((step+1)*int64(checkInCodePeriod.Seconds()), 0).UTC(),
+	}, nil
+}
+
+func (s *AttendanceService) CheckIn(sessionID, userID string, dto dto.CheckIn) (models.Attendance, error) {
+	log.Debug().Str(logger.SessionID, sessionID).Str(logger.UserID, userID).Msg("Checking in")
+
+	session, err := s.sessionRepository.FindByID(sessionID)
+	if err != nil {
+		return models.Attendance{}, err
+	}
+
+	err = s.trainingRepository.VerifyUserInTraining(session.TrainingID, userID)
+	if err != nil {
+		return models.Attendance{}, err
+	}
+
+	occurrence, err := currentOccurrence(session, time.Now())
+	if err != nil {
+		return models.Attendance{}, err
+	}
+
+	// A scanned QR payload carries the session ID in front of the code.
+	code := strings.TrimPrefix(strings.TrimSpace(dto.Code), session.ID+":")
+
+	step := time.Now().Unix() / int64(checkInCodePeriod.Seconds())
+	if !hmac.Equal([]byte(code), []byte(s.code(session.ID, occurrence, step))) &&
+		!hmac.Equal([]byte(code), []byte(s.code(session.ID, occurrence, step-1))) {
+		return models.Attendance{}, errors.New("check-in code is not valid")
+	}
+
+	attendance := models.Attendance{
+		SessionID:    session.ID,
+		OccurrenceAt: occurrence,
+		UserID:       userID,
+		Status:       models.PresentAttendance,
+		Method:       models.CodeCheckIn,
+		MarkedByID:   userID,
+	}
+
+	err = s.attendanceRepository.Save(&attendance)
+	if err != nil {
+		return attendance, err
+	}
+
+	s.awardAttendance(session, attendance)
+
+	return attendance, nil
+}
+
+func (s *AttendanceService) Mark(sessionID, memberID, userID string, dto dto.MarkAttendance) (models.Attendance, error) {
+	log.Debug().Str(logger.SessionID, sessionID).Str(logger.UserID, memberID).Msg("Marking attendance")
+
+	session, err := s.sessionRepository.FindByID(sessionID)
+	if err != nil {
+		return models.Attendance{}, err
+	}
+
+	member, err := s.trainingRepository.FindMember(session.TrainingID, userID)
+	if err != nil || !member.CanPost() {
+		return models.Attendance{}, errors.New("you are not allowed to take attendance")
+	}
+
+	err = s.trainingRepository.VerifyUserInTraining(session.TrainingID, memberID)
+	if err != nil {
+		return models.Attendance{}, err
+	}
+
+	occurrences, err := session.Occurrences(dto.OccurrenceAt, dto.OccurrenceAt.Add(time.Second))
+	if err != nil {
+		return models.Attendance{}, err
+	}
+
+	if len(occurrences) == 0 {
+		return models.Attendance{}, errors.New("session has no occurrence at this time")
+	}
+
+	attendance := models.Attendance{
+		SessionID:    session.ID,
+		OccurrenceAt: occurrences[0],
+		UserID:       memberID,
+		Status:       dto.Status,
+		Method:       models.ManualCheckIn,
+		MarkedByID:   userID,
+	}
+
+	err = s.attendanceRepository.Save(&attendance)
+	if err != nil {
+		return attendance, err
+	}
+
+	s.awardAttendance(session, attendance)
+
+	return attendance, nil
+}
+
+// awardAttendance gives points for being present at an occurrence, once per occurrence.
+func (s *AttendanceService) awardAttendance(session models.Session, attendance models.Attendance) {
+	if attendance.Status != models.PresentAttendance {
+		return
+	}
+
+	sourceID := session.ID + ":" + attendance.OccurrenceAt.UTC().Format(time.RFC3339)
+	s.pointsService.Award(attendance.UserID, models.AttendancePoints, &session.TrainingID, sourceID)
+}
+
+func (s *AttendanceService) FindByOccurrence(sessionID, userID string, query dto.AttendanceQuery) ([]models.Attendance, error) {
+	log.Debug().Str(logger.SessionID, sessionID).Msg("Finding attendance")
+
+	session, err := s.sessionRepository.FindByID(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.verifyCanSeeReports(session.TrainingID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.attendanceRepository.FindByOccurrence(session.ID, query.OccurrenceAt), nil
+}
+
+// TrainingReport sums up the attendance of every learner over the past occurrences since they joined.
+// Occurrences nobody marked count as unmarked and lower the attendance rate like absences do.
+func (s *AttendanceService) TrainingReport(trainingID, userID string) ([]dto.AttendanceSummary, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Msg("Finding training attendance report")
+
+	err := s.verifyCanSeeReports(trainingID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	to := time.Now()
+	from := to.Add(-attendanceReportRange)
+	sessions := s.sessionRepository.FindByTrainingID(trainingID)
+	attendances := s.attendanceRepository.FindByTrainingID(trainingID, from, to)
+
+	summaries := []dto.AttendanceSummary{}
+	for _, member := range s.trainingRepository.FindMembers(trainingID) {
+		// Staff who take attendance aren't expected to check in themselves.
+		if member.CanPost() {
+			continue
+		}
+
+		records, err := attendanceRecords(sessions, attendances, member, from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		summary := dto.AttendanceSummary{
+			UserID:    member.UserID,
+			FirstName: member.User.FirstName,
+			LastName:  member.User.LastName,
+			Email:     member.User.Email,
+			Role:      member.Role,
+		}
+
+		for _, record := range records {
+			switch record.Status {
+			case models.PresentAttendance:
+				summary.Present++
+			case models.AbsentAttendance:
+				summary.Absent++
+			case models.ExcusedAttendance:
+				summary.Excused++
+			default:
+				summary.Unmarked++
+			}
+		}
+
+		if counted := len(records) - summary.Excused; counted > 0 {
+			summary.Rate = float64(summary.Present) / float64(counted)
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+func (s *AttendanceService) UserReport(trainingID, memberID, userID string) ([]dto.AttendanceRecord, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, memberID).Msg("Finding user attendance report")
+
+	if memberID != userID {
+		err := s.verifyCanSeeReports(trainingID, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	member, err := s.trainingRepository.FindMember(trainingID, memberID)
+	if err != nil {
+		return nil, errors.New("user is not in training")
+	}
+
+	to := time.Now()
+	from := to.Add(-attendanceReportRange)
+	sessions := s.sessionRepository.FindByTrainingID(trainingID)
+	attendances := s.attendanceRepository.FindByTrainingID(trainingID, from, to)
+
+	return attendanceRecords(sessions, attendances, member, from, to)
+}
+
+func (s *AttendanceService) verifyCanSeeReports(trainingID, userID string) error {
+	member, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err != nil || !member.CanModerate() {
+		return errors.New("you are not allowed to see attendance of this training")
+	}
+
+	return nil
+}
+
+// code derives the six digit check-in code of an occurrence for a time step, so every replica
+// shows and accepts the same codes without storing them.
+func (s *AttendanceService) code(sessionID string, occurrence time.Time, step int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s:%d:%d", sessionID, occurrence.Unix(), step)
+	sum := mac.Sum(nil)
+
+	return fmt.Sprintf("%06d", binary.BigEndian.Uint32(sum)%1000000)
+}
+
+// currentOccurrence returns the start of the occurrence of the session that is open for check-in at now.
+func currentOccurrence(session models.Session, now time.Time) (time.Time, error) {
+	occurrences, err := session.Occurrences(now.Add(-session.Duration()), now.Add(checkInEarly+time.Second))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if len(occurrences) == 0 {
+		return time.Time{}, errors.New("check-in is not open for this session")
+	}
+
+	return occurrences[0].UTC(), nil
+}
+
+// attendanceRecords lists the occurrences of the sessions within [from, to) that happened since
+// the member joined, along with the attendance of the member.
+func attendanceRecords(sessions []models.Session, attendances []models.Attendance, member models.Member, from, to time.Time) ([]dto.AttendanceRecord, error) {
+	if member.CreatedAt.After(from) {
+		from = member.CreatedAt
+	}
+
+	marked := map[string]models.Attendance{}
+	for _, attendance := range attendances {
+		if attendance.UserID == member.UserID {
+			marked[attendanceKey(attendance.SessionID, attendance.OccurrenceAt)] = attendance
+		}
+	}
+
+	records := []dto.AttendanceRecord{}
+	for _, session := range sessions {
+		occurrences, err := session.Occurrences(from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, occurrence := range occurrences {
+			record := dto.AttendanceRecord{
+				SessionID:    session.ID,
+				Title:        session.Title,
+				OccurrenceAt: occurrence.UTC(),
+			}
+
+			if attendance, ok := marked[attendanceKey(session.ID, occurrence)]; ok {
+				record.Status = attendance.Status
+				record.Method = attendance.Method
+			}
+
+			records = append(records, record)
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].OccurrenceAt.Before(records[j].OccurrenceAt)
+	})
+
+	return records, nil
+}
+
+func attendanceKey(sessionID string, occurrence time.Time) string {
+	return fmt.Sprintf("%s:%d", sessionID, occurrence.Unix())
+}
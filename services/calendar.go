@@ -0,0 +1,209 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+)
+
+const (
+	icsUtcFormat   = "20060102T150405Z"
+	icsLocalFormat = "20060102T150405"
+	icsLineLength  = 75
+	// Time zone definitions cover this many years past the latest session, recurrences
+	// further out keep the last offset.
+	icsTimeZoneYears = 10
+)
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// writeCalendar renders sessions as an iCalendar (RFC 5545) document. Sessions with a loaded
+// training get its name in their summary, recurring sessions are left for the calendar app to expand.
+func writeCalendar(name string, sessions []models.Session) []byte {
+	var buf bytes.Buffer
+
+	line := func(format string, args ...any) {
+		writeIcsLine(&buf, fmt.Sprintf(format, args...))
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//Trainings API//Sessions//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:%s", icsEscaper.Replace(name))
+
+	writeTimeZones(line, sessions)
+
+	for _, session := range sessions {
+		summary := session.Title
+		if session.Training.Name != "" {
+			summary = session.Training.Name + " - " + session.Title
+		}
+
+		line("BEGIN:VEVENT")
+		line("UID:%s@trainings", session.ID)
+		line("DTSTAMP:%s", session.UpdatedAt.UTC().Format(icsUtcFormat))
+		line("DTSTART%s", icsTime(session, session.StartsAt))
+		line("DTEND%s", icsTime(session, session.EndsAt))
+		if session.RRule != "" {
+			line("RRULE:%s", session.RRule)
+		}
+		line("SUMMARY:%s", icsEscaper.Replace(summary))
+		if session.Description != "" {
+			line("DESCRIPTION:%s", icsEscaper.Replace(session.Description))
+		}
+		if session.Location != "" {
+			line("LOCATION:%s", icsEscaper.Replace(session.Location))
+		} else if session.MeetingURL != "" {
+			line("LOCATION:%s", icsEscaper.Replace(session.MeetingURL))
+		}
+		if session.MeetingURL != "" {
+			line("URL:%s", session.MeetingURL)
+		}
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+
+	return buf.Bytes()
+}
+
+// icsTime formats a date property value, in the session time zone so recurrences follow its DST rules.
+func icsTime(session models.Session, t time.Time) string {
+	loc := session.TimeLocation()
+	if loc == time.UTC {
+		return ":" + t.UTC().Format(icsUtcFormat)
+	}
+
+	return fmt.Sprintf(";TZID=%s:%s", loc.String(), t.In(loc).Format(icsLocalFormat))
+}
+
+// writeTimeZones defines every time zone the sessions refer to with a VTIMEZONE component,
+// listing its offset changes from the year of the earliest session on.
+func writeTimeZones(line func(format string, args ...any), sessions []models.Session) {
+	var zones []*time.Location
+	seen := map[string]bool{}
+
+	from, to := time.Now(), time.Now()
+	for _, session := range sessions {
+		loc := session.TimeLocation()
+		if loc == time.UTC {
+			continue
+		}
+
+		if !seen[loc.String()] {
+			seen[loc.String()] = true
+			zones = append(zones, loc)
+		}
+
+		if session.StartsAt.Before(from) {
+			from = session.StartsAt
+		}
+
+		if session.StartsAt.After(to) {
+			to = session.StartsAt
+		}
+	}
+
+	from = time.Date(from.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year()+icsTimeZoneYears, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, loc := range zones {
+		line("BEGIN:VTIMEZONE")
+		line("TZID:%s", loc.String())
+
+		start := from.In(loc)
+		_, offset := start.Zone()
+		writeObservance(line, start, offset)
+
+		for _, change := range zoneChanges(loc, from, to) {
+			writeObservance(line, change, offset)
+			_, offset = change.Zone()
+		}
+
+		line("END:VTIMEZONE")
+	}
+}
+
+// writeObservance writes the offset that is in effect from t on, its onset is in the local time of the previous offset.
+func writeObservance(line func(format string, args ...any), t time.Time, previous int) {
+	name, offset := t.Zone()
+
+	kind := "STANDARD"
+	if t.IsDST() {
+		kind = "DAYLIGHT"
+	}
+
+	line("BEGIN:%s", kind)
+	line("DTSTART:%s", t.UTC().Add(time.Duration(previous)*time.Second).Format(icsLocalFormat))
+	line("TZOFFSETFROM:%s", icsOffset(previous))
+	line("TZOFFSETTO:%s", icsOffset(offset))
+	line("TZNAME:%s", icsEscaper.Replace(name))
+	line("END:%s", kind)
+}
+
+// zoneChanges finds the instants the offset of loc changes between from and to. Changes are
+// looked for day by day and then narrowed down to the second.
+func zoneChanges(loc *time.Location, from, to time.Time) []time.Time {
+	var changes []time.Time
+
+	_, offset := from.In(loc).Zone()
+	for day := from; day.Before(to); day = day.Add(24 * time.Hour) {
+		next := day.Add(24 * time.Hour)
+
+		_, nextOffset := next.In(loc).Zone()
+		if nextOffset == offset {
+			continue
+		}
+
+		low, high := day, next
+		for high.Sub(low) > time.Second {
+			mid := low.Add(high.Sub(low) / 2)
+			if _, o := mid.In(loc).Zone(); o == offset {
+				low = mid
+			} else {
+				high = mid
+			}
+		}
+
+		changes = append(changes, high.In(loc))
+		offset = nextOffset
+	}
+
+	return changes
+}
+
+func icsOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+
+	return fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+}
+
+// writeIcsLine writes a content line folded at 75 octets without splitting UTF-8 characters.
+func writeIcsLine(buf *bytes.Buffer, line string) {
+	limit := icsLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space that counts towards their length.
+		limit = icsLineLength - 1
+	}
+
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
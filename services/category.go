@@ -0,0 +1,192 @@
+package services
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+type ICategoryService interface {
+	FindAll() []models.Category
+	FindBySlug(slug string) (models.Category, error)
+	Create(dto dto.CreateCategory, userID string) (models.Category, error)
+	Update(id, userID string, dto dto.UpdateCategory) (models.Category, error)
+	Delete(id, userID string) error
+}
+
+type CategoryService struct {
+	categoryRepository repositories.ICategoryRepository
+	userRepository     repositories.IUserRepository
+}
+
+var (
+	categoryOnce    sync.Once
+	categoryService ICategoryService
+)
+
+func GetCategoryService() ICategoryService {
+	categoryOnce.Do(func() {
+		log.Info().Msg("Initializing category service")
+		categoryService = &CategoryService{
+			categoryRepository: repositories.GetCategoryRepository(),
+			userRepository:     repositories.GetUserRepository(),
+		}
+	})
+	return categoryService
+}
+
+func (s *CategoryService) FindAll() []models.Category {
+	log.Debug().Msg("Finding all categories")
+
+	return s.categoryRepository.FindAll()
+}
+
+func (s *CategoryService) FindBySlug(slug string) (models.Category, error) {
+	log.Debug().Str("slug", slug).Msg("Finding category")
+
+	return s.categoryRepository.FindBySlug(slug)
+}
+
+func (s *CategoryService) Create(dto dto.CreateCategory, userID string) (models.Category, error) {
+	log.Debug().Str(logger.UserID, userID).Msg("Creating category")
+
+	var category models.Category
+
+	err := s.verifyAdmin(userID)
+	if err != nil {
+		return category, err
+	}
+
+	category = models.Category{
+		Name:     dto.Name,
+		Slug:     slugOrName(dto.Slug, dto.Name),
+		Icon:     dto.Icon,
+		ParentID: dto.ParentID,
+	}
+
+	err = s.verifyParent(category)
+	if err != nil {
+		return category, err
+	}
+
+	_, err = s.categoryRepository.FindBySlug(category.Slug)
+	if err == nil {
+		return category, errors.New("category already exists")
+	}
+
+	err = s.categoryRepository.Create(&category)
+	if err != nil {
+		return category, err
+	}
+
+	return category, nil
+}
+
+func (s *CategoryService) Update(id, userID string, dto dto.UpdateCategory) (models.Category, error) {
+	log.Debug().Str(logger.CategoryID, id).Str(logger.UserID, userID).Msg("Updating category")
+
+	err := s.verifyAdmin(userID)
+	if err != nil {
+		return models.Category{}, err
+	}
+
+	category, err := s.categoryRepository.FindByID(id)
+	if err != nil {
+		return category, err
+	}
+
+	slug := slugOrName(dto.Slug, dto.Name)
+	if slug != category.Slug {
+		_, err = s.categoryRepository.FindBySlug(slug)
+		if err == nil {
+			return category, errors.New("category already exists")
+		}
+	}
+
+	category.Name = dto.Name
+	category.Slug = slug
+	category.Icon = dto.Icon
+	category.ParentID = dto.ParentID
+
+	err = s.verifyParent(category)
+	if err != nil {
+		return category, err
+	}
+
+	err = s.categoryRepository.Update(&category)
+	if err != nil {
+		return category, err
+	}
+
+	return category, nil
+}
+
+func (s *CategoryService) Delete(id, userID string) error {
+	log.Debug().Str(logger.CategoryID, id).Str(logger.UserID, userID).Msg("Deleting category")
+
+	err := s.verifyAdmin(userID)
+	if err != nil {
+		return err
+	}
+
+	category, err := s.categoryRepository.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	children, err := s.categoryRepository.CountChildren(id)
+	if err != nil {
+		return err
+	}
+
+	if children > 0 {
+		return errors.New("category has subcategories")
+	}
+
+	return s.categoryRepository.Delete(&category)
+}
+
+func (s *CategoryService) verifyAdmin(userID string) error {
+	user, err := s.userRepository.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.HasRole(models.AdminRole) {
+		return errors.New("user is not admin")
+	}
+
+	return nil
+}
+
+// verifyParent makes sure the parent exists and that it isn't the category itself or one of its descendants.
+func (s *CategoryService) verifyParent(category models.Category) error {
+	parentID := category.ParentID
+
+	for parentID != nil {
+		if *parentID == category.ID {
+			return errors.New("category can't be nested in itself")
+		}
+
+		parent, err := s.categoryRepository.FindByID(*parentID)
+		if err != nil {
+			return errors.New("parent category not found")
+		}
+
+		parentID = parent.ParentID
+	}
+
+	return nil
+}
+
+func slugOrName(slug, name string) string {
+	if slug != "" {
+		return models.Slugify(slug)
+	}
+	return models.Slugify(name)
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+type ICertificateService interface {
+	FindBySerial(serial string) (models.Certificate, error)
+	FindByUserID(userID string) []models.Certificate
+	FindByTrainingID(trainingID, userID string) ([]models.Certificate, error)
+	Issue(trainingID, memberID, userID string) (models.Certificate, error)
+	IssueIfCompleted(trainingID, userID string)
+}
+
+type CertificateService struct {
+	certificateRepository repositories.ICertificateRepository
+	trainingRepository    repositories.ITrainingRepository
+	userRepository        repositories.IUserRepository
+	lessonRepository      repositories.ILessonRepository
+	moduleRepository      repositories.IModuleRepository
+	blobService           IBlobService
+	mailService           IMailService
+	appUrl                string
+}
+
+var (
+	certificateOnce    sync.Once
+	certificateService ICertificateService
+)
+
+func GetCertificateService() ICertificateService {
+	certificateOnce.Do(func() {
+		log.Info().Msg("Initializing certificate service")
+		certificateService = &CertificateService{
+			certificateRepository: repositories.GetCertificateRepository(),
+			trainingRepository:    repositories.GetTrainingRepository(),
+			userRepository:        repositories.GetUserRepository(),
+			lessonRepository:      repositories.GetLessonRepository(),
+			moduleRepository:      repositories.GetModuleRepository(),
+			blobService:           GetBlobService(),
+			mailService:           GetMailService(),
+			appUrl:                strings.TrimSuffix(os.Getenv("APP_URL"), "/"),
+		}
+	})
+	return certificateService
+}
+
+func (s *CertificateService) FindBySerial(serial string) (models.Certificate, error) {
+	log.Debug().Str("serial", serial).Msg("Verifying certificate")
+
+	return s.certificateRepository.FindBySerial(strings.ToUpper(strings.TrimSpace(serial)))
+}
+
+func (s *CertificateService) FindByUserID(userID string) []models.Certificate {
+	log.Debug().Str(logger.UserID, userID).Msg("Finding user certificates")
+
+	return s.certificateRepository.FindByUserID(userID)
+}
+
+func (s *CertificateService) FindByTrainingID(trainingID, userID string) ([]models.Certificate, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Msg("Finding training certificates")
+
+	member, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err != nil || !member.CanModerate() {
+		return nil, errors.New("you are not allowed to see certificates of this training")
+	}
+
+	return s.certificateRepository.FindByTrainingID(trainingID), nil
+}
+
+// Issue marks the training as completed by a learner on behalf of the owner and issues their certificate.
+func (s *CertificateService) Issue(trainingID, memberID, userID string) (models.Certificate, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, memberID).Msg("Issuing certificate")
+
+	manager, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err != nil || !manager.CanManage() {
+		return models.Certificate{}, errors.New("you are not allowed to manage this training")
+	}
+
+	member, err := s.trainingRepository.FindMember(trainingID, memberID)
+	if err != nil {
+		return models.Certificate{}, errors.New("user is not in training")
+	}
+
+	if member.CanPost() {
+		return models.Certificate{}, errors.New("certificates are only issued to learners")
+	}
+
+	return s.issue(trainingID, memberID, &userID)
+}
+
+// IssueIfCompleted issues the certificate of a learner once every published lesson of the training is completed.
+func (s *CertificateService) IssueIfCompleted(trainingID, userID string) {
+	member, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err != nil || member.CanPost() {
+		return
+	}
+
+	lessons := publishedLessons(s.moduleRepository.FindByTrainingID(trainingID))
+	if len(lessons) == 0 {
+		return
+	}
+
+	completed := map[string]bool{}
+	for _, progress := range s.lessonRepository.FindProgress(trainingID, userID) {
+		completed[progress.LessonID] = progress.CompletedAt != nil
+	}
+
+	for _, lesson := range lessons {
+		if !completed[lesson.ID] {
+			return
+		}
+	}
+
+	_, err = s.issue(trainingID, userID, nil)
+	if err != nil {
+		log.Err(err).Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Failed to issue certificate")
+	}
+}
+
+// issue generates, stores and emails the certificate, a user only ever gets one per training.
+func (s *CertificateService) issue(trainingID, userID string, issuedByID *string) (models.Certificate, error) {
+	certificate, err := s.certificateRepository.FindOne(trainingID, userID)
+	if err == nil {
+		return certificate, nil
+	}
+
+	training, err := s.trainingRepository.FindByID(trainingID)
+	if err != nil {
+		return certificate, err
+	}
+
+	user, err := s.userRepository.FindByID(userID)
+	if err != nil {
+		return certificate, err
+	}
+
+	serial, err := certificateSerial()
+	if err != nil {
+		return certificate, err
+	}
+
+	certificate = models.Certificate{
+		Serial:       serial,
+		TrainingID:   training.ID,
+		UserID:       user.ID,
+		FullName:     fullName(user),
+		TrainingName: training.Name,
+		IssuedAt:     time.Now().UTC(),
+		IssuedByID:   issuedByID,
+	}
+
+	verifyUrl := ""
+	if s.appUrl != "" {
+		verifyUrl = s.appUrl + "/certificates/" + serial
+	}
+
+	data, err := writeCertificatePdf(certificate.FullName, certificate.TrainingName,
+		certificate.IssuedAt.Format("January 2, 2006"), serial, verifyUrl)
+	if err != nil {
+		return certificate, err
+	}
+
+	fileName := "certificate-" + serial + ".pdf"
+
+	url, err := s.blobService.Upload(fileName, data)
+	if err != nil {
+		return certificate, err
+	}
+
+	certificate.Url = strings.Replace(url, "azurite", "localhost", 1)
+
+	err = s.certificateRepository.Create(&certificate)
+	if err != nil {
+		// Another request issued the certificate in the meantime.
+		if existing, findErr := s.certificateRepository.FindOne(trainingID, userID); findErr == nil {
+			go s.blobService.Delete(fileName)
+			return existing, nil
+		}
+		return certificate, err
+	}
+
+	body := fmt.Sprintf("Congratulations on completing <strong>%s</strong>!<br>Your certificate is attached, its serial number is %s.", html.EscapeString(training.Name), serial)
+	if verifyUrl != "" {
+		body += "<br>Anyone can verify it at " + verifyUrl
+	}
+
+	go s.mailService.Send(Mail{
+		To:      []string{user.Email},
+		Subject: "Trainings - Certificate of completion",
+		Body:    body,
+		Attachments: []Attachment{{
+			Name:        fileName,
+			ContentType: "application/pdf",
+			Data:        data,
+		}},
+	})
+
+	return certificate, nil
+}
+
+// certificateSerial returns a random serial like 7KQ2-M4XD-9PZA that is easy to read out and type.
+func certificateSerial() (string, error) {
+	bytes := make([]byte, 10)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", err
+	}
+
+	code := base32.StdEncoding.EncodeToString(bytes)[:12]
+
+	return code[:4] + "-" + code[4:8] + "-" + code[8:], nil
+}
@@ -12,7 +12,7 @@ import (
 )
 
 type ICommentService interface {
-	FindByPostID(postID, userID string) ([]models.Comment, error)
+	FindByPostID(postID, userID string, params dto.PaginationQuery) (dto.Page[models.Comment], error)
 	Create(postID, userID string, dto dto.CreateComment) (models.Comment, error)
 	Update(commentID, userID string, dto dto.UpdateComment) (models.Comment, error)
 	Delete(commentID, userID string) (models.Comment, error)
@@ -22,7 +22,7 @@ type CommentService struct {
 	commentRepository  repositories.ICommentRepository
 	postRepository     repositories.IPostRepository
 	trainingRepository repositories.ITrainingRepository
-	userRepository     repositories.IUserRepository
+	pointsService      IPointsService
 }
 
 var (
@@ -37,35 +37,28 @@ func GetCommentService() ICommentService {
 			commentRepository:  repositories.GetCommentRepository(),
 			postRepository:     repositories.GetPostRepository(),
 			trainingRepository: repositories.GetTrainingRepository(),
-			userRepository:     repositories.GetUserRepository(),
+			pointsService:      GetPointsService(),
 		}
 	})
 	return commentService
 }
 
-func (s *CommentService) FindByPostID(postID, userID string) ([]models.Comment, error) {
+func (s *CommentService) FindByPostID(postID, userID string, params dto.PaginationQuery) (dto.Page[models.Comment], error) {
 	log.Debug().Str(logger.PostID, postID).Str(logger.UserID, userID).Msg("Finding comments")
 
-	var comments []models.Comment
+	var comments dto.Page[models.Comment]
 
-	_, err := s.postRepository.FindByID(postID)
+	post, err := s.postRepository.FindByID(postID)
 	if err != nil {
 		return comments, err
 	}
 
-	_, err = s.userRepository.FindByID(userID)
+	err = s.trainingRepository.VerifyUserInTraining(post.TrainingID, userID)
 	if err != nil {
 		return comments, err
 	}
 
-	// err = s.verifyIfCanRead(post.Training, user)
-	// if err != nil {
-	// 	return comments, err
-	// }
-
-	comments = s.commentRepository.FindByPostID(postID)
-
-	return comments, nil
+	return s.commentRepository.FindByPostID(postID, params)
 }
 
 func (s *CommentService) Create(postID, userID string, dto dto.CreateComment) (models.Comment, error) {
@@ -73,21 +66,17 @@ func (s *CommentService) Create(postID, userID string, dto dto.CreateComment) (m
 
 	var comment models.Comment
 
-	_, err := s.postRepository.FindByID(postID)
+	post, err := s.postRepository.FindByID(postID)
 	if err != nil {
 		return comment, err
 	}
 
-	_, err = s.userRepository.FindByID(userID)
+	// Every member, students included, can comment.
+	err = s.trainingRepository.VerifyUserInTraining(post.TrainingID, userID)
 	if err != nil {
 		return comment, err
 	}
 
-	// err = s.verifyIfCanWrite(post.Training, user)
-	// if err != nil {
-	// 	return comment, err
-	// }
-
 	comment.Text = dto.Text
 	comment.PostID = postID
 	comment.UserID = userID
@@ -97,6 +86,8 @@ func (s *CommentService) Create(postID, userID string, dto dto.CreateComment) (m
 		return comment, err
 	}
 
+	s.pointsService.Award(userID, models.CommentPoints, &post.TrainingID, comment.ID)
+
 	return comment, nil
 }
 
@@ -131,7 +122,15 @@ func (s *CommentService) Delete(commentID, userID string) (models.Comment, error
 	}
 
 	if comment.UserID != userID {
-		return comment, errors.New("you are not allowed to delete this comment")
+		post, err := s.postRepository.FindByID(comment.PostID)
+		if err != nil {
+			return comment, err
+		}
+
+		member, err := s.trainingRepository.FindMember(post.TrainingID, userID)
+		if err != nil || !member.CanModerate() {
+			return comment, errors.New("you are not allowed to delete this comment")
+		}
 	}
 
 	comment.Text = ""
@@ -143,19 +142,3 @@ func (s *CommentService) Delete(commentID, userID string) (models.Comment, error
 
 	return comment, nil
 }
-
-// func (s *CommentService) verifyIfCanWrite(training models.Training, user models.User) error {
-// 	log.Debug().Str(logger.TrainingID, training.ID).Str(logger.UserID, user.ID).Msg("Verifying if user is authorized in training")
-
-// 	if training.OwnerID == user.ID {
-// 		return nil
-// 	}
-
-// 	return errors.New("you are not allowed to write in this training")
-// }
-
-// func (s *CommentService) verifyIfCanRead(training models.Training, user models.User) error {
-// 	log.Debug().Str(logger.TrainingID, training.ID).Str(logger.UserID, user.ID).Msg("Verifying if user is authorized in training")
-
-// 	return s.trainingRepository.VerifyUserInTraining(training.ID, user.ID)
-// }
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+type ICouponService interface {
+	FindAll(userID string) []models.Coupon
+	FindOne(id, userID string) (models.Coupon, error)
+	Create(userID string, dto dto.CreateCoupon) (models.Coupon, error)
+	Update(id, userID string, dto dto.UpdateCoupon) (models.Coupon, error)
+	Delete(id, userID string) error
+}
+
+type CouponService struct {
+	couponRepository   repositories.ICouponRepository
+	trainingRepository repositories.ITrainingRepository
+	categoryRepository repositories.ICategoryRepository
+	userRepository     repositories.IUserRepository
+}
+
+var (
+	couponOnce    sync.Once
+	couponService ICouponService
+)
+
+func GetCouponService() ICouponService {
+	couponOnce.Do(func() {
+		log.Info().Msg("Initializing coupon service")
+		couponService = &CouponService{
+			couponRepository:   repositories.GetCouponRepository(),
+			trainingRepository: repositories.GetTrainingRepository(),
+			categoryRepository: repositories.GetCategoryRepository(),
+			userRepository:     repositories.GetUserRepository(),
+		}
+	})
+	return couponService
+}
+
+// FindAll finds every coupon for admins and the coupons of the trainings they manage for everyone else.
+func (s *CouponService) FindAll(userID string) []models.Coupon {
+	log.Debug().Str(logger.UserID, userID).Msg("Finding coupons")
+
+	if s.isAdmin(userID) {
+		return s.couponRepository.FindAll()
+	}
+
+	return s.couponRepository.FindManagedBy(userID)
+}
+
+func (s *CouponService) FindOne(id, userID string) (models.Coupon, error) {
+	log.Debug().Str(logger.CouponID, id).Str(logger.UserID, userID).Msg("Finding coupon")
+
+	coupon, err := s.couponRepository.FindByID(id)
+	if err != nil {
+		return coupon, err
+	}
+
+	err = s.verifyCanManage(coupon, userID)
+	if err != nil {
+		return models.Coupon{}, err
+	}
+
+	return coupon, nil
+}
+
+func (s *CouponService) Create(userID string, dto dto.CreateCoupon) (models.Coupon, error) {
+	log.Debug().Str(logger.UserID, userID).Str("code", dto.Code).Msg("Creating coupon")
+
+	coupon := models.Coupon{CreatedByID: userID}
+
+	err := s.fillCoupon(&coupon, dto, userID)
+	if err != nil {
+		return coupon, err
+	}
+
+	err = s.couponRepository.Create(&coupon)
+	if err != nil {
+		return coupon, errors.New("coupon code already exists")
+	}
+
+	return coupon, nil
+}
+
+func (s *CouponService) Update(id, userID string, dto dto.UpdateCoupon) (models.Coupon, error) {
+	log.Debug().Str(logger.CouponID, id).Str(logger.UserID, userID).Msg("Updating coupon")
+
+	coupon, err := s.couponRepository.FindByID(id)
+	if err != nil {
+		return coupon, err
+	}
+
+	err = s.verifyCanManage(coupon, userID)
+	if err != nil {
+		return coupon, err
+	}
+
+	err = s.fillCoupon(&coupon, createCoupon(dto), userID)
+	if err != nil {
+		return coupon, err
+	}
+
+	err = s.couponRepository.Update(&coupon)
+	if err != nil {
+		return coupon, errors.New("coupon code already exists")
+	}
+
+	return coupon, nil
+}
+
+func (s *CouponService) Delete(id, userID string) error {
+	log.Debug().Str(logger.CouponID, id).Str(logger.UserID, userID).Msg("Deleting coupon")
+
+	coupon, err := s.couponRepository.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.verifyCanManage(coupon, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.couponRepository.Delete(&coupon)
+}
+
+// fillCoupon validates the coupon and checks that the user may give discounts on its scope.
+func (s *CouponService) fillCoupon(coupon *models.Coupon, dto dto.CreateCoupon, userID string) error {
+	if dto.Type == models.PercentCoupon && dto.Value > 100 {
+		return errors.New("percent coupons can't take off more than 100 percent")
+	}
+
+	if dto.TrainingID != nil && dto.CategoryID != nil {
+		return errors.New("coupon can't be scoped to a training and a category")
+	}
+
+	if dto.StartsAt != nil && dto.EndsAt != nil && !dto.EndsAt.After(*dto.StartsAt) {
+		return errors.New("coupon must end after it starts")
+	}
+
+	coupon.Code = strings.ToUpper(dto.Code)
+	coupon.Type = dto.Type
+	coupon.Value = dto.Value
+	coupon.TrainingID = dto.TrainingID
+	coupon.CategoryID = dto.CategoryID
+	coupon.StartsAt = dto.StartsAt
+	coupon.EndsAt = dto.EndsAt
+	coupon.MaxUses = dto.MaxUses
+	coupon.MaxUsesPerUser = dto.MaxUsesPerUser
+
+	if coupon.TrainingID != nil {
+		_, err := s.trainingRepository.FindByID(*coupon.TrainingID)
+		if err != nil {
+			return errors.New("training not found")
+		}
+	}
+
+	if coupon.CategoryID != nil {
+		_, err := s.categoryRepository.FindByID(*coupon.CategoryID)
+		if err != nil {
+			return errors.New("category not found")
+		}
+	}
+
+	return s.verifyCanManage(*coupon, userID)
+}
+
+// verifyCanManage lets owners and co-owners manage the coupons of their trainings,
+// coupons for a category or for every training are managed by admins only.
+func (s *CouponService) verifyCanManage(coupon models.Coupon, userID string) error {
+	if s.isAdmin(userID) {
+		return nil
+	}
+
+	if coupon.TrainingID == nil {
+		return errors.New("user is not admin")
+	}
+
+	member, err := s.trainingRepository.FindMember(*coupon.TrainingID, userID)
+	if err != nil || !member.CanManage() {
+		return errors.New("you are not allowed to manage this training")
+	}
+
+	return nil
+}
+
+func (s *CouponService) isAdmin(userID string) bool {
+	user, err := s.userRepository.FindByID(userID)
+
+	return err == nil && user.HasRole(models.AdminRole)
+}
+
+func createCoupon(update dto.UpdateCoupon) dto.CreateCoupon {
+	return dto.CreateCoupon(update)
+}
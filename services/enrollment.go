@@ -0,0 +1,316 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"sync"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+type IEnrollmentService interface {
+	Apply(trainingID, userID string, dto dto.CreateEnrollment) (models.Enrollment, error)
+	FindByTrainingID(trainingID, userID string, params dto.EnrollmentQueryParams) ([]models.Enrollment, error)
+	FindByUserID(userID string) []models.Enrollment
+	FindOne(id, userID string) (models.Enrollment, error)
+	Approve(id, userID string) (models.Enrollment, error)
+	Reject(id, userID string, dto dto.RejectEnrollment) (models.Enrollment, error)
+	Withdraw(id, userID string) (models.Enrollment, error)
+}
+
+type EnrollmentService struct {
+	enrollmentRepository repositories.IEnrollmentRepository
+	trainingRepository   repositories.ITrainingRepository
+	userRepository       repositories.IUserRepository
+	mailService          IMailService
+}
+
+var (
+	enrollmentOnce    sync.Once
+	enrollmentService IEnrollmentService
+)
+
+func GetEnrollmentService() IEnrollmentService {
+	enrollmentOnce.Do(func() {
+		log.Info().Msg("Initializing enrollment service")
+		enrollmentService = &EnrollmentService{
+			enrollmentRepository: repositories.GetEnrollmentRepository(),
+			trainingRepository:   repositories.GetTrainingRepository(),
+			userRepository:       repositories.GetUserRepository(),
+			mailService:          GetMailService(),
+		}
+	})
+	return enrollmentService
+}
+
+func (s *EnrollmentService) Apply(trainingID, userID string, dto dto.CreateEnrollment) (models.Enrollment, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Applying to training")
+
+	var enrollment models.Enrollment
+
+	training, err := s.trainingRepository.FindByID(trainingID)
+	if err != nil {
+		return enrollment, err
+	}
+
+	if training.Visibility == models.DraftVisibility || training.Visibility == models.PrivateVisibility {
+		return enrollment, errors.New("training is not open for enrollment")
+	}
+
+	if training.Price > 0 {
+		return enrollment, errors.New("training is paid, checkout to enroll")
+	}
+
+	user, err := s.userRepository.FindByID(userID)
+	if err != nil {
+		return enrollment, err
+	}
+
+	err = s.trainingRepository.VerifyUserInTraining(trainingID, userID)
+	if err == nil {
+		return enrollment, errors.New("user already in this training")
+	}
+
+	_, err = s.enrollmentRepository.FindPending(trainingID, userID)
+	if err == nil {
+		return enrollment, errors.New("enrollment request already pending")
+	}
+
+	enrollment = models.Enrollment{
+		TrainingID: trainingID,
+		UserID:     userID,
+		Status:     models.PendingEnrollment,
+		Message:    dto.Message,
+	}
+
+	err = s.enrollmentRepository.Create(&enrollment)
+	if err != nil {
+		return enrollment, err
+	}
+
+	err = s.addEvent(&enrollment, userID, "", models.PendingEnrollment, "")
+	if err != nil {
+		return enrollment, err
+	}
+
+	if training.AutoApprove && training.Visibility == models.PublicVisibility {
+		return enrollment, s.approve(&enrollment, training, user, training.OwnerID)
+	}
+
+	owner, err := s.userRepository.FindByID(training.OwnerID)
+	if err == nil {
+		go s.mailService.Send(Mail{
+			To:      []string{owner.Email},
+			Subject: "Trainings - New Enrollment Request",
+			Body: fmt.Sprintf("<strong>%s %s</strong> asked to join <strong>%s</strong>.<br>%s",
+				html.EscapeString(user.FirstName), html.EscapeString(user.LastName), html.EscapeString(training.Name),
+				html.EscapeString(enrollment.Message)),
+		})
+	}
+
+	return enrollment, nil
+}
+
+func (s *EnrollmentService) FindByTrainingID(trainingID, userID string, params dto.EnrollmentQueryParams) ([]models.Enrollment, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Finding enrollments")
+
+	var enrollments []models.Enrollment
+
+	err := s.verifyCanManage(trainingID, userID)
+	if err != nil {
+		return enrollments, err
+	}
+
+	return s.enrollmentRepository.FindByTrainingID(trainingID, params.Status), nil
+}
+
+func (s *EnrollmentService) FindByUserID(userID string) []models.Enrollment {
+	log.Debug().Str(logger.UserID, userID).Msg("Finding user enrollments")
+
+	return s.enrollmentRepository.FindByUserID(userID)
+}
+
+func (s *EnrollmentService) FindOne(id, userID string) (models.Enrollment, error) {
+	log.Debug().Str(logger.EnrollmentID, id).Str(logger.UserID, userID).Msg("Finding enrollment")
+
+	enrollment, err := s.enrollmentRepository.FindByID(id)
+	if err != nil {
+		return enrollment, err
+	}
+
+	if enrollment.UserID != userID && s.verifyCanManage(enrollment.TrainingID, userID) != nil {
+		return models.Enrollment{}, errors.New("you are not allowed to see this enrollment")
+	}
+
+	return enrollment, nil
+}
+
+func (s *EnrollmentService) Approve(id, userID string) (models.Enrollment, error) {
+	log.Debug().Str(logger.EnrollmentID, id).Str(logger.UserID, userID).Msg("Approving enrollment")
+
+	enrollment, err := s.findPendingForManager(id, userID)
+	if err != nil {
+		return enrollment, err
+	}
+
+	err = s.approve(&enrollment, enrollment.Training, enrollment.User, userID)
+	if err != nil {
+		return enrollment, err
+	}
+
+	return enrollment, nil
+}
+
+func (s *EnrollmentService) Reject(id, userID string, dto dto.RejectEnrollment) (models.Enrollment, error) {
+	log.Debug().Str(logger.EnrollmentID, id).Str(logger.UserID, userID).Msg("Rejecting enrollment")
+
+	enrollment, err := s.findPendingForManager(id, userID)
+	if err != nil {
+		return enrollment, err
+	}
+
+	err = s.changeStatus(&enrollment, userID, models.RejectedEnrollment, dto.Reason)
+	if err != nil {
+		return enrollment, err
+	}
+
+	go s.mailService.Send(Mail{
+		To:      []string{enrollment.User.Email},
+		Subject: "Trainings - Enrollment Rejected",
+		Body: fmt.Sprintf("Your request to join <strong>%s</strong> was rejected.<br>Reason: %s",
+			html.EscapeString(enrollment.Training.Name), html.EscapeString(dto.Reason)),
+	})
+
+	return enrollment, nil
+}
+
+func (s *EnrollmentService) Withdraw(id, userID string) (models.Enrollment, error) {
+	log.Debug().Str(logger.EnrollmentID, id).Str(logger.UserID, userID).Msg("Withdrawing enrollment")
+
+	enrollment, err := s.enrollmentRepository.FindByID(id)
+	if err != nil {
+		return enrollment, err
+	}
+
+	if enrollment.UserID != userID {
+		return enrollment, errors.New("you are not allowed to withdraw this enrollment")
+	}
+
+	if enrollment.Status != models.PendingEnrollment {
+		return enrollment, errors.New("enrollment is not pending")
+	}
+
+	err = s.changeStatus(&enrollment, userID, models.WithdrawnEnrollment, "")
+	if err != nil {
+		return enrollment, err
+	}
+
+	owner, err := s.userRepository.FindByID(enrollment.Training.OwnerID)
+	if err == nil {
+		go s.mailService.Send(Mail{
+			To:      []string{owner.Email},
+			Subject: "Trainings - Enrollment Withdrawn",
+			Body: fmt.Sprintf("<strong>%s %s</strong> withdrew their request to join <strong>%s</strong>.",
+				html.EscapeString(enrollment.User.FirstName), html.EscapeString(enrollment.User.LastName),
+				html.EscapeString(enrollment.Training.Name)),
+		})
+	}
+
+	return enrollment, nil
+}
+
+func (s *EnrollmentService) findPendingForManager(id, userID string) (models.Enrollment, error) {
+	enrollment, err := s.enrollmentRepository.FindByID(id)
+	if err != nil {
+		return enrollment, err
+	}
+
+	err = s.verifyCanManage(enrollment.TrainingID, userID)
+	if err != nil {
+		return enrollment, err
+	}
+
+	if enrollment.Status != models.PendingEnrollment {
+		return enrollment, errors.New("enrollment is not pending")
+	}
+
+	return enrollment, nil
+}
+
+func (s *EnrollmentService) verifyCanManage(trainingID, userID string) error {
+	member, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err != nil || !member.CanManage() {
+		return errors.New("you are not allowed to manage this training")
+	}
+
+	return nil
+}
+
+func (s *EnrollmentService) approve(enrollment *models.Enrollment, training models.Training, user models.User, actorID string) error {
+	waitlisted := false
+
+	err := s.trainingRepository.VerifyUserInTraining(training.ID, user.ID)
+	if err != nil {
+		waitlisted, err = s.trainingRepository.Enroll(&training, &user, models.StudentMember)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.changeStatus(enrollment, actorID, models.ApprovedEnrollment, "")
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("You are now enrolled in <strong>%s</strong>.", html.EscapeString(training.Name))
+	if waitlisted {
+		body = fmt.Sprintf("Your request to join <strong>%s</strong> was approved, but the training is full. "+
+			"You are on the waitlist and will be enrolled as soon as a spot opens up.", html.EscapeString(training.Name))
+	}
+
+	go s.mailService.Send(Mail{
+		To:      []string{user.Email},
+		Subject: "Trainings - Enrollment Approved",
+		Body:    body,
+	})
+
+	return nil
+}
+
+func (s *EnrollmentService) changeStatus(enrollment *models.Enrollment, actorID, status, reason string) error {
+	from := enrollment.Status
+
+	enrollment.Status = status
+	enrollment.Reason = reason
+
+	err := s.enrollmentRepository.Update(enrollment)
+	if err != nil {
+		return err
+	}
+
+	return s.addEvent(enrollment, actorID, from, status, reason)
+}
+
+func (s *EnrollmentService) addEvent(enrollment *models.Enrollment, actorID, from, to, reason string) error {
+	event := models.EnrollmentEvent{
+		EnrollmentID: enrollment.ID,
+		ActorID:      actorID,
+		FromStatus:   from,
+		ToStatus:     to,
+		Reason:       reason,
+	}
+
+	err := s.enrollmentRepository.AddEvent(&event)
+	if err != nil {
+		return err
+	}
+
+	enrollment.Events = append(enrollment.Events, event)
+
+	return nil
+}
@@ -7,12 +7,15 @@ import (
 
 	"github.com/Marcel-MD/xmas-faf-api/models"
 	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/google/uuid"
 )
 
 type IFileService interface {
 	FindByPostID(postID string) []models.File
 	FindByID(id string) (models.File, error)
 	Create(postID, fileName string, data []byte) (models.File, error)
+	Upload(fileName string, data []byte) (models.File, error)
+	UploadTo(folder, fileName string, data []byte) (models.File, error)
 	Delete(id string) error
 }
 
@@ -55,6 +58,24 @@ func (s *FileService) Create(postID, fileName string, data []byte) (models.File,
 		return models.File{}, err
 	}
 
+	file, err := s.Upload(fileName, data)
+	if err != nil {
+		return file, err
+	}
+
+	file.PostID = &postID
+
+	err = s.fileRepository.Create(&file)
+	if err != nil {
+		return file, err
+	}
+
+	return file, nil
+}
+
+// Upload stores the data in blob storage and returns the file without saving it,
+// the caller attaches it to its post, lesson or submission.
+func (s *FileService) Upload(fileName string, data []byte) (models.File, error) {
 	url, err := s.blobService.Upload(fileName, data)
 	if err != nil {
 		return models.File{}, err
@@ -62,18 +83,26 @@ func (s *FileService) Create(postID, fileName string, data []byte) (models.File,
 
 	url = strings.Replace(url, "azurite", "localhost", 1)
 
-	file := models.File{
-		PostID: postID,
-		Name:   fileName,
-		Ext:    path.Ext(fileName),
-		Url:    url,
-	}
+	return models.File{
+		Name: fileName,
+		Ext:  path.Ext(fileName),
+		Url:  url,
+	}, nil
+}
 
-	err = s.fileRepository.Create(&file)
+// UploadTo stores the data under a unique blob in the folder and keeps the original name only as the name of the file,
+// so files with the same name don't overwrite each other. Their keys can't be fetched through the public file route.
+func (s *FileService) UploadTo(folder, fileName string, data []byte) (models.File, error) {
+	key := folder + "/" + uuid.New().String() + path.Ext(fileName)
+
+	file, err := s.Upload(key, data)
 	if err != nil {
 		return file, err
 	}
 
+	file.Name = fileName
+	file.Key = key
+
 	return file, nil
 }
 
@@ -83,7 +112,12 @@ func (s *FileService) Delete(id string) error {
 		return err
 	}
 
-	err = s.blobService.Delete(file.Name)
+	key := file.Key
+	if key == "" {
+		key = file.Name
+	}
+
+	err = s.blobService.Delete(key)
 	if err != nil {
 		return err
 	}
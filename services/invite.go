@@ -0,0 +1,283 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"html"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+type IInviteService interface {
+	FindByTrainingID(trainingID, userID string) ([]models.Invite, error)
+	FindByCode(code string) (models.Invite, error)
+	Create(trainingID, userID string, dto dto.CreateInvite) (models.Invite, error)
+	SendEmails(trainingID, userID string, dto dto.EmailInvites) ([]models.Invite, error)
+	Revoke(id, userID string) (models.Invite, error)
+	Redeem(code, userID string) (models.Invite, bool, error)
+}
+
+type InviteService struct {
+	inviteRepository   repositories.IInviteRepository
+	trainingRepository repositories.ITrainingRepository
+	userRepository     repositories.IUserRepository
+	trainingService    ITrainingService
+	mailService        IMailService
+	appUrl             string
+}
+
+const inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+const inviteCodeLength = 10
+
+var (
+	inviteOnce    sync.Once
+	inviteService IInviteService
+)
+
+func GetInviteService() IInviteService {
+	inviteOnce.Do(func() {
+		log.Info().Msg("Initializing invite service")
+		inviteService = &InviteService{
+			inviteRepository:   repositories.GetInviteRepository(),
+			trainingRepository: repositories.GetTrainingRepository(),
+			userRepository:     repositories.GetUserRepository(),
+			trainingService:    GetTrainingService(),
+			mailService:        GetMailService(),
+			appUrl:             strings.TrimSuffix(os.Getenv("APP_URL"), "/"),
+		}
+	})
+	return inviteService
+}
+
+func (s *InviteService) FindByTrainingID(trainingID, userID string) ([]models.Invite, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Finding invites")
+
+	_, err := s.findManagedTraining(trainingID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.inviteRepository.FindByTrainingID(trainingID), nil
+}
+
+func (s *InviteService) FindByCode(code string) (models.Invite, error) {
+	log.Debug().Msg("Finding invite by code")
+
+	invite, err := s.inviteRepository.FindByCode(strings.ToUpper(code))
+	if err != nil {
+		return invite, err
+	}
+
+	if !invite.IsUsable() {
+		return models.Invite{}, errors.New("invite is no longer valid")
+	}
+
+	return invite, nil
+}
+
+func (s *InviteService) Create(trainingID, userID string, dto dto.CreateInvite) (models.Invite, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Creating invite")
+
+	training, err := s.findManagedTraining(trainingID, userID)
+	if err != nil {
+		return models.Invite{}, err
+	}
+
+	if dto.Role == models.CoOwnerMember && training.OwnerID != userID {
+		return models.Invite{}, errors.New("only the owner can invite co-owners")
+	}
+
+	return s.create(trainingID, userID, "", dto.Role, dto.MaxUses, dto.ExpiresAt)
+}
+
+func (s *InviteService) SendEmails(trainingID, userID string, dto dto.EmailInvites) ([]models.Invite, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Sending invite emails")
+
+	invites := []models.Invite{}
+
+	training, err := s.findManagedTraining(trainingID, userID)
+	if err != nil {
+		return invites, err
+	}
+
+	if dto.Role == models.CoOwnerMember && training.OwnerID != userID {
+		return invites, errors.New("only the owner can invite co-owners")
+	}
+
+	for _, email := range dto.Emails {
+		invite, err := s.create(trainingID, userID, strings.ToLower(email), dto.Role, 1, dto.ExpiresAt)
+		if err != nil {
+			return invites, err
+		}
+
+		go s.mailService.Send(Mail{
+			To:      []string{email},
+			Subject: "Trainings - Invitation",
+			Body: fmt.Sprintf("You are invited to join <strong>%s</strong>.<br>%s",
+				html.EscapeString(training.Name), s.inviteLink(invite.Code)),
+		})
+
+		invites = append(invites, invite)
+	}
+
+	return invites, nil
+}
+
+func (s *InviteService) Revoke(id, userID string) (models.Invite, error) {
+	log.Debug().Str(logger.InviteID, id).Str(logger.UserID, userID).Msg("Revoking invite")
+
+	invite, err := s.inviteRepository.FindByID(id)
+	if err != nil {
+		return invite, err
+	}
+
+	_, err = s.findManagedTraining(invite.TrainingID, userID)
+	if err != nil {
+		return invite, err
+	}
+
+	if invite.RevokedAt != nil {
+		return invite, errors.New("invite already revoked")
+	}
+
+	now := time.Now()
+	invite.RevokedAt = &now
+
+	err = s.inviteRepository.Update(&invite)
+	if err != nil {
+		return invite, err
+	}
+
+	return invite, nil
+}
+
+// Redeem enrolls the user in the training of the invite, it returns true when the user was waitlisted.
+func (s *InviteService) Redeem(code, userID string) (models.Invite, bool, error) {
+	log.Debug().Str(logger.UserID, userID).Msg("Redeeming invite")
+
+	invite, err := s.FindByCode(code)
+	if err != nil {
+		return invite, false, err
+	}
+
+	user, err := s.userRepository.FindByID(userID)
+	if err != nil {
+		return invite, false, err
+	}
+
+	if invite.Email != "" && !strings.EqualFold(invite.Email, user.Email) {
+		return invite, false, errors.New("invite was sent to another email")
+	}
+
+	err = s.trainingService.VerifyUserInTraining(invite.TrainingID, userID)
+	if err == nil {
+		return invite, false, errors.New("user already in this training")
+	}
+
+	err = s.inviteRepository.IncrementUses(&invite)
+	if err != nil {
+		return invite, false, err
+	}
+
+	role := invite.Role
+	if role == "" {
+		role = models.StudentMember
+	}
+
+	// A waitlisted user keeps the role of the invite until they are promoted.
+	waitlisted, err := s.trainingService.Enroll(invite.TrainingID, userID, role)
+	if err != nil {
+		s.releaseUse(&invite)
+		return invite, false, err
+	}
+
+	return invite, waitlisted, nil
+}
+
+// releaseUse gives back the use of a redemption that failed, so it doesn't count against the invite.
+func (s *InviteService) releaseUse(invite *models.Invite) {
+	err := s.inviteRepository.DecrementUses(invite)
+	if err != nil {
+		log.Err(err).Str(logger.InviteID, invite.ID).Msg("Failed to release invite use")
+	}
+}
+
+func (s *InviteService) create(trainingID, userID, email, role string, maxUses int, expiresAt *time.Time) (models.Invite, error) {
+	if expiresAt != nil && expiresAt.Before(time.Now()) {
+		return models.Invite{}, errors.New("expiry date is in the past")
+	}
+
+	if role == "" {
+		role = models.StudentMember
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		return models.Invite{}, err
+	}
+
+	invite := models.Invite{
+		TrainingID:  trainingID,
+		Code:        code,
+		Email:       email,
+		Role:        role,
+		MaxUses:     maxUses,
+		ExpiresAt:   expiresAt,
+		CreatedByID: userID,
+	}
+
+	err = s.inviteRepository.Create(&invite)
+	if err != nil {
+		return invite, err
+	}
+
+	return invite, nil
+}
+
+func (s *InviteService) findManagedTraining(trainingID, userID string) (models.Training, error) {
+	training, err := s.trainingRepository.FindByID(trainingID)
+	if err != nil {
+		return training, err
+	}
+
+	member, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err != nil || !member.CanManage() {
+		return training, errors.New("you are not allowed to manage this training")
+	}
+
+	return training, nil
+}
+
+func (s *InviteService) inviteLink(code string) string {
+	if s.appUrl == "" {
+		return fmt.Sprintf("Your invite code is <strong>%s</strong>.", code)
+	}
+
+	link := s.appUrl + "/invites/" + code
+	return fmt.Sprintf("<a href=\"%s\">%s</a>", link, link)
+}
+
+func generateInviteCode() (string, error) {
+	code := make([]byte, inviteCodeLength)
+	max := big.NewInt(int64(len(inviteCodeAlphabet)))
+
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = inviteCodeAlphabet[n.Int64()]
+	}
+
+	return string(code), nil
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+type IInvoiceService interface {
+	FindByUserID(userID string) []models.Invoice
+	FindOne(id, userID string) (models.Invoice, error)
+	Pdf(id, userID string) (models.Invoice, []byte, error)
+	Issue(orderID string) (models.Invoice, error)
+	Schedule(orderID string) error
+}
+
+type InvoiceService struct {
+	invoiceRepository  repositories.IInvoiceRepository
+	orderRepository    repositories.IOrderRepository
+	trainingRepository repositories.ITrainingRepository
+	userRepository     repositories.IUserRepository
+	blobService        IBlobService
+	mailService        IMailService
+	schedulerService   ISchedulerService
+}
+
+const issueInvoiceJob = "issue-invoice"
+
+var (
+	invoiceOnce    sync.Once
+	invoiceService IInvoiceService
+)
+
+func GetInvoiceService() IInvoiceService {
+	invoiceOnce.Do(func() {
+		log.Info().Msg("Initializing invoice service")
+		service := &InvoiceService{
+			invoiceRepository:  repositories.GetInvoiceRepository(),
+			orderRepository:    repositories.GetOrderRepository(),
+			trainingRepository: repositories.GetTrainingRepository(),
+			userRepository:     repositories.GetUserRepository(),
+			blobService:        GetBlobService(),
+			mailService:        GetMailService(),
+			schedulerService:   GetSchedulerService(),
+		}
+
+		service.schedulerService.Handle(issueInvoiceJob, service.issue)
+		invoiceService = service
+	})
+	return invoiceService
+}
+
+func (s *InvoiceService) FindByUserID(userID string) []models.Invoice {
+	log.Debug().Str(logger.UserID, userID).Msg("Finding user invoices")
+
+	return s.invoiceRepository.FindByUserID(userID)
+}
+
+// FindOne finds an invoice for its buyer, the owner and co-owners of the training or an admin.
+func (s *InvoiceService) FindOne(id, userID string) (models.Invoice, error) {
+	log.Debug().Str(logger.InvoiceID, id).Str(logger.UserID, userID).Msg("Finding invoice")
+
+	invoice, err := s.invoiceRepository.FindByID(id)
+	if err != nil {
+		return invoice, err
+	}
+
+	if invoice.UserID == userID {
+		return invoice, nil
+	}
+
+	member, err := s.trainingRepository.FindMember(invoice.TrainingID, userID)
+	if err == nil && member.CanManage() {
+		return invoice, nil
+	}
+
+	user, err := s.userRepository.FindByID(userID)
+	if err == nil && user.HasRole(models.AdminRole) {
+		return invoice, nil
+	}
+
+	return models.Invoice{}, errors.New("invoice not found")
+}
+
+func (s *InvoiceService) Pdf(id, userID string) (models.Invoice, []byte, error) {
+	invoice, err := s.FindOne(id, userID)
+	if err != nil {
+		return invoice, nil, err
+	}
+
+	data, err := writeInvoicePdf(invoice)
+
+	return invoice, data, err
+}
+
+// Schedule issues the invoice of a paid order in the background, failed attempts are retried by the scheduler.
+func (s *InvoiceService) Schedule(orderID string) error {
+	return s.schedulerService.Schedule(Job{
+		ID:      issueInvoiceJob + ":" + orderID,
+		Kind:    issueInvoiceJob,
+		Payload: orderID,
+	})
+}
+
+func (s *InvoiceService) issue(job Job) error {
+	_, err := s.Issue(job.Payload)
+	return err
+}
+
+// Issue invoices a paid order, stores the PDF and emails it to the buyer. An order is only ever invoiced once,
+// an invoice without a key didn't get stored and emailed yet and is finished by the next call.
+func (s *InvoiceService) Issue(orderID string) (models.Invoice, error) {
+	log.Debug().Str(logger.OrderID, orderID).Msg("Issuing invoice")
+
+	invoice, err := s.invoiceRepository.FindByOrderID(orderID)
+	if err == nil {
+		if invoice.Key != "" {
+			return invoice, nil
+		}
+
+		return s.finish(invoice)
+	}
+
+	order, err := s.orderRepository.FindByID(orderID)
+	if err != nil {
+		return invoice, err
+	}
+
+	// Refunded orders were paid too, they keep their invoice.
+	if order.Status != models.PaidOrder && order.Status != models.RefundedOrder {
+		return invoice, errors.New("order is not paid")
+	}
+
+	buyer, err := s.userRepository.FindByID(order.UserID)
+	if err != nil {
+		return invoice, err
+	}
+
+	seller, err := s.userRepository.FindByID(order.Training.OwnerID)
+	if err != nil {
+		return invoice, err
+	}
+
+	issuedAt := time.Now().UTC()
+	if order.PaidAt != nil {
+		issuedAt = order.PaidAt.UTC()
+	}
+
+	invoice = models.Invoice{
+		OrderID:      order.ID,
+		UserID:       order.UserID,
+		TrainingID:   order.TrainingID,
+		SellerName:   fullName(seller),
+		BuyerName:    fullName(buyer),
+		BuyerEmail:   buyer.Email,
+		TrainingName: order.Training.Name,
+		Price:        order.Price,
+		Discount:     order.Discount,
+		Amount:       order.Amount,
+		Currency:     order.Currency,
+		IssuedAt:     issuedAt,
+	}
+
+	err = s.invoiceRepository.Create(&invoice)
+	if err != nil {
+		// Another request invoiced the order in the meantime.
+		if existing, findErr := s.invoiceRepository.FindByOrderID(orderID); findErr == nil {
+			return existing, nil
+		}
+		return invoice, err
+	}
+
+	return s.finish(invoice)
+}
+
+// finish stores the PDF of a numbered invoice and emails it, the key is only set once both worked.
+// The blob is named after the random invoice ID so it can't be found by counting up invoice numbers.
+func (s *InvoiceService) finish(invoice models.Invoice) (models.Invoice, error) {
+	data, err := writeInvoicePdf(invoice)
+	if err != nil {
+		return invoice, err
+	}
+
+	fileName := "invoice-" + invoice.Number + ".pdf"
+	key := "invoices/" + invoice.ID + ".pdf"
+
+	_, err = s.blobService.Upload(key, data)
+	if err != nil {
+		return invoice, err
+	}
+
+	err = s.mailService.Deliver(Mail{
+		To:      []string{invoice.BuyerEmail},
+		Subject: "Trainings - Invoice " + invoice.Number,
+		Body: fmt.Sprintf("Your invoice for <strong>%s</strong> is attached, you paid %s.",
+			html.EscapeString(invoice.TrainingName), formatMoney(invoice.Amount, invoice.Currency)),
+		Attachments: []Attachment{{
+			Name:        fileName,
+			ContentType: "application/pdf",
+			Data:        data,
+		}},
+	})
+	if err != nil {
+		return invoice, err
+	}
+
+	invoice.Key = key
+
+	err = s.invoiceRepository.Update(&invoice)
+	if err != nil {
+		return invoice, err
+	}
+
+	return invoice, nil
+}
+
+// fullName returns the name of a user, or their email when they didn't set one.
+func fullName(user models.User) string {
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if name == "" {
+		return user.Email
+	}
+
+	return name
+}
@@ -0,0 +1,526 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/rdb"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/go-redis/redis/v9"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+type ILeaderboardService interface {
+	Find(userID string, query dto.LeaderboardQuery) (dto.Leaderboard, error)
+	FindByTrainingID(trainingID, userID string, query dto.LeaderboardQuery) (dto.Leaderboard, error)
+	UpdatePrivacy(userID string, dto dto.LeaderboardPrivacy) (models.User, error)
+	Add(entry models.PointsEntry)
+}
+
+type LeaderboardService struct {
+	pointsRepository   repositories.IPointsRepository
+	userRepository     repositories.IUserRepository
+	trainingRepository repositories.ITrainingRepository
+	rdb                *redis.Client
+	ctx                context.Context
+}
+
+const (
+	leaderboardPrefix = "leaderboard:"
+	// A board is only trusted once it was built from the ledger, if Redis loses it the marker is gone too.
+	// The marker holds the cutoff of the build, entries created up to it are part of the board.
+	leaderboardBuiltSuffix = ":built"
+	// While a board is built, new entries are queued on its pending list instead of being counted,
+	// entries the build counted one by one are kept in its applied set so their own increment is skipped.
+	leaderboardLockSuffix    = ":lock"
+	leaderboardNextSuffix    = ":next"
+	leaderboardPendingSuffix = ":pending"
+	leaderboardAppliedSuffix = ":applied"
+	leaderboardLockTTL       = 30 * time.Second
+	leaderboardAppliedTTL    = 10 * time.Minute
+	leaderboardAwaitTries    = 40
+	leaderboardAwaitDelay    = 50 * time.Millisecond
+	// Entries are summed once they are this old, younger ones might not be committed yet and are counted one by one.
+	leaderboardSettle = time.Minute
+	// Boards of a period are kept a bit after the period ends.
+	leaderboardRetention = 24 * time.Hour
+
+	allPeriod   = "all"
+	weekPeriod  = "week"
+	monthPeriod = "month"
+
+	leaderboardSize   = 10
+	leaderboardAround = 2
+)
+
+var (
+	leaderboardOnce    sync.Once
+	leaderboardService ILeaderboardService
+)
+
+func GetLeaderboardService() ILeaderboardService {
+	leaderboardOnce.Do(func() {
+		log.Info().Msg("Initializing leaderboard service")
+
+		rdb, ctx := rdb.GetRDB()
+		leaderboardService = &LeaderboardService{
+			pointsRepository:   repositories.GetPointsRepository(),
+			userRepository:     repositories.GetUserRepository(),
+			trainingRepository: repositories.GetTrainingRepository(),
+			rdb:                rdb,
+			ctx:                ctx,
+		}
+	})
+	return leaderboardService
+}
+
+// leaderboard is a Redis sorted set of user IDs scored by the points earned in a training, or in every
+// training if trainingID is empty, during a period, or all time if from is zero.
+type leaderboard struct {
+	key        string
+	period     string
+	trainingID string
+	from       time.Time
+	to         time.Time
+}
+
+func newLeaderboard(trainingID, period string, now time.Time) leaderboard {
+	board := leaderboard{
+		key:        leaderboardPrefix + "global:",
+		period:     period,
+		trainingID: trainingID,
+	}
+
+	if trainingID != "" {
+		board.key = leaderboardPrefix + "training:" + trainingID + ":"
+	}
+
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch period {
+	case weekPeriod:
+		// Weeks start on Monday.
+		board.from = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		board.to = board.from.AddDate(0, 0, 7)
+		year, week := board.from.ISOWeek()
+		board.key += fmt.Sprintf("week:%d-W%02d", year, week)
+	case monthPeriod:
+		board.from = time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+		board.to = board.from.AddDate(0, 1, 0)
+		board.key += "month:" + board.from.Format("2006-01")
+	default:
+		board.period = allPeriod
+		board.key += allPeriod
+	}
+
+	return board
+}
+
+// ttl is how long the board is kept, boards of all time are kept forever.
+func (b leaderboard) ttl(now time.Time) time.Duration {
+	if b.from.IsZero() {
+		return 0
+	}
+
+	return b.to.Sub(now) + leaderboardRetention
+}
+
+// leaderboardsOf lists every board an entry counts on.
+func leaderboardsOf(entry models.PointsEntry, now time.Time) []leaderboard {
+	periods := []string{allPeriod, weekPeriod, monthPeriod}
+	if entry.Reason == models.OpeningPoints {
+		periods = []string{allPeriod}
+	}
+
+	trainings := []string{""}
+	if entry.TrainingID != nil {
+		trainings = append(trainings, *entry.TrainingID)
+	}
+
+	var boards []leaderboard
+	for _, trainingID := range trainings {
+		for _, period := range periods {
+			boards = append(boards, newLeaderboard(trainingID, period, now))
+		}
+	}
+
+	return boards
+}
+
+func (s *LeaderboardService) Find(userID string, query dto.LeaderboardQuery) (dto.Leaderboard, error) {
+	log.Debug().Str(logger.UserID, userID).Str("period", query.Period).Msg("Finding leaderboard")
+
+	return s.find(newLeaderboard("", query.Period, time.Now()), userID, query)
+}
+
+func (s *LeaderboardService) FindByTrainingID(trainingID, userID string, query dto.LeaderboardQuery) (dto.Leaderboard, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Str("period", query.Period).Msg("Finding training leaderboard")
+
+	err := s.trainingRepository.VerifyUserInTraining(trainingID, userID)
+	if err != nil {
+		return dto.Leaderboard{}, err
+	}
+
+	return s.find(newLeaderboard(trainingID, query.Period, time.Now()), userID, query)
+}
+
+// UpdatePrivacy hides the user from every leaderboard or shows them again.
+func (s *LeaderboardService) UpdatePrivacy(userID string, dto dto.LeaderboardPrivacy) (models.User, error) {
+	log.Debug().Str(logger.UserID, userID).Bool("hidden", dto.Hidden).Msg("Updating leaderboard privacy")
+
+	user, err := s.userRepository.FindByID(userID)
+	if err != nil {
+		return user, err
+	}
+
+	if user.LeaderboardHidden == dto.Hidden {
+		return user, nil
+	}
+
+	user.LeaderboardHidden = dto.Hidden
+
+	err = s.userRepository.Update(&user)
+	if err != nil {
+		return user, err
+	}
+
+	err = s.sync(user)
+	if err != nil {
+		log.Err(err).Str(logger.UserID, userID).Msg("Failed to update leaderboards")
+	}
+
+	return user, nil
+}
+
+// addScript counts an entry on a built board unless the build already did. Entries added while the board
+// is built are queued for the build, boards that aren't built yet pick the entry up from the ledger.
+var addScript = redis.NewScript(`
+local board, built, lock, pending, applied = KEYS[1], KEYS[2], KEYS[3], KEYS[4], KEYS[5]
+local id, created, delta, user, ttl = ARGV[1], ARGV[2], ARGV[3], ARGV[4], tonumber(ARGV[5])
+
+if redis.call('EXISTS', lock) == 1 then
+	redis.call('RPUSH', pending, id .. ' ' .. created .. ' ' .. delta .. ' ' .. user)
+	redis.call('PEXPIRE', pending, ARGV[6])
+	return 0
+end
+
+local cutoff = redis.call('GET', built)
+if not cutoff or tonumber(created) <= tonumber(cutoff) or redis.call('SREM', applied, id) == 1 then
+	return 0
+end
+
+redis.call('ZINCRBY', board, delta, user)
+if ttl > 0 then
+	redis.call('PEXPIRE', board, ttl)
+end
+
+return 1
+`)
+
+// swapScript replaces a board with the one built next to it, counts the entries younger than the cutoff
+// and the ones queued during the build, each once, and marks the board as built.
+var swapScript = redis.NewScript(`
+local board, fresh, built, lock, pending, applied = KEYS[1], KEYS[2], KEYS[3], KEYS[4], KEYS[5], KEYS[6]
+local token, cutoff, ttl = ARGV[1], tonumber(ARGV[2]), tonumber(ARGV[3])
+
+if redis.call('GET', lock) ~= token then
+	return redis.error_reply('leaderboard build lock expired')
+end
+
+if redis.call('EXISTS', fresh) == 1 then
+	redis.call('RENAME', fresh, board)
+else
+	redis.call('DEL', board)
+end
+
+redis.call('DEL', applied)
+for i = 5, #ARGV, 4 do
+	redis.call('ZINCRBY', board, ARGV[i + 2], ARGV[i + 3])
+	redis.call('SADD', applied, ARGV[i])
+end
+
+for _, item in ipairs(redis.call('LRANGE', pending, 0, -1)) do
+	local id, created, delta, user = string.match(item, '(%S+) (%S+) (%S+) (%S+)')
+	if tonumber(created) > cutoff and redis.call('SREM', applied, id) == 0 then
+		redis.call('ZINCRBY', board, delta, user)
+	end
+end
+redis.call('DEL', pending, lock)
+redis.call('PEXPIRE', applied, ARGV[4])
+
+if ttl > 0 then
+	redis.call('SET', built, ARGV[2], 'PX', ttl)
+	redis.call('PEXPIRE', board, ttl)
+else
+	redis.call('SET', built, ARGV[2])
+end
+
+return 1
+`)
+
+// Add counts a new ledger entry on its built boards.
+func (s *LeaderboardService) Add(entry models.PointsEntry) {
+	user, err := s.userRepository.FindByID(entry.UserID)
+	if err != nil || user.LeaderboardHidden {
+		return
+	}
+
+	now := time.Now()
+
+	for _, board := range leaderboardsOf(entry, now) {
+		err = addScript.Run(s.ctx, s.rdb, board.keys(),
+			entry.ID, entry.CreatedAt.UnixNano(), entry.Delta, entry.UserID,
+			board.ttl(now).Milliseconds(), leaderboardAppliedTTL.Milliseconds()).Err()
+		if err != nil {
+			log.Err(err).Str(logger.UserID, entry.UserID).Msg("Failed to update leaderboards")
+		}
+	}
+}
+
+func (s *LeaderboardService) find(board leaderboard, userID string, query dto.LeaderboardQuery) (dto.Leaderboard, error) {
+	result := dto.Leaderboard{Period: board.period}
+
+	err := s.build(board)
+	if err != nil {
+		return result, err
+	}
+
+	size := query.Size
+	if size == 0 {
+		size = leaderboardSize
+	}
+
+	around := query.Around
+	if around == 0 {
+		around = leaderboardAround
+	}
+
+	result.Total, err = s.rdb.ZCard(s.ctx, board.key).Result()
+	if err != nil {
+		return result, err
+	}
+
+	top, err := s.rdb.ZRevRangeWithScores(s.ctx, board.key, 0, int64(size-1)).Result()
+	if err != nil {
+		return result, err
+	}
+
+	var nearby []redis.Z
+	var start int64
+
+	rank, err := s.rdb.ZRevRank(s.ctx, board.key, userID).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return result, err
+	}
+
+	// Users who opted out aren't on the board, they only get the top.
+	if err == nil {
+		start = rank - int64(around)
+		if start < 0 {
+			start = 0
+		}
+
+		nearby, err = s.rdb.ZRevRangeWithScores(s.ctx, board.key, start, rank+int64(around)).Result()
+		if err != nil {
+			return result, err
+		}
+	}
+
+	users := s.findUsers(top, nearby)
+
+	result.Top = leaderboardEntries(top, 0, users)
+	result.Nearby = leaderboardEntries(nearby, start, users)
+
+	for i := range result.Nearby {
+		if result.Nearby[i].UserID == userID {
+			result.Me = &result.Nearby[i]
+		}
+	}
+
+	return result, nil
+}
+
+// keys are the keys addScript works on.
+func (b leaderboard) keys() []string {
+	return []string{
+		b.key,
+		b.key + leaderboardBuiltSuffix,
+		b.key + leaderboardLockSuffix,
+		b.key + leaderboardPendingSuffix,
+		b.key + leaderboardAppliedSuffix,
+	}
+}
+
+// build fills the board from the ledger unless it was already built. The board is built next to the old one
+// and swapped in under a lock, entries added meanwhile are queued and counted by the swap.
+func (s *LeaderboardService) build(board leaderboard) error {
+	built, err := s.rdb.Exists(s.ctx, board.key+leaderboardBuiltSuffix).Result()
+	if err != nil || built > 0 {
+		return err
+	}
+
+	token := uuid.New().String()
+
+	locked, err := s.rdb.SetNX(s.ctx, board.key+leaderboardLockSuffix, token, leaderboardLockTTL).Result()
+	if err != nil {
+		return err
+	}
+
+	if !locked {
+		return s.awaitBuild(board)
+	}
+
+	log.Info().Str("board", board.key).Msg("Building leaderboard")
+
+	now := time.Now()
+	cutoff := now.Add(-leaderboardSettle).Truncate(time.Microsecond)
+
+	scores := s.pointsRepository.SumByUser(board.trainingID, board.from, board.to, cutoff)
+
+	members := make([]redis.Z, 0, len(scores))
+	for _, score := range scores {
+		members = append(members, redis.Z{Score: float64(score.Points), Member: score.UserID})
+	}
+
+	next := board.key + leaderboardNextSuffix
+
+	_, err = s.rdb.TxPipelined(s.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(s.ctx, next)
+
+		if len(members) > 0 {
+			pipe.ZAdd(s.ctx, next, members...)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	args := []interface{}{token, cutoff.UnixNano(), board.ttl(now).Milliseconds(), leaderboardAppliedTTL.Milliseconds()}
+	for _, entry := range s.pointsRepository.FindSince(board.trainingID, board.from, board.to, cutoff) {
+		args = append(args, entry.ID, strconv.FormatInt(entry.CreatedAt.UnixNano(), 10), entry.Delta, entry.UserID)
+	}
+
+	keys := []string{
+		board.key,
+		next,
+		board.key + leaderboardBuiltSuffix,
+		board.key + leaderboardLockSuffix,
+		board.key + leaderboardPendingSuffix,
+		board.key + leaderboardAppliedSuffix,
+	}
+
+	return swapScript.Run(s.ctx, s.rdb, keys, args...).Err()
+}
+
+// awaitBuild waits a moment for another request to finish building the board.
+func (s *LeaderboardService) awaitBuild(board leaderboard) error {
+	for i := 0; i < leaderboardAwaitTries; i++ {
+		time.Sleep(leaderboardAwaitDelay)
+
+		built, err := s.rdb.Exists(s.ctx, board.key+leaderboardBuiltSuffix).Result()
+		if err != nil || built > 0 {
+			return err
+		}
+	}
+
+	return errors.New("leaderboard is being built, try again in a moment")
+}
+
+// sync removes a hidden user from the boards they are on, or puts a visible user back on the built ones.
+func (s *LeaderboardService) sync(user models.User) error {
+	now := time.Now()
+
+	var boards []leaderboard
+	var points []int
+
+	for _, period := range []string{allPeriod, weekPeriod, monthPeriod} {
+		global := newLeaderboard("", period, now)
+		total := 0
+
+		for _, score := range s.pointsRepository.SumByTraining(user.ID, global.from, global.to) {
+			total += score.Points
+
+			if score.TrainingID != nil {
+				boards = append(boards, newLeaderboard(*score.TrainingID, period, now))
+				points = append(points, score.Points)
+			}
+		}
+
+		boards = append(boards, global)
+		points = append(points, total)
+	}
+
+	for i, board := range boards {
+		if user.LeaderboardHidden {
+			err := s.rdb.ZRem(s.ctx, board.key, user.ID).Err()
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		built, err := s.rdb.Exists(s.ctx, board.key+leaderboardBuiltSuffix).Result()
+		if err != nil {
+			return err
+		}
+
+		if built > 0 {
+			err = s.rdb.ZAdd(s.ctx, board.key, redis.Z{Score: float64(points[i]), Member: user.ID}).Err()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *LeaderboardService) findUsers(boards ...[]redis.Z) map[string]models.User {
+	var ids []string
+	for _, board := range boards {
+		for _, member := range board {
+			ids = append(ids, member.Member.(string))
+		}
+	}
+
+	users := map[string]models.User{}
+	if len(ids) == 0 {
+		return users
+	}
+
+	for _, user := range s.userRepository.FindByIDs(ids) {
+		users[user.ID] = user
+	}
+
+	return users
+}
+
+func leaderboardEntries(members []redis.Z, start int64, users map[string]models.User) []dto.LeaderboardEntry {
+	entries := make([]dto.LeaderboardEntry, 0, len(members))
+
+	for i, member := range members {
+		userID := member.Member.(string)
+		user := users[userID]
+
+		entries = append(entries, dto.LeaderboardEntry{
+			Rank:      start + int64(i) + 1,
+			UserID:    userID,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Points:    int(member.Score),
+		})
+	}
+
+	return entries
+}
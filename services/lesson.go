@@ -0,0 +1,266 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+type ILessonService interface {
+	FindOne(id, userID string) (models.Lesson, error)
+	Create(moduleID, userID string, dto dto.CreateLesson) (models.Lesson, error)
+	Update(id, userID string, dto dto.UpdateLesson) (models.Lesson, error)
+	Delete(id, userID string) error
+	Complete(id, userID string) (models.LessonProgress, error)
+	Upload(id, userID, fileName string, data []byte) (models.File, error)
+}
+
+type LessonService struct {
+	lessonRepository   repositories.ILessonRepository
+	moduleRepository   repositories.IModuleRepository
+	trainingRepository repositories.ITrainingRepository
+	fileRepository     repositories.IFileRepository
+	fileService        IFileService
+	certificateService ICertificateService
+	pointsService      IPointsService
+}
+
+var (
+	lessonOnce    sync.Once
+	lessonService ILessonService
+)
+
+func GetLessonService() ILessonService {
+	lessonOnce.Do(func() {
+		log.Info().Msg("Initializing lesson service")
+		lessonService = &LessonService{
+			lessonRepository:   repositories.GetLessonRepository(),
+			moduleRepository:   repositories.GetModuleRepository(),
+			trainingRepository: repositories.GetTrainingRepository(),
+			fileRepository:     repositories.GetFileRepository(),
+			fileService:        GetFileService(),
+			certificateService: GetCertificateService(),
+			pointsService:      GetPointsService(),
+		}
+	})
+	return lessonService
+}
+
+func (s *LessonService) FindOne(id, userID string) (models.Lesson, error) {
+	log.Debug().Str(logger.LessonID, id).Str(logger.UserID, userID).Msg("Finding lesson")
+
+	lesson, err := s.lessonRepository.FindByID(id)
+	if err != nil {
+		return lesson, err
+	}
+
+	member, err := s.trainingRepository.FindMember(lesson.TrainingID, userID)
+	if err != nil {
+		return models.Lesson{}, errors.New("user is not in training")
+	}
+
+	if member.CanPost() {
+		return lesson, nil
+	}
+
+	lesson, err = s.findReleased(lesson, userID)
+	if err != nil {
+		return lesson, err
+	}
+
+	err = s.lessonRepository.Open(&lesson, userID)
+	if err != nil {
+		log.Err(err).Str(logger.LessonID, id).Msg("Failed to record lesson progress")
+	}
+
+	return lesson, nil
+}
+
+func (s *LessonService) Create(moduleID, userID string, dto dto.CreateLesson) (models.Lesson, error) {
+	log.Debug().Str(logger.ModuleID, moduleID).Str(logger.UserID, userID).Msg("Creating lesson")
+
+	module, err := s.moduleRepository.FindByID(moduleID)
+	if err != nil {
+		return models.Lesson{}, err
+	}
+
+	err = s.verifyCanPost(module.TrainingID, userID)
+	if err != nil {
+		return models.Lesson{}, err
+	}
+
+	lesson := models.Lesson{
+		ModuleID:   module.ID,
+		TrainingID: module.TrainingID,
+		Files:      []models.File{},
+	}
+	fillLesson(&lesson, dto)
+
+	err = s.lessonRepository.Create(&lesson)
+	if err != nil {
+		return lesson, err
+	}
+
+	return lesson, nil
+}
+
+func (s *LessonService) Update(id, userID string, dto dto.UpdateLesson) (models.Lesson, error) {
+	log.Debug().Str(logger.LessonID, id).Str(logger.UserID, userID).Msg("Updating lesson")
+
+	lesson, err := s.lessonRepository.FindByID(id)
+	if err != nil {
+		return lesson, err
+	}
+
+	err = s.verifyCanPost(lesson.TrainingID, userID)
+	if err != nil {
+		return lesson, err
+	}
+
+	fillLesson(&lesson, createLesson(dto))
+
+	err = s.lessonRepository.Update(&lesson)
+	if err != nil {
+		return lesson, err
+	}
+
+	return lesson, nil
+}
+
+func (s *LessonService) Delete(id, userID string) error {
+	log.Debug().Str(logger.LessonID, id).Str(logger.UserID, userID).Msg("Deleting lesson")
+
+	lesson, err := s.lessonRepository.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.verifyCanPost(lesson.TrainingID, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.lessonRepository.Delete(&lesson)
+}
+
+func (s *LessonService) Complete(id, userID string) (models.LessonProgress, error) {
+	log.Debug().Str(logger.LessonID, id).Str(logger.UserID, userID).Msg("Completing lesson")
+
+	lesson, err := s.lessonRepository.FindByID(id)
+	if err != nil {
+		return models.LessonProgress{}, err
+	}
+
+	err = s.trainingRepository.VerifyUserInTraining(lesson.TrainingID, userID)
+	if err != nil {
+		return models.LessonProgress{}, err
+	}
+
+	lesson, err = s.findReleased(lesson, userID)
+	if err != nil {
+		return models.LessonProgress{}, err
+	}
+
+	progress, err := s.lessonRepository.Complete(&lesson, userID)
+	if err != nil {
+		return progress, err
+	}
+
+	s.pointsService.Award(userID, models.LessonPoints, &lesson.TrainingID, lesson.ID)
+
+	go s.certificateService.IssueIfCompleted(lesson.TrainingID, userID)
+
+	return progress, nil
+}
+
+func (s *LessonService) Upload(id, userID, fileName string, data []byte) (models.File, error) {
+	log.Debug().Str(logger.LessonID, id).Str(logger.UserID, userID).Msg("Uploading lesson file")
+
+	lesson, err := s.lessonRepository.FindByID(id)
+	if err != nil {
+		return models.File{}, err
+	}
+
+	err = s.verifyCanPost(lesson.TrainingID, userID)
+	if err != nil {
+		return models.File{}, err
+	}
+
+	file, err := s.fileService.Upload(fileName, data)
+	if err != nil {
+		return file, err
+	}
+
+	file.LessonID = &lesson.ID
+
+	err = s.fileRepository.Create(&file)
+	if err != nil {
+		return file, err
+	}
+
+	return file, nil
+}
+
+// findReleased returns the lesson as a learner sees it, failing when it isn't released to them yet.
+func (s *LessonService) findReleased(lesson models.Lesson, userID string) (models.Lesson, error) {
+	locked, ok := lessonLocks(s.lessonRepository, s.moduleRepository, lesson.TrainingID, userID)[lesson.ID]
+	if !ok {
+		return models.Lesson{}, errors.New("lesson not found")
+	}
+
+	if locked {
+		return models.Lesson{}, errors.New("lesson is not released yet")
+	}
+
+	return lesson, nil
+}
+
+func (s *LessonService) verifyCanPost(trainingID, userID string) error {
+	member, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err != nil || !member.CanPost() {
+		return errors.New("you are not allowed to edit the content of this training")
+	}
+
+	return nil
+}
+
+func createLesson(update dto.UpdateLesson) dto.CreateLesson {
+	return dto.CreateLesson(update)
+}
+
+func fillLesson(lesson *models.Lesson, dto dto.CreateLesson) {
+	lesson.Title = dto.Title
+	lesson.Body = dto.Body
+	lesson.Duration = dto.Duration
+	lesson.Published = dto.Published
+	lesson.RequiresPrevious = dto.RequiresPrevious
+	lesson.ReleaseAt = nil
+
+	if dto.ReleaseAt != nil {
+		releaseAt := dto.ReleaseAt.UTC()
+		lesson.ReleaseAt = &releaseAt
+	}
+}
+
+// lessonLocks tells for every published lesson of a training whether it is still locked for the user.
+func lessonLocks(lessonRepository repositories.ILessonRepository, moduleRepository repositories.IModuleRepository, trainingID, userID string) map[string]bool {
+	completed := map[string]bool{}
+	for _, progress := range lessonRepository.FindProgress(trainingID, userID) {
+		completed[progress.LessonID] = progress.CompletedAt != nil
+	}
+
+	locks := map[string]bool{}
+	for _, module := range lockLessons(moduleRepository.FindByTrainingID(trainingID), completed, time.Now()) {
+		for _, lesson := range module.Lessons {
+			locks[lesson.ID] = lesson.Locked
+		}
+	}
+
+	return locks
+}
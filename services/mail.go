@@ -1,6 +1,7 @@
 package services
 
 import (
+	"encoding/base64"
 	"fmt"
 	"net/smtp"
 	"os"
@@ -11,13 +12,23 @@ import (
 )
 
 type Mail struct {
-	To      []string
-	Subject string
-	Body    string
+	To          []string
+	Subject     string
+	Body        string
+	Attachments []Attachment
 }
 
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+const mailBoundary = "xmas-faf-mail-boundary"
+
 type IMailService interface {
 	Send(mail Mail)
+	Deliver(mail Mail) error
 }
 
 type MailService struct {
@@ -57,21 +68,54 @@ func GetMailService() IMailService {
 }
 
 func (s *MailService) Send(mail Mail) {
-	log.Debug().Msg("Sending mail")
-	msg := s.buildMail(mail)
-
-	err := smtp.SendMail(s.addr, s.auth, s.from, mail.To, msg)
+	err := s.Deliver(mail)
 	if err != nil {
 		log.Error().Err(err).Msg("Error sending mail")
 	}
 }
 
+// Deliver sends the mail and returns the error instead of logging it, so callers can retry.
+func (s *MailService) Deliver(mail Mail) error {
+	log.Debug().Msg("Sending mail")
+	msg := s.buildMail(mail)
+
+	return smtp.SendMail(s.addr, s.auth, s.from, mail.To, msg)
+}
+
 func (s *MailService) buildMail(mail Mail) []byte {
-	msg := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\r\n"
+	contentType := "text/html; charset=\"UTF-8\""
+	// Mails with attachments are sent as multipart, with the body as the first part.
+	if len(mail.Attachments) > 0 {
+		contentType = fmt.Sprintf("multipart/mixed; boundary=%q", mailBoundary)
+	}
+
+	msg := fmt.Sprintf("MIME-version: 1.0;\nContent-Type: %s;\r\n", contentType)
 	msg += fmt.Sprintf("From: %s\r\n", s.senderName)
 	msg += fmt.Sprintf("To: %s\r\n", strings.Join(mail.To, ";"))
 	msg += fmt.Sprintf("Subject: %s\r\n", mail.Subject)
-	msg += fmt.Sprintf("\r\n%s\r\n", mail.Body)
+
+	if len(mail.Attachments) == 0 {
+		msg += fmt.Sprintf("\r\n%s\r\n", mail.Body)
+		return []byte(msg)
+	}
+
+	msg += fmt.Sprintf("\r\n--%s\r\nContent-Type: text/html; charset=\"UTF-8\"\r\n\r\n%s\r\n", mailBoundary, mail.Body)
+
+	for _, attachment := range mail.Attachments {
+		msg += fmt.Sprintf("--%s\r\n", mailBoundary)
+		msg += fmt.Sprintf("Content-Type: %s; name=%q\r\n", attachment.ContentType, attachment.Name)
+		msg += "Content-Transfer-Encoding: base64\r\n"
+		msg += fmt.Sprintf("Content-Disposition: attachment; filename=%q\r\n\r\n", attachment.Name)
+
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		for len(encoded) > 76 {
+			msg += encoded[:76] + "\r\n"
+			encoded = encoded[76:]
+		}
+		msg += encoded + "\r\n"
+	}
+
+	msg += fmt.Sprintf("--%s--\r\n", mailBoundary)
 
 	return []byte(msg)
 }
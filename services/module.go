@@ -0,0 +1,236 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+type IModuleService interface {
+	FindByTrainingID(trainingID, userID string) ([]models.Module, error)
+	Create(trainingID, userID string, dto dto.CreateModule) (models.Module, error)
+	Update(id, userID string, dto dto.UpdateModule) (models.Module, error)
+	Delete(id, userID string) error
+	Reorder(trainingID, userID string, dto dto.ReorderCurriculum) ([]models.Module, error)
+}
+
+type ModuleService struct {
+	moduleRepository   repositories.IModuleRepository
+	lessonRepository   repositories.ILessonRepository
+	trainingRepository repositories.ITrainingRepository
+	trainingService    ITrainingService
+}
+
+var (
+	moduleOnce    sync.Once
+	moduleService IModuleService
+)
+
+func GetModuleService() IModuleService {
+	moduleOnce.Do(func() {
+		log.Info().Msg("Initializing module service")
+		moduleService = &ModuleService{
+			moduleRepository:   repositories.GetModuleRepository(),
+			lessonRepository:   repositories.GetLessonRepository(),
+			trainingRepository: repositories.GetTrainingRepository(),
+			trainingService:    GetTrainingService(),
+		}
+	})
+	return moduleService
+}
+
+// FindByTrainingID returns the curriculum of a training. Staff who can post see every lesson,
+// learners only see published lessons and the content of the ones released to them.
+// Anyone else who can see the training only gets the outline.
+func (s *ModuleService) FindByTrainingID(trainingID, userID string) ([]models.Module, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Msg("Finding modules")
+
+	_, err := s.trainingService.FindOne(trainingID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := s.moduleRepository.FindByTrainingID(trainingID)
+
+	member, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err == nil && member.CanPost() {
+		return modules, nil
+	}
+
+	completed := map[string]bool{}
+	if err == nil {
+		for _, progress := range s.lessonRepository.FindProgress(trainingID, userID) {
+			completed[progress.LessonID] = progress.CompletedAt != nil
+		}
+	}
+
+	modules = lockLessons(modules, completed, time.Now())
+
+	for i := range modules {
+		for j := range modules[i].Lessons {
+			lesson := &modules[i].Lessons[j]
+			if err != nil || lesson.Locked {
+				lesson.Body = ""
+				lesson.Files = []models.File{}
+			}
+		}
+	}
+
+	return modules, nil
+}
+
+func (s *ModuleService) Create(trainingID, userID string, dto dto.CreateModule) (models.Module, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Creating module")
+
+	err := s.verifyCanPost(trainingID, userID)
+	if err != nil {
+		return models.Module{}, err
+	}
+
+	module := models.Module{
+		TrainingID:  trainingID,
+		Title:       dto.Title,
+		Description: dto.Description,
+		Lessons:     []models.Lesson{},
+	}
+
+	err = s.moduleRepository.Create(&module)
+	if err != nil {
+		return module, err
+	}
+
+	return module, nil
+}
+
+func (s *ModuleService) Update(id, userID string, dto dto.UpdateModule) (models.Module, error) {
+	log.Debug().Str(logger.ModuleID, id).Str(logger.UserID, userID).Msg("Updating module")
+
+	module, err := s.moduleRepository.FindByID(id)
+	if err != nil {
+		return module, err
+	}
+
+	err = s.verifyCanPost(module.TrainingID, userID)
+	if err != nil {
+		return module, err
+	}
+
+	module.Title = dto.Title
+	module.Description = dto.Description
+
+	err = s.moduleRepository.Update(&module)
+	if err != nil {
+		return module, err
+	}
+
+	return module, nil
+}
+
+func (s *ModuleService) Delete(id, userID string) error {
+	log.Debug().Str(logger.ModuleID, id).Str(logger.UserID, userID).Msg("Deleting module")
+
+	module, err := s.moduleRepository.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.verifyCanPost(module.TrainingID, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.moduleRepository.Delete(&module)
+}
+
+// Reorder expects every module and lesson of the training to be listed exactly once,
+// so a stale curriculum on the client can't silently drop or duplicate anything.
+func (s *ModuleService) Reorder(trainingID, userID string, dto dto.ReorderCurriculum) ([]models.Module, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Reordering curriculum")
+
+	err := s.verifyCanPost(trainingID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := map[string]bool{}
+	lessons := map[string]bool{}
+	for _, module := range s.moduleRepository.FindByTrainingID(trainingID) {
+		modules[module.ID] = false
+		for _, lesson := range module.Lessons {
+			lessons[lesson.ID] = false
+		}
+	}
+
+	for _, module := range dto.Modules {
+		listed, ok := modules[module.ID]
+		if !ok || listed {
+			return nil, errors.New("modules have to be listed exactly once")
+		}
+		modules[module.ID] = true
+
+		for _, lessonID := range module.Lessons {
+			listed, ok := lessons[lessonID]
+			if !ok || listed {
+				return nil, errors.New("lessons have to be listed exactly once")
+			}
+			lessons[lessonID] = true
+		}
+	}
+
+	if len(dto.Modules) != len(modules) {
+		return nil, errors.New("every module of the training has to be listed")
+	}
+
+	for _, listed := range lessons {
+		if !listed {
+			return nil, errors.New("every lesson of the training has to be listed")
+		}
+	}
+
+	err = s.moduleRepository.Reorder(trainingID, dto)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.moduleRepository.FindByTrainingID(trainingID), nil
+}
+
+func (s *ModuleService) verifyCanPost(trainingID, userID string) error {
+	member, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err != nil || !member.CanPost() {
+		return errors.New("you are not allowed to edit the content of this training")
+	}
+
+	return nil
+}
+
+// lockLessons drops the unpublished lessons and locks the ones that aren't released yet,
+// or that require the previous lesson of the curriculum to be completed first.
+func lockLessons(modules []models.Module, completed map[string]bool, now time.Time) []models.Module {
+	previous := ""
+
+	for i := range modules {
+		lessons := []models.Lesson{}
+
+		for _, lesson := range modules[i].Lessons {
+			if !lesson.Published {
+				continue
+			}
+
+			lesson.Locked = !lesson.IsReleased(now) || (lesson.RequiresPrevious && previous != "" && !completed[previous])
+			previous = lesson.ID
+
+			lessons = append(lessons, lesson)
+		}
+
+		modules[i].Lessons = lessons
+	}
+
+	return modules
+}
@@ -0,0 +1,370 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+// checkoutLifetime is how long a pending checkout is reused before a new one is opened.
+const checkoutLifetime = 23 * time.Hour
+
+type IOrderService interface {
+	FindByUserID(userID string) []models.Order
+	FindOne(id, userID string) (models.Order, error)
+	Checkout(trainingID, userID string, dto dto.Checkout) (models.Order, error)
+	HandleWebhook(payload []byte, header http.Header) error
+}
+
+type OrderService struct {
+	orderRepository    repositories.IOrderRepository
+	couponRepository   repositories.ICouponRepository
+	trainingRepository repositories.ITrainingRepository
+	userRepository     repositories.IUserRepository
+	trainingService    ITrainingService
+	invoiceService     IInvoiceService
+	mailService        IMailService
+	paymentProvider    PaymentProvider
+	currency           string
+}
+
+var (
+	orderOnce    sync.Once
+	orderService IOrderService
+)
+
+func GetOrderService() IOrderService {
+	orderOnce.Do(func() {
+		log.Info().Msg("Initializing order service")
+
+		currency := strings.ToLower(os.Getenv("PAYMENT_CURRENCY"))
+		if currency == "" {
+			currency = "eur"
+		}
+
+		orderService = &OrderService{
+			orderRepository:    repositories.GetOrderRepository(),
+			couponRepository:   repositories.GetCouponRepository(),
+			trainingRepository: repositories.GetTrainingRepository(),
+			userRepository:     repositories.GetUserRepository(),
+			trainingService:    GetTrainingService(),
+			invoiceService:     GetInvoiceService(),
+			mailService:        GetMailService(),
+			paymentProvider:    GetPaymentProvider(),
+			currency:           currency,
+		}
+	})
+	return orderService
+}
+
+func (s *OrderService) FindByUserID(userID string) []models.Order {
+	log.Debug().Str(logger.UserID, userID).Msg("Finding user orders")
+
+	return s.orderRepository.FindByUserID(userID)
+}
+
+func (s *OrderService) FindOne(id, userID string) (models.Order, error) {
+	log.Debug().Str(logger.OrderID, id).Str(logger.UserID, userID).Msg("Finding order")
+
+	order, err := s.orderRepository.FindByID(id)
+	if err != nil {
+		return order, err
+	}
+
+	if order.UserID != userID {
+		member, err := s.trainingRepository.FindMember(order.TrainingID, userID)
+		if err != nil || !member.CanManage() {
+			return models.Order{}, errors.New("order not found")
+		}
+	}
+
+	return order, nil
+}
+
+// Checkout opens a payment with the provider for a paid training, discounted by the coupon if one is given.
+// Asking again while the previous checkout is still pending returns the same order instead of a new one.
+func (s *OrderService) Checkout(trainingID, userID string, dto dto.Checkout) (models.Order, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Checking out training")
+
+	training, err := s.trainingRepository.FindByID(trainingID)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	if training.Visibility == models.DraftVisibility || training.Visibility == models.PrivateVisibility {
+		return models.Order{}, errors.New("training is not open for enrollment")
+	}
+
+	if training.Price <= 0 {
+		return models.Order{}, errors.New("training is free")
+	}
+
+	if s.isEnrolled(trainingID, userID) {
+		return models.Order{}, errors.New("user already in this training")
+	}
+
+	var coupon *models.Coupon
+	if dto.Coupon != "" {
+		found, err := s.findCoupon(dto.Coupon, training)
+		if err != nil {
+			return models.Order{}, err
+		}
+
+		coupon = &found
+	}
+
+	since := time.Now().Add(-checkoutLifetime)
+
+	order, err := s.orderRepository.FindPending(trainingID, userID, since)
+	if err == nil && sameCoupon(order.CouponID, coupon) {
+		return order, nil
+	}
+
+	user, err := s.userRepository.FindByID(userID)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	order = models.Order{
+		TrainingID: trainingID,
+		UserID:     userID,
+		Price:      training.Price * 100,
+		Currency:   s.currency,
+		Status:     models.PendingOrder,
+		Provider:   s.paymentProvider.Name(),
+	}
+
+	if coupon == nil {
+		order.Amount = order.Price
+		err = s.orderRepository.Create(&order)
+	} else {
+		order.CouponID = &coupon.ID
+		order.Discount = coupon.DiscountOn(order.Price)
+		order.Amount = order.Price - order.Discount
+		err = s.orderRepository.CreateWithCoupon(&order, *coupon, since)
+	}
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	// Nothing to charge, the coupon pays for the whole training.
+	if order.Amount == 0 {
+		_, err = s.orderRepository.Resolve(&order, "coupon_"+order.ID, models.PaidOrder)
+		if err != nil {
+			return order, err
+		}
+
+		order.Training = training
+
+		return order, s.complete(order)
+	}
+
+	checkout, err := s.paymentProvider.CreateCheckout(order, training, user)
+	if err != nil {
+		log.Err(err).Str(logger.OrderID, order.ID).Msg("Failed to create checkout")
+
+		order.Status = models.FailedOrder
+		s.orderRepository.Update(&order)
+
+		return models.Order{}, errors.New("failed to create checkout")
+	}
+
+	order.ProviderRef = checkout.Reference
+	order.CheckoutUrl = checkout.Url
+
+	err = s.orderRepository.Update(&order)
+	if err != nil {
+		return order, err
+	}
+
+	order.Training = training
+
+	return order, nil
+}
+
+// HandleWebhook applies a payment event of the provider to its order and enrolls the buyer once the order is paid.
+// Providers retry deliveries, every event changes the order at most once. Failing to complete the order fails the
+// delivery, the retry enrolls the buyer and invoices the order if that didn't happen yet.
+func (s *OrderService) HandleWebhook(payload []byte, header http.Header) error {
+	event, err := s.paymentProvider.ParseWebhook(payload, header)
+	if err != nil {
+		return err
+	}
+
+	log.Debug().Str("event", event.ID).Str("type", event.Type).Msg("Handling payment event")
+
+	if event.Type == "" {
+		return nil
+	}
+
+	order, err := s.orderRepository.FindByProviderRef(s.paymentProvider.Name(), event.Reference)
+	if err != nil {
+		return errors.New("order not found")
+	}
+
+	status := models.FailedOrder
+	if event.Type == PaymentSucceeded {
+		status = models.PaidOrder
+	}
+
+	_, err = s.orderRepository.Resolve(&order, event.ID, status)
+	if err != nil || status != models.PaidOrder || order.Status != models.PaidOrder {
+		return err
+	}
+
+	return s.complete(order)
+}
+
+// findCoupon finds a coupon by its code and checks that it can be used for the training right now.
+func (s *OrderService) findCoupon(code string, training models.Training) (models.Coupon, error) {
+	coupon, err := s.couponRepository.FindByCode(strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		return coupon, errors.New("coupon not found")
+	}
+
+	if !coupon.IsActive(time.Now()) {
+		return coupon, errors.New("coupon is not active")
+	}
+
+	if !coupon.AppliesTo(training) {
+		return coupon, errors.New("coupon doesn't apply to this training")
+	}
+
+	return coupon, nil
+}
+
+func sameCoupon(couponID *string, coupon *models.Coupon) bool {
+	if couponID == nil || coupon == nil {
+		return couponID == nil && coupon == nil
+	}
+
+	return *couponID == coupon.ID
+}
+
+// complete enrolls the buyer of a paid order and schedules its invoice, both only happen once per order.
+func (s *OrderService) complete(order models.Order) error {
+	err := s.enroll(order)
+	if err != nil {
+		return err
+	}
+
+	err = s.invoiceService.Schedule(order.ID)
+	if err != nil {
+		log.Err(err).Str(logger.OrderID, order.ID).Msg("Failed to schedule invoice")
+		return err
+	}
+
+	return nil
+}
+
+// enroll enrolls the buyer of a paid order once. The order is claimed before the buyer is enrolled,
+// so a buyer who is a member or on the waitlist already didn't get there through this order
+// and gets the payment refunded, e.g. when two checkouts were paid or the owner added them meanwhile.
+func (s *OrderService) enroll(order models.Order) error {
+	if order.EnrolledAt != nil {
+		return nil
+	}
+
+	training, err := s.trainingRepository.FindByID(order.TrainingID)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.userRepository.FindByID(order.UserID)
+	if err != nil {
+		return err
+	}
+
+	claimed, err := s.orderRepository.MarkEnrolled(&order)
+	if err != nil || !claimed {
+		return err
+	}
+
+	if s.isEnrolled(training.ID, user.ID) {
+		err = s.refundDuplicate(order, training, user)
+		if err != nil {
+			s.orderRepository.UnmarkEnrolled(&order)
+		}
+
+		return err
+	}
+
+	// The buyer is enrolled on behalf of the owner, who accepted the payment.
+	waitlisted, err := s.trainingService.AddUser(training.ID, user.ID, training.OwnerID)
+	if err != nil {
+		log.Err(err).Str(logger.OrderID, order.ID).Msg("Failed to enroll buyer")
+
+		s.orderRepository.UnmarkEnrolled(&order)
+
+		return err
+	}
+
+	body := fmt.Sprintf("Thank you for your payment, you are now enrolled in <strong>%s</strong>.", html.EscapeString(training.Name))
+	if waitlisted {
+		body = fmt.Sprintf("Thank you for your payment. <strong>%s</strong> is full, "+
+			"you are on the waitlist and will be enrolled as soon as a spot opens up.", html.EscapeString(training.Name))
+	}
+
+	go s.mailService.Send(Mail{
+		To:      []string{user.Email},
+		Subject: "Trainings - Payment Received",
+		Body:    body,
+	})
+
+	return nil
+}
+
+// isEnrolled reports whether the user is a member of the training or on its waitlist.
+func (s *OrderService) isEnrolled(trainingID, userID string) bool {
+	err := s.trainingRepository.VerifyUserInTraining(trainingID, userID)
+	if err == nil {
+		return true
+	}
+
+	for _, entry := range s.trainingRepository.FindWaitlist(trainingID) {
+		if entry.UserID == userID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// refundDuplicate gives the whole amount of an order back to a buyer who was enrolled without it.
+func (s *OrderService) refundDuplicate(order models.Order, training models.Training, user models.User) error {
+	log.Warn().Str(logger.OrderID, order.ID).Msg("Refunding duplicate payment")
+
+	// The order id makes retries safe, the provider refunds a payment once per key.
+	if order.Amount > 0 {
+		_, err := s.paymentProvider.Refund(order, order.Amount, "duplicate_"+order.ID)
+		if err != nil {
+			log.Err(err).Str(logger.OrderID, order.ID).Msg("Failed to refund duplicate payment")
+			return err
+		}
+	}
+
+	err := s.orderRepository.MarkRefunded(&order, order.Amount)
+	if err != nil {
+		return err
+	}
+
+	go s.mailService.Send(Mail{
+		To:      []string{user.Email},
+		Subject: "Trainings - Payment Refunded",
+		Body: fmt.Sprintf("You are already enrolled in <strong>%s</strong>, your payment of %s was refunded.",
+			html.EscapeString(training.Name), formatMoney(order.Amount, order.Currency)),
+	})
+
+	return nil
+}
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+)
+
+// The fakes embed the interfaces they stand in for, only the methods the webhook uses are implemented.
+
+type fakeOrderRepository struct {
+	repositories.IOrderRepository
+	order  models.Order
+	events map[string]bool
+}
+
+func (r *fakeOrderRepository) FindByProviderRef(provider, ref string) (models.Order, error) {
+	if r.order.Provider != provider || r.order.ProviderRef != ref {
+		return models.Order{}, errors.New("record not found")
+	}
+
+	return r.order, nil
+}
+
+func (r *fakeOrderRepository) Resolve(order *models.Order, eventID, status string) (bool, error) {
+	if r.events[eventID] {
+		*order = r.order
+		return false, nil
+	}
+	r.events[eventID] = true
+
+	changed := false
+	if (r.order.Status == models.PendingOrder || r.order.Status == models.FailedOrder) && r.order.Status != status {
+		r.order.Status = status
+		changed = true
+	}
+
+	*order = r.order
+
+	return changed, nil
+}
+
+func (r *fakeOrderRepository) MarkEnrolled(order *models.Order) (bool, error) {
+	if r.order.EnrolledAt != nil {
+		return false, nil
+	}
+
+	now := time.Now()
+	r.order.EnrolledAt = &now
+	order.EnrolledAt = &now
+
+	return true, nil
+}
+
+func (r *fakeOrderRepository) UnmarkEnrolled(order *models.Order) error {
+	r.order.EnrolledAt = nil
+	order.EnrolledAt = nil
+
+	return nil
+}
+
+func (r *fakeOrderRepository) MarkRefunded(order *models.Order, amount int) error {
+	if r.order.Status == models.PaidOrder {
+		r.order.Status = models.RefundedOrder
+		r.order.Refunded += amount
+	}
+
+	*order = r.order
+
+	return nil
+}
+
+type fakeTrainingRepository struct {
+	repositories.ITrainingRepository
+	training models.Training
+	members  map[string]bool
+}
+
+func (r *fakeTrainingRepository) FindByID(id string) (models.Training, error) {
+	return r.training, nil
+}
+
+func (r *fakeTrainingRepository) VerifyUserInTraining(trainingID, userID string) error {
+	if !r.members[userID] {
+		return errors.New("user is not in training")
+	}
+
+	return nil
+}
+
+func (r *fakeTrainingRepository) FindWaitlist(trainingID string) []models.WaitlistEntry {
+	return nil
+}
+
+type fakeUserRepository struct {
+	repositories.IUserRepository
+	user models.User
+}
+
+func (r *fakeUserRepository) FindByID(id string) (models.User, error) {
+	return r.user, nil
+}
+
+type fakeTrainingService struct {
+	ITrainingService
+	members  map[string]bool
+	failures int
+	added    int
+}
+
+func (s *fakeTrainingService) AddUser(trainingID, addUserID, userID string) (bool, error) {
+	if s.failures > 0 {
+		s.failures--
+		return false, errors.New("failed to add user")
+	}
+
+	s.added++
+	s.members[addUserID] = true
+
+	return false, nil
+}
+
+type fakeInvoiceService struct {
+	IInvoiceService
+	scheduled map[string]bool
+}
+
+func (s *fakeInvoiceService) Schedule(orderID string) error {
+	s.scheduled[orderID] = true
+	return nil
+}
+
+type fakeMailService struct{}
+
+func (fakeMailService) Send(mail Mail) {}
+
+func (fakeMailService) Deliver(mail Mail) error {
+	return nil
+}
+
+type refundingPaymentProvider struct {
+	*FakePaymentProvider
+	refunds []string
+}
+
+func (p *refundingPaymentProvider) Refund(order models.Order, amount int, key string) (string, error) {
+	p.refunds = append(p.refunds, key)
+	return p.FakePaymentProvider.Refund(order, amount, key)
+}
+
+func TestOrderServiceHandleWebhook(t *testing.T) {
+	type delivery struct {
+		event   string
+		kind    string
+		wantErr bool
+	}
+
+	tests := []struct {
+		name         string
+		member       bool
+		failures     int
+		deliveries   []delivery
+		wantStatus   string
+		wantAdded    int
+		wantRefunds  int
+		wantInvoiced bool
+	}{
+		{
+			name:         "paid",
+			deliveries:   []delivery{{event: "evt_1", kind: PaymentSucceeded}},
+			wantStatus:   models.PaidOrder,
+			wantAdded:    1,
+			wantInvoiced: true,
+		},
+		{
+			name: "replayed",
+			deliveries: []delivery{
+				{event: "evt_1", kind: PaymentSucceeded},
+				{event: "evt_1", kind: PaymentSucceeded},
+				{event: "evt_2", kind: PaymentSucceeded},
+			},
+			wantStatus:   models.PaidOrder,
+			wantAdded:    1,
+			wantInvoiced: true,
+		},
+		{
+			name:     "retried after failing to enroll",
+			failures: 1,
+			deliveries: []delivery{
+				{event: "evt_1", kind: PaymentSucceeded, wantErr: true},
+				{event: "evt_1", kind: PaymentSucceeded},
+				{event: "evt_1", kind: PaymentSucceeded},
+			},
+			wantStatus:   models.PaidOrder,
+			wantAdded:    1,
+			wantInvoiced: true,
+		},
+		{
+			name:       "failed",
+			deliveries: []delivery{{event: "evt_1", kind: PaymentFailed}},
+			wantStatus: models.FailedOrder,
+		},
+		{
+			name: "paid after failing",
+			deliveries: []delivery{
+				{event: "evt_1", kind: PaymentFailed},
+				{event: "evt_2", kind: PaymentSucceeded},
+			},
+			wantStatus:   models.PaidOrder,
+			wantAdded:    1,
+			wantInvoiced: true,
+		},
+		{
+			name: "failure after paying",
+			deliveries: []delivery{
+				{event: "evt_1", kind: PaymentSucceeded},
+				{event: "evt_2", kind: PaymentFailed},
+			},
+			wantStatus:   models.PaidOrder,
+			wantAdded:    1,
+			wantInvoiced: true,
+		},
+		{
+			name:   "already a member",
+			member: true,
+			deliveries: []delivery{
+				{event: "evt_1", kind: PaymentSucceeded},
+				{event: "evt_1", kind: PaymentSucceeded},
+			},
+			wantStatus:   models.RefundedOrder,
+			wantRefunds:  1,
+			wantInvoiced: true,
+		},
+		{
+			name:       "ignored event",
+			deliveries: []delivery{{event: "evt_1", kind: "disputed"}},
+			wantStatus: models.PendingOrder,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			user := models.User{Base: models.Base{ID: "user"}, Email: "user@example.com"}
+			training := models.Training{Base: models.Base{ID: "training"}, Name: "Yoga", OwnerID: "owner", Price: 1000}
+			members := map[string]bool{user.ID: test.member}
+
+			provider := &refundingPaymentProvider{FakePaymentProvider: &FakePaymentProvider{webhookSecret: []byte("secret")}}
+			orders := &fakeOrderRepository{
+				order: models.Order{
+					Base:        models.Base{ID: "order"},
+					TrainingID:  training.ID,
+					UserID:      user.ID,
+					Price:       1000,
+					Amount:      1000,
+					Currency:    "eur",
+					Status:      models.PendingOrder,
+					Provider:    provider.Name(),
+					ProviderRef: "fake_order",
+				},
+				events: map[string]bool{},
+			}
+			trainings := &fakeTrainingService{members: members, failures: test.failures}
+			invoices := &fakeInvoiceService{scheduled: map[string]bool{}}
+
+			service := &OrderService{
+				orderRepository:    orders,
+				trainingRepository: &fakeTrainingRepository{training: training, members: members},
+				userRepository:     &fakeUserRepository{user: user},
+				trainingService:    trainings,
+				invoiceService:     invoices,
+				mailService:        fakeMailService{},
+				paymentProvider:    provider,
+				currency:           "eur",
+			}
+
+			for i, delivery := range test.deliveries {
+				payload := []byte(fmt.Sprintf(`{"id":"%s","type":"%s","reference":"fake_order"}`, delivery.event, delivery.kind))
+				header := http.Header{}
+				header.Set(fakeSignatureHeader, provider.Sign(payload))
+
+				err := service.HandleWebhook(payload, header)
+				if (err != nil) != delivery.wantErr {
+					t.Fatalf("delivery %d: HandleWebhook() error = %v, want error %v", i, err, delivery.wantErr)
+				}
+			}
+
+			if orders.order.Status != test.wantStatus {
+				t.Errorf("order status = %s, want %s", orders.order.Status, test.wantStatus)
+			}
+
+			if trainings.added != test.wantAdded {
+				t.Errorf("buyer enrolled %d times, want %d", trainings.added, test.wantAdded)
+			}
+
+			if len(provider.refunds) != test.wantRefunds {
+				t.Errorf("payment refunded %d times, want %d", len(provider.refunds), test.wantRefunds)
+			}
+
+			if invoices.scheduled[orders.order.ID] != test.wantInvoiced {
+				t.Errorf("invoice scheduled = %v, want %v", invoices.scheduled[orders.order.ID], test.wantInvoiced)
+			}
+
+			wantEnrolled := test.wantAdded > 0 || test.wantRefunds > 0
+			if (orders.order.EnrolledAt != nil) != wantEnrolled {
+				t.Errorf("order enrolled = %v, want %v", orders.order.EnrolledAt != nil, wantEnrolled)
+			}
+		})
+	}
+}
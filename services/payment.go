@@ -0,0 +1,175 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	PaymentSucceeded = "succeeded"
+	PaymentFailed    = "failed"
+)
+
+// Checkout is a payment page opened with the provider for an order.
+type Checkout struct {
+	Reference string
+	Url       string
+}
+
+// PaymentEvent is a webhook event of the provider about a checkout.
+// Events the application doesn't care about have an empty Type.
+type PaymentEvent struct {
+	ID        string
+	Type      string
+	Reference string
+}
+
+// PaymentProvider charges the buyers of trainings.
+type PaymentProvider interface {
+	Name() string
+	CreateCheckout(order models.Order, training models.Training, user models.User) (Checkout, error)
+	// ParseWebhook verifies the signature of a webhook request and returns its event.
+	ParseWebhook(payload []byte, header http.Header) (PaymentEvent, error)
+	// Refund gives amount of a paid order back to the buyer, retrying with the same key refunds only once.
+	Refund(order models.Order, amount int, key string) (string, error)
+}
+
+var (
+	paymentOnce     sync.Once
+	paymentProvider PaymentProvider
+)
+
+// GetPaymentProvider returns the provider selected by PAYMENT_PROVIDER. Without one paid trainings can't be bought,
+// the fake provider has to be asked for explicitly since anyone knowing its secret can confirm payments.
+func GetPaymentProvider() PaymentProvider {
+	paymentOnce.Do(func() {
+		name := os.Getenv("PAYMENT_PROVIDER")
+		secret := []byte(os.Getenv("PAYMENT_WEBHOOK_SECRET"))
+		appUrl := strings.TrimSuffix(os.Getenv("APP_URL"), "/")
+
+		if name == "" {
+			log.Warn().Msg("No payment provider configured, paid trainings can't be bought")
+			paymentProvider = &DisabledPaymentProvider{}
+			return
+		}
+
+		if len(secret) == 0 {
+			log.Fatal().Str("provider", name).Msg("PAYMENT_WEBHOOK_SECRET is required")
+		}
+
+		log.Info().Str("provider", name).Msg("Initializing payment provider")
+
+		switch name {
+		case "stripe":
+			paymentProvider = &StripePaymentProvider{
+				apiUrl:        "https://api.stripe.com/v1",
+				apiKey:        os.Getenv("STRIPE_SECRET_KEY"),
+				webhookSecret: secret,
+				appUrl:        appUrl,
+				client:        http.DefaultClient,
+			}
+		case "fake":
+			paymentProvider = &FakePaymentProvider{
+				webhookSecret: secret,
+				appUrl:        appUrl,
+			}
+		default:
+			log.Fatal().Str("provider", name).Msg("Unknown payment provider")
+		}
+	})
+	return paymentProvider
+}
+
+const fakeSignatureHeader = "X-Payment-Signature"
+
+// FakePaymentProvider doesn't charge anything, payments are confirmed by
+// sending its webhook a JSON event signed with the webhook secret.
+type FakePaymentProvider struct {
+	webhookSecret []byte
+	appUrl        string
+}
+
+func (p *FakePaymentProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakePaymentProvider) CreateCheckout(order models.Order, training models.Training, user models.User) (Checkout, error) {
+	reference := "fake_" + order.ID
+
+	return Checkout{
+		Reference: reference,
+		Url:       fmt.Sprintf("%s/checkout/%s", p.appUrl, reference),
+	}, nil
+}
+
+func (p *FakePaymentProvider) ParseWebhook(payload []byte, header http.Header) (PaymentEvent, error) {
+	var event PaymentEvent
+
+	if !hmac.Equal([]byte(header.Get(fakeSignatureHeader)), []byte(p.Sign(payload))) {
+		return event, errors.New("invalid webhook signature")
+	}
+
+	var body struct {
+		ID        string `json:"id"`
+		Type      string `json:"type"`
+		Reference string `json:"reference"`
+	}
+
+	err := json.Unmarshal(payload, &body)
+	if err != nil {
+		return event, err
+	}
+
+	if body.ID == "" || body.Reference == "" {
+		return event, errors.New("invalid webhook event")
+	}
+
+	event = PaymentEvent{ID: body.ID, Reference: body.Reference}
+	if body.Type == PaymentSucceeded || body.Type == PaymentFailed {
+		event.Type = body.Type
+	}
+
+	return event, nil
+}
+
+func (p *FakePaymentProvider) Refund(order models.Order, amount int, key string) (string, error) {
+	return "fake_refund_" + key, nil
+}
+
+// Sign returns the signature the fake provider expects for a webhook payload.
+func (p *FakePaymentProvider) Sign(payload []byte) string {
+	mac := hmac.New(sha256.New, p.webhookSecret)
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// DisabledPaymentProvider is used when no provider is configured, it refuses every payment.
+type DisabledPaymentProvider struct{}
+
+func (p *DisabledPaymentProvider) Name() string {
+	return "none"
+}
+
+func (p *DisabledPaymentProvider) CreateCheckout(order models.Order, training models.Training, user models.User) (Checkout, error) {
+	return Checkout{}, errors.New("payments are not configured")
+}
+
+func (p *DisabledPaymentProvider) ParseWebhook(payload []byte, header http.Header) (PaymentEvent, error) {
+	return PaymentEvent{}, errors.New("payments are not configured")
+}
+
+func (p *DisabledPaymentProvider) Refund(order models.Order, amount int, key string) (string, error) {
+	return "", errors.New("payments are not configured")
+}
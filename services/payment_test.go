@@ -0,0 +1,100 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestFakePaymentProviderParseWebhook(t *testing.T) {
+	provider := &FakePaymentProvider{webhookSecret: []byte("secret")}
+	other := &FakePaymentProvider{webhookSecret: []byte("other")}
+
+	signed := func(signer *FakePaymentProvider, payload string) http.Header {
+		header := http.Header{}
+		header.Set(fakeSignatureHeader, signer.Sign([]byte(payload)))
+		return header
+	}
+
+	tests := []struct {
+		name    string
+		payload string
+		header  http.Header
+		want    PaymentEvent
+		wantErr bool
+	}{
+		{
+			name:    "succeeded",
+			payload: `{"id":"evt_1","type":"succeeded","reference":"fake_1"}`,
+			header:  signed(provider, `{"id":"evt_1","type":"succeeded","reference":"fake_1"}`),
+			want:    PaymentEvent{ID: "evt_1", Type: PaymentSucceeded, Reference: "fake_1"},
+		},
+		{
+			name:    "failed",
+			payload: `{"id":"evt_2","type":"failed","reference":"fake_1"}`,
+			header:  signed(provider, `{"id":"evt_2","type":"failed","reference":"fake_1"}`),
+			want:    PaymentEvent{ID: "evt_2", Type: PaymentFailed, Reference: "fake_1"},
+		},
+		{
+			name:    "unknown type is ignored",
+			payload: `{"id":"evt_3","type":"disputed","reference":"fake_1"}`,
+			header:  signed(provider, `{"id":"evt_3","type":"disputed","reference":"fake_1"}`),
+			want:    PaymentEvent{ID: "evt_3", Reference: "fake_1"},
+		},
+		{
+			name:    "missing signature",
+			payload: `{"id":"evt_1","type":"succeeded","reference":"fake_1"}`,
+			header:  http.Header{},
+			wantErr: true,
+		},
+		{
+			name:    "signed with another secret",
+			payload: `{"id":"evt_1","type":"succeeded","reference":"fake_1"}`,
+			header:  signed(other, `{"id":"evt_1","type":"succeeded","reference":"fake_1"}`),
+			wantErr: true,
+		},
+		{
+			name:    "signature of another payload",
+			payload: `{"id":"evt_1","type":"succeeded","reference":"fake_2"}`,
+			header:  signed(provider, `{"id":"evt_1","type":"succeeded","reference":"fake_1"}`),
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			payload: `{"id":`,
+			header:  signed(provider, `{"id":`),
+			wantErr: true,
+		},
+		{
+			name:    "missing id",
+			payload: `{"type":"succeeded","reference":"fake_1"}`,
+			header:  signed(provider, `{"type":"succeeded","reference":"fake_1"}`),
+			wantErr: true,
+		},
+		{
+			name:    "missing reference",
+			payload: `{"id":"evt_1","type":"succeeded"}`,
+			header:  signed(provider, `{"id":"evt_1","type":"succeeded"}`),
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			event, err := provider.ParseWebhook([]byte(test.payload), test.header)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("ParseWebhook() = %+v, want an error", event)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseWebhook() error = %v", err)
+			}
+
+			if event != test.want {
+				t.Errorf("ParseWebhook() = %+v, want %+v", event, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/go-pdf/fpdf"
+)
+
+// writeCertificatePdf draws a one page landscape certificate. The core fonts only cover
+// Latin-1, other characters are replaced when the text is translated.
+func writeCertificatePdf(fullName, trainingName, date, serial, verifyUrl string) ([]byte, error) {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetTitle("Certificate of Completion", true)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	width, height := pdf.GetPageSize()
+
+	pdf.SetDrawColor(40, 70, 120)
+	pdf.SetLineWidth(2)
+	pdf.Rect(10, 10, width-20, height-20, "D")
+	pdf.SetLineWidth(0.5)
+	pdf.Rect(15, 15, width-30, height-30, "D")
+
+	line := func(y float64, size float64, style, text string) {
+		pdf.SetFont("Helvetica", style, size)
+		pdf.SetXY(20, y)
+		pdf.CellFormat(width-40, size/2, tr(text), "", 0, "C", false, 0, "")
+	}
+
+	pdf.SetTextColor(40, 70, 120)
+	line(40, 36, "B", "Certificate of Completion")
+
+	pdf.SetTextColor(60, 60, 60)
+	line(70, 16, "", "This certifies that")
+
+	pdf.SetTextColor(0, 0, 0)
+	line(88, 30, "B", fullName)
+
+	pdf.SetTextColor(60, 60, 60)
+	line(112, 16, "", "has successfully completed the training")
+
+	pdf.SetTextColor(0, 0, 0)
+	line(128, 24, "B", trainingName)
+
+	pdf.SetTextColor(60, 60, 60)
+	line(150, 14, "", "Issued on "+date)
+
+	line(172, 10, "", "Certificate "+serial)
+	if verifyUrl != "" {
+		line(178, 10, "", "Verify at "+verifyUrl)
+	}
+
+	var buf bytes.Buffer
+	err := pdf.Output(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeInvoicePdf draws a one page portrait invoice for a single training.
+func writeInvoicePdf(invoice models.Invoice) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle("Invoice "+invoice.Number, true)
+	pdf.AddPage()
+
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	width, _ := pdf.GetPageSize()
+
+	pdf.SetFont("Helvetica", "B", 24)
+	pdf.SetTextColor(40, 70, 120)
+	pdf.CellFormat(0, 14, "Invoice", "", 1, "L", false, 0, "")
+
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFont("Helvetica", "", 11)
+	for _, text := range []string{
+		"Number: " + invoice.Number,
+		"Date: " + invoice.IssuedAt.Format("January 2, 2006"),
+		"Seller: " + invoice.SellerName,
+		"Billed to: " + invoice.BuyerName + " <" + invoice.BuyerEmail + ">",
+	} {
+		pdf.CellFormat(0, 7, tr(text), "", 1, "L", false, 0, "")
+	}
+
+	pdf.Ln(10)
+
+	row := func(style, label, amount string) {
+		pdf.SetFont("Helvetica", style, 11)
+		pdf.CellFormat(width-70, 9, tr(label), "B", 0, "L", false, 0, "")
+		pdf.CellFormat(50, 9, amount, "B", 1, "R", false, 0, "")
+	}
+
+	row("B", "Description", "Amount")
+	row("", "Training: "+invoice.TrainingName, formatMoney(invoice.Price, invoice.Currency))
+	if invoice.Discount > 0 {
+		row("", "Discount", "-"+formatMoney(invoice.Discount, invoice.Currency))
+	}
+	row("B", "Total paid", formatMoney(invoice.Amount, invoice.Currency))
+
+	var buf bytes.Buffer
+	err := pdf.Output(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// formatMoney formats an amount in cents with its currency, like 25.00 EUR.
+func formatMoney(cents int, currency string) string {
+	return formatCents(cents) + " " + strings.ToUpper(currency)
+}
+
+// formatCents formats an amount in cents without its currency, like 25.00.
+func formatCents(cents int) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+type IPointsService interface {
+	FindHistory(userID string, query dto.PointsHistoryQuery) (dto.Page[models.PointsEntry], error)
+	FindRules() []models.PointsRule
+	UpdateRule(event, userID string, dto dto.UpdatePointsRule) (models.PointsRule, error)
+	Award(userID, event string, trainingID *string, sourceID string)
+}
+
+type PointsService struct {
+	pointsRepository   repositories.IPointsRepository
+	userRepository     repositories.IUserRepository
+	leaderboardService ILeaderboardService
+}
+
+var (
+	pointsOnce    sync.Once
+	pointsService IPointsService
+)
+
+func GetPointsService() IPointsService {
+	pointsOnce.Do(func() {
+		log.Info().Msg("Initializing points service")
+		pointsService = &PointsService{
+			pointsRepository:   repositories.GetPointsRepository(),
+			userRepository:     repositories.GetUserRepository(),
+			leaderboardService: GetLeaderboardService(),
+		}
+	})
+	return pointsService
+}
+
+// FindHistory finds the ledger entries of the current user, admins can look at any user.
+func (s *PointsService) FindHistory(userID string, query dto.PointsHistoryQuery) (dto.Page[models.PointsEntry], error) {
+	log.Debug().Str(logger.UserID, userID).Msg("Finding points history")
+
+	if query.UserID != "" && query.UserID != userID {
+		err := s.verifyAdmin(userID)
+		if err != nil {
+			return dto.Page[models.PointsEntry]{}, err
+		}
+
+		userID = query.UserID
+	}
+
+	return s.pointsRepository.FindByUserID(userID, query.PaginationQuery)
+}
+
+func (s *PointsService) FindRules() []models.PointsRule {
+	log.Debug().Msg("Finding points rules")
+
+	return s.pointsRepository.FindRules()
+}
+
+func (s *PointsService) UpdateRule(event, userID string, dto dto.UpdatePointsRule) (models.PointsRule, error) {
+	log.Debug().Str("event", event).Str(logger.UserID, userID).Msg("Updating points rule")
+
+	err := s.verifyAdmin(userID)
+	if err != nil {
+		return models.PointsRule{}, err
+	}
+
+	rule, err := s.pointsRepository.FindRule(event)
+	if err != nil {
+		return rule, err
+	}
+
+	rule.Points = dto.Points
+	rule.DailyLimit = dto.DailyLimit
+
+	err = s.pointsRepository.UpdateRule(&rule)
+
+	return rule, err
+}
+
+// Award gives a user the points the rule of the event is worth for a source, once per source.
+// Failing to award points never fails the action that earned them, errors are only logged.
+func (s *PointsService) Award(userID, event string, trainingID *string, sourceID string) {
+	rule, err := s.pointsRepository.FindRule(event)
+	if err != nil || rule.Points <= 0 {
+		return
+	}
+
+	entry := models.PointsEntry{
+		UserID:     userID,
+		TrainingID: trainingID,
+		Delta:      rule.Points,
+		Reason:     event,
+		SourceID:   sourceID,
+	}
+
+	awarded, err := s.pointsRepository.Award(&entry, rule.DailyLimit)
+	if err != nil {
+		log.Err(err).Str(logger.UserID, userID).Str("event", event).Msg("Failed to award points")
+		return
+	}
+
+	if awarded {
+		log.Debug().Str(logger.UserID, userID).Str("event", event).Int("points", rule.Points).Msg("Awarded points")
+		s.leaderboardService.Add(entry)
+	}
+}
+
+func (s *PointsService) verifyAdmin(userID string) error {
+	user, err := s.userRepository.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.HasRole(models.AdminRole) {
+		return errors.New("user is not admin")
+	}
+
+	return nil
+}
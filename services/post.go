@@ -12,7 +12,7 @@ import (
 )
 
 type IPostService interface {
-	FindByTrainingID(trainingID, userID string) ([]models.Post, error)
+	FindByTrainingID(trainingID, userID string, params dto.PostQueryParams) (dto.Page[models.Post], error)
 	Create(trainingID, userID string, dto dto.CreatePost) (models.Post, error)
 	Update(postID, userID string, dto dto.UpdatePost) (models.Post, error)
 	Delete(postID, userID string) (models.Post, error)
@@ -22,6 +22,7 @@ type PostService struct {
 	postRepository     repositories.IPostRepository
 	trainingRepository repositories.ITrainingRepository
 	userRepository     repositories.IUserRepository
+	pointsService      IPointsService
 }
 
 var (
@@ -36,15 +37,16 @@ func GetPostService() IPostService {
 			postRepository:     repositories.GetPostRepository(),
 			trainingRepository: repositories.GetTrainingRepository(),
 			userRepository:     repositories.GetUserRepository(),
+			pointsService:      GetPointsService(),
 		}
 	})
 	return postService
 }
 
-func (s *PostService) FindByTrainingID(trainingID, userID string) ([]models.Post, error) {
+func (s *PostService) FindByTrainingID(trainingID, userID string, params dto.PostQueryParams) (dto.Page[models.Post], error) {
 	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Finding posts")
 
-	var posts []models.Post
+	var posts dto.Page[models.Post]
 
 	training, err := s.trainingRepository.FindByID(trainingID)
 	if err != nil {
@@ -61,9 +63,7 @@ func (s *PostService) FindByTrainingID(trainingID, userID string) ([]models.Post
 		return posts, err
 	}
 
-	posts = s.postRepository.FindByTrainingID(trainingID)
-
-	return posts, nil
+	return s.postRepository.FindByTrainingID(trainingID, params)
 }
 
 func (s *PostService) Create(trainingID, userID string, dto dto.CreatePost) (models.Post, error) {
@@ -96,6 +96,8 @@ func (s *PostService) Create(trainingID, userID string, dto dto.CreatePost) (mod
 		return post, err
 	}
 
+	s.pointsService.Award(userID, models.PostPoints, &post.TrainingID, post.ID)
+
 	return post, nil
 }
 
@@ -131,7 +133,10 @@ func (s *PostService) Delete(postID, userID string) (models.Post, error) {
 	}
 
 	if post.UserID != userID {
-		return post, errors.New("you are not allowed to delete this post")
+		member, err := s.trainingRepository.FindMember(post.TrainingID, userID)
+		if err != nil || !member.CanModerate() {
+			return post, errors.New("you are not allowed to delete this post")
+		}
 	}
 
 	post.Text = ""
@@ -147,7 +152,8 @@ func (s *PostService) Delete(postID, userID string) (models.Post, error) {
 func (s *PostService) verifyIfCanWrite(training models.Training, user models.User) error {
 	log.Debug().Str(logger.TrainingID, training.ID).Str(logger.UserID, user.ID).Msg("Verifying if user is authorized in training")
 
-	if training.OwnerID == user.ID {
+	member, err := s.trainingRepository.FindMember(training.ID, user.ID)
+	if err == nil && member.CanPost() {
 		return nil
 	}
 
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+type IProgressService interface {
+	FindOne(trainingID, userID string) (dto.TrainingProgress, error)
+	FindByTrainingID(trainingID, userID string) ([]dto.TrainingProgress, error)
+}
+
+type ProgressService struct {
+	lessonRepository   repositories.ILessonRepository
+	moduleRepository   repositories.IModuleRepository
+	trainingRepository repositories.ITrainingRepository
+	userRepository     repositories.IUserRepository
+}
+
+var (
+	progressOnce    sync.Once
+	progressService IProgressService
+)
+
+func GetProgressService() IProgressService {
+	progressOnce.Do(func() {
+		log.Info().Msg("Initializing progress service")
+		progressService = &ProgressService{
+			lessonRepository:   repositories.GetLessonRepository(),
+			moduleRepository:   repositories.GetModuleRepository(),
+			trainingRepository: repositories.GetTrainingRepository(),
+			userRepository:     repositories.GetUserRepository(),
+		}
+	})
+	return progressService
+}
+
+func (s *ProgressService) FindOne(trainingID, userID string) (dto.TrainingProgress, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Finding progress")
+
+	member, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err != nil {
+		return dto.TrainingProgress{}, errors.New("user is not in training")
+	}
+
+	member.User, err = s.userRepository.FindByID(userID)
+	if err != nil {
+		return dto.TrainingProgress{}, err
+	}
+
+	lessons := publishedLessons(s.moduleRepository.FindByTrainingID(trainingID))
+	progress := s.lessonRepository.FindProgress(trainingID, userID)
+
+	return trainingProgress(member, lessons, progress), nil
+}
+
+// FindByTrainingID returns the progress of every member, lesson by lesson, for staff to follow the training.
+func (s *ProgressService) FindByTrainingID(trainingID, userID string) ([]dto.TrainingProgress, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Msg("Finding training progress")
+
+	member, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err != nil || !member.CanModerate() {
+		return nil, errors.New("you are not allowed to see progress of this training")
+	}
+
+	lessons := publishedLessons(s.moduleRepository.FindByTrainingID(trainingID))
+
+	byUser := map[string][]models.LessonProgress{}
+	for _, progress := range s.lessonRepository.FindProgressByTrainingID(trainingID) {
+		byUser[progress.UserID] = append(byUser[progress.UserID], progress)
+	}
+
+	grid := []dto.TrainingProgress{}
+	for _, member := range s.trainingRepository.FindMembers(trainingID) {
+		grid = append(grid, trainingProgress(member, lessons, byUser[member.UserID]))
+	}
+
+	return grid, nil
+}
+
+func publishedLessons(modules []models.Module) []models.Lesson {
+	lessons := []models.Lesson{}
+	for _, module := range modules {
+		for _, lesson := range module.Lessons {
+			if lesson.Published {
+				lessons = append(lessons, lesson)
+			}
+		}
+	}
+
+	return lessons
+}
+
+// trainingProgress sums up the progress records of a member over the published lessons in curriculum order.
+// Records of lessons that were unpublished or deleted since are left out.
+func trainingProgress(member models.Member, lessons []models.Lesson, records []models.LessonProgress) dto.TrainingProgress {
+	byLesson := map[string]models.LessonProgress{}
+	for _, record := range records {
+		byLesson[record.LessonID] = record
+	}
+
+	progress := dto.TrainingProgress{
+		UserID:    member.UserID,
+		FirstName: member.User.FirstName,
+		LastName:  member.User.LastName,
+		Email:     member.User.Email,
+		Role:      member.Role,
+		Total:     len(lessons),
+		Lessons:   []dto.LessonProgress{},
+	}
+
+	for _, lesson := range lessons {
+		item := dto.LessonProgress{
+			LessonID: lesson.ID,
+			Title:    lesson.Title,
+		}
+
+		if record, ok := byLesson[lesson.ID]; ok {
+			startedAt, openedAt := record.StartedAt, record.OpenedAt
+			item.StartedAt = &startedAt
+			item.CompletedAt = record.CompletedAt
+			progress.Started++
+
+			if record.CompletedAt != nil {
+				progress.Completed++
+			}
+
+			if progress.LastOpenedAt == nil || openedAt.After(*progress.LastOpenedAt) {
+				lessonID := lesson.ID
+				progress.LastLessonID = &lessonID
+				progress.LastOpenedAt = &openedAt
+			}
+		}
+
+		progress.Lessons = append(progress.Lessons, item)
+	}
+
+	if progress.Total > 0 {
+		progress.Percent = math.Round(float64(progress.Completed)/float64(progress.Total)*1000) / 10
+	}
+
+	return progress
+}
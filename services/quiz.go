@@ -0,0 +1,659 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type IQuizService interface {
+	FindByTrainingID(trainingID, userID string) ([]models.Quiz, error)
+	FindOne(id, userID string) (models.Quiz, error)
+	Create(trainingID, userID string, dto dto.CreateQuiz) (models.Quiz, error)
+	Update(id, userID string, dto dto.UpdateQuiz) (models.Quiz, error)
+	Delete(id, userID string) error
+	Start(id, userID string) (models.QuizAttempt, error)
+	FindAttempt(id, attemptID, userID string) (models.QuizAttempt, error)
+	FindAttempts(id, userID string) ([]models.QuizAttempt, error)
+	Submit(id, attemptID, userID string, dto dto.SubmitAttempt) (models.QuizAttempt, error)
+	Results(id, userID string) (dto.QuizResults, error)
+}
+
+type QuizService struct {
+	quizRepository     repositories.IQuizRepository
+	lessonRepository   repositories.ILessonRepository
+	moduleRepository   repositories.IModuleRepository
+	trainingRepository repositories.ITrainingRepository
+	leaderboardService ILeaderboardService
+	random             *rand.Rand
+	mu                 sync.Mutex
+}
+
+var (
+	quizOnce    sync.Once
+	quizService IQuizService
+)
+
+func GetQuizService() IQuizService {
+	quizOnce.Do(func() {
+		log.Info().Msg("Initializing quiz service")
+		quizService = &QuizService{
+			quizRepository:     repositories.GetQuizRepository(),
+			lessonRepository:   repositories.GetLessonRepository(),
+			moduleRepository:   repositories.GetModuleRepository(),
+			trainingRepository: repositories.GetTrainingRepository(),
+			leaderboardService: GetLeaderboardService(),
+			random:             rand.New(rand.NewSource(time.Now().UnixNano())),
+		}
+	})
+	return quizService
+}
+
+// FindByTrainingID lists the quizzes of a training. Learners only get the published quizzes
+// of released lessons, without their questions.
+func (s *QuizService) FindByTrainingID(trainingID, userID string) ([]models.Quiz, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Msg("Finding quizzes")
+
+	member, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err != nil {
+		return nil, errors.New("user is not in training")
+	}
+
+	quizzes := s.quizRepository.FindByTrainingID(trainingID)
+	if member.CanPost() {
+		for i := range quizzes {
+			quizzes[i].QuestionCount = len(quizzes[i].Questions)
+		}
+		return quizzes, nil
+	}
+
+	locks := lessonLocks(s.lessonRepository, s.moduleRepository, trainingID, userID)
+
+	available := []models.Quiz{}
+	for _, quiz := range quizzes {
+		if !isQuizAvailable(quiz, locks) {
+			continue
+		}
+
+		quiz.QuestionCount = len(quiz.Questions)
+		quiz.Questions = []models.Question{}
+		available = append(available, quiz)
+	}
+
+	return available, nil
+}
+
+func (s *QuizService) FindOne(id, userID string) (models.Quiz, error) {
+	log.Debug().Str(logger.QuizID, id).Msg("Finding quiz")
+
+	quiz, member, err := s.findQuiz(id, userID)
+	if err != nil {
+		return quiz, err
+	}
+
+	quiz.QuestionCount = len(quiz.Questions)
+
+	// Learners get the questions when they start an attempt, so the time limit holds.
+	if !member.CanPost() {
+		quiz.Questions = []models.Question{}
+	}
+
+	return quiz, nil
+}
+
+func (s *QuizService) Create(trainingID, userID string, dto dto.CreateQuiz) (models.Quiz, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Creating quiz")
+
+	err := s.verifyCanPost(trainingID, userID)
+	if err != nil {
+		return models.Quiz{}, err
+	}
+
+	quiz := models.Quiz{TrainingID: trainingID}
+
+	err = s.fillQuiz(&quiz, dto)
+	if err != nil {
+		return quiz, err
+	}
+
+	err = s.quizRepository.Create(&quiz)
+	if err != nil {
+		return quiz, err
+	}
+
+	quiz.QuestionCount = len(quiz.Questions)
+
+	return quiz, nil
+}
+
+func (s *QuizService) Update(id, userID string, dto dto.UpdateQuiz) (models.Quiz, error) {
+	log.Debug().Str(logger.QuizID, id).Str(logger.UserID, userID).Msg("Updating quiz")
+
+	quiz, err := s.quizRepository.FindByID(id)
+	if err != nil {
+		return quiz, err
+	}
+
+	err = s.verifyCanPost(quiz.TrainingID, userID)
+	if err != nil {
+		return quiz, err
+	}
+
+	err = s.fillQuiz(&quiz, createQuiz(dto))
+	if err != nil {
+		return quiz, err
+	}
+
+	err = s.quizRepository.Update(&quiz)
+	if err != nil {
+		return quiz, err
+	}
+
+	quiz.QuestionCount = len(quiz.Questions)
+
+	return quiz, nil
+}
+
+func (s *QuizService) Delete(id, userID string) error {
+	log.Debug().Str(logger.QuizID, id).Str(logger.UserID, userID).Msg("Deleting quiz")
+
+	quiz, err := s.quizRepository.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.verifyCanPost(quiz.TrainingID, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.quizRepository.Delete(&quiz)
+}
+
+// Start returns the open attempt of the user, or starts a new one if there are attempts left.
+// Attempts whose time ran out are closed first, they count with the answers they never got.
+func (s *QuizService) Start(id, userID string) (models.QuizAttempt, error) {
+	log.Debug().Str(logger.QuizID, id).Str(logger.UserID, userID).Msg("Starting quiz attempt")
+
+	quiz, member, err := s.findQuiz(id, userID)
+	if err != nil {
+		return models.QuizAttempt{}, err
+	}
+
+	now := time.Now()
+	attempts := s.quizRepository.FindAttempts(quiz.ID, userID)
+
+	for _, attempt := range attempts {
+		if attempt.SubmittedAt != nil {
+			continue
+		}
+
+		if attempt.IsOpen(now) {
+			return withQuestions(attempt, quiz, false), nil
+		}
+
+		_, err = s.grade(quiz, member, attempt, dto.SubmitAttempt{})
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.QuizAttempt{}, err
+		}
+	}
+
+	if quiz.MaxAttempts > 0 && len(attempts) >= quiz.MaxAttempts {
+		return models.QuizAttempt{}, errors.New("no attempts left for this quiz")
+	}
+
+	order := []string{}
+	for _, question := range quiz.Questions {
+		order = append(order, question.ID)
+	}
+
+	if quiz.Shuffle {
+		s.mu.Lock()
+		s.random.Shuffle(len(order), func(i, j int) {
+			order[i], order[j] = order[j], order[i]
+		})
+		s.mu.Unlock()
+	}
+
+	attempt := models.QuizAttempt{
+		QuizID:  quiz.ID,
+		UserID:  userID,
+		Order:   order,
+		Answers: models.List[models.Answer]{},
+	}
+
+	if quiz.TimeLimit > 0 {
+		deadline := now.Add(time.Duration(quiz.TimeLimit) * time.Minute)
+		attempt.DeadlineAt = &deadline
+	}
+
+	err = s.quizRepository.CreateAttempt(&attempt)
+	if err != nil {
+		return attempt, err
+	}
+
+	return withQuestions(attempt, quiz, false), nil
+}
+
+func (s *QuizService) FindAttempt(id, attemptID, userID string) (models.QuizAttempt, error) {
+	log.Debug().Str(logger.QuizID, id).Str(logger.UserID, userID).Msg("Finding quiz attempt")
+
+	quiz, _, err := s.findQuiz(id, userID)
+	if err != nil {
+		return models.QuizAttempt{}, err
+	}
+
+	attempt, err := s.quizRepository.FindAttemptByID(attemptID)
+	if err != nil || attempt.QuizID != quiz.ID || attempt.UserID != userID {
+		return models.QuizAttempt{}, errors.New("attempt not found")
+	}
+
+	return withQuestions(attempt, quiz, attempt.SubmittedAt != nil), nil
+}
+
+func (s *QuizService) FindAttempts(id, userID string) ([]models.QuizAttempt, error) {
+	log.Debug().Str(logger.QuizID, id).Str(logger.UserID, userID).Msg("Finding quiz attempts")
+
+	quiz, _, err := s.findQuiz(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.quizRepository.FindAttempts(quiz.ID, userID), nil
+}
+
+// Submit grades the answers of an attempt and reveals the answer key. Answers that arrive
+// after the time limit are not graded, the attempt is closed as expired instead.
+func (s *QuizService) Submit(id, attemptID, userID string, dto dto.SubmitAttempt) (models.QuizAttempt, error) {
+	log.Debug().Str(logger.QuizID, id).Str(logger.UserID, userID).Msg("Submitting quiz attempt")
+
+	quiz, member, err := s.findQuiz(id, userID)
+	if err != nil {
+		return models.QuizAttempt{}, err
+	}
+
+	attempt, err := s.quizRepository.FindAttemptByID(attemptID)
+	if err != nil || attempt.QuizID != quiz.ID || attempt.UserID != userID {
+		return models.QuizAttempt{}, errors.New("attempt not found")
+	}
+
+	if attempt.SubmittedAt != nil {
+		return models.QuizAttempt{}, errors.New("attempt was already submitted")
+	}
+
+	attempt, err = s.grade(quiz, member, attempt, dto)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.QuizAttempt{}, errors.New("attempt was already submitted")
+	}
+	if err != nil {
+		return attempt, err
+	}
+
+	return withQuestions(attempt, quiz, true), nil
+}
+
+// Results sums up the submitted attempts of the learners, staff attempts are previews and left out.
+func (s *QuizService) Results(id, userID string) (dto.QuizResults, error) {
+	log.Debug().Str(logger.QuizID, id).Str(logger.UserID, userID).Msg("Finding quiz results")
+
+	quiz, err := s.quizRepository.FindByID(id)
+	if err != nil {
+		return dto.QuizResults{}, err
+	}
+
+	member, err := s.trainingRepository.FindMember(quiz.TrainingID, userID)
+	if err != nil || !member.CanModerate() {
+		return dto.QuizResults{}, errors.New("you are not allowed to see results of this quiz")
+	}
+
+	byUser := map[string][]models.QuizAttempt{}
+	for _, attempt := range s.quizRepository.FindAttemptsByQuizID(quiz.ID) {
+		if attempt.SubmittedAt != nil {
+			byUser[attempt.UserID] = append(byUser[attempt.UserID], attempt)
+		}
+	}
+
+	questions := map[string]*dto.QuizQuestionResult{}
+	results := dto.QuizResults{
+		QuizID:    quiz.ID,
+		Members:   []dto.QuizMemberResult{},
+		Questions: []dto.QuizQuestionResult{},
+	}
+
+	for _, question := range quiz.Questions {
+		questions[question.ID] = &dto.QuizQuestionResult{
+			QuestionID: question.ID,
+			Text:       question.Text,
+		}
+	}
+
+	total, passed := 0.0, 0
+	for _, member := range s.trainingRepository.FindMembers(quiz.TrainingID) {
+		if member.CanPost() {
+			continue
+		}
+
+		result := dto.QuizMemberResult{
+			UserID:    member.UserID,
+			FirstName: member.User.FirstName,
+			LastName:  member.User.LastName,
+			Email:     member.User.Email,
+		}
+
+		for _, attempt := range byUser[member.UserID] {
+			result.Attempts++
+			result.BestPercent = math.Max(result.BestPercent, attempt.Percent)
+			result.Passed = result.Passed || attempt.Passed
+			result.LastSubmittedAt = attempt.SubmittedAt
+
+			results.Attempts++
+			total += attempt.Percent
+			if attempt.Passed {
+				passed++
+			}
+
+			for _, answer := range attempt.Answers {
+				if question, ok := questions[answer.QuestionID]; ok && answer.IsGiven() {
+					question.Answered++
+					if answer.Correct {
+						question.Correct++
+					}
+				}
+			}
+		}
+
+		results.Members = append(results.Members, result)
+	}
+
+	if results.Attempts > 0 {
+		results.AveragePercent = roundPercent(total / float64(results.Attempts))
+		results.PassRate = roundPercent(float64(passed) / float64(results.Attempts) * 100)
+	}
+
+	for _, question := range quiz.Questions {
+		result := questions[question.ID]
+		if result.Answered > 0 {
+			result.Rate = roundPercent(float64(result.Correct) / float64(result.Answered) * 100)
+		}
+		results.Questions = append(results.Questions, *result)
+	}
+
+	return results, nil
+}
+
+// findQuiz returns a quiz the user can take along with their membership,
+// learners can only take published quizzes of released lessons.
+func (s *QuizService) findQuiz(id, userID string) (models.Quiz, models.Member, error) {
+	quiz, err := s.quizRepository.FindByID(id)
+	if err != nil {
+		return quiz, models.Member{}, err
+	}
+
+	member, err := s.trainingRepository.FindMember(quiz.TrainingID, userID)
+	if err != nil {
+		return models.Quiz{}, member, errors.New("user is not in training")
+	}
+
+	if member.CanPost() {
+		return quiz, member, nil
+	}
+
+	if !isQuizAvailable(quiz, lessonLocks(s.lessonRepository, s.moduleRepository, quiz.TrainingID, userID)) {
+		return models.Quiz{}, member, errors.New("quiz not found")
+	}
+
+	return quiz, member, nil
+}
+
+// grade scores the answers against the questions the attempt was started with and submits it.
+// Learners are awarded the points of the quiz the first time they pass it.
+func (s *QuizService) grade(quiz models.Quiz, member models.Member, attempt models.QuizAttempt, dto dto.SubmitAttempt) (models.QuizAttempt, error) {
+	now := time.Now()
+
+	answers := map[string]models.Answer{}
+	if attempt.IsOpen(now) {
+		for _, answer := range dto.Answers {
+			answers[answer.QuestionID] = models.Answer{
+				QuestionID: answer.QuestionID,
+				Choices:    answer.Choices,
+				Text:       answer.Text,
+				Number:     answer.Number,
+			}
+		}
+	} else {
+		attempt.Expired = true
+	}
+
+	questions := map[string]models.Question{}
+	for _, question := range quiz.Questions {
+		questions[question.ID] = question
+	}
+
+	attempt.Answers = models.List[models.Answer]{}
+	attempt.Score, attempt.MaxScore = 0, 0
+
+	// Questions replaced since the attempt started can't be graded and are left out.
+	for _, questionID := range attempt.Order {
+		question, ok := questions[questionID]
+		if !ok {
+			continue
+		}
+
+		answer := answers[questionID]
+		answer.QuestionID = questionID
+		answer.Correct = question.Grade(answer)
+		if answer.Correct {
+			answer.Points = question.Points
+		}
+
+		attempt.Answers = append(attempt.Answers, answer)
+		attempt.Score += answer.Points
+		attempt.MaxScore += question.Points
+	}
+
+	attempt.Percent = 0
+	if attempt.MaxScore > 0 {
+		attempt.Percent = roundPercent(attempt.Score / attempt.MaxScore * 100)
+	}
+	attempt.Passed = attempt.Percent >= quiz.PassPercent
+	attempt.SubmittedAt = &now
+
+	var award *models.PointsEntry
+	if quiz.Points > 0 && !member.CanPost() {
+		award = &models.PointsEntry{
+			UserID:     attempt.UserID,
+			TrainingID: &quiz.TrainingID,
+			Delta:      quiz.Points,
+			Reason:     models.QuizPoints,
+			SourceID:   quiz.ID,
+		}
+	}
+
+	awarded, err := s.quizRepository.SubmitAttempt(&attempt, award)
+	if err != nil {
+		return attempt, err
+	}
+
+	if awarded {
+		log.Info().Str(logger.QuizID, quiz.ID).Str(logger.UserID, attempt.UserID).Int("points", quiz.Points).Msg("Awarded quiz points")
+		s.leaderboardService.Add(*award)
+	}
+
+	return attempt, nil
+}
+
+func (s *QuizService) verifyCanPost(trainingID, userID string) error {
+	member, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err != nil || !member.CanPost() {
+		return errors.New("you are not allowed to edit the content of this training")
+	}
+
+	return nil
+}
+
+func (s *QuizService) fillQuiz(quiz *models.Quiz, dto dto.CreateQuiz) error {
+	if dto.LessonID != nil && *dto.LessonID == "" {
+		dto.LessonID = nil
+	}
+
+	if dto.LessonID != nil {
+		lesson, err := s.lessonRepository.FindByID(*dto.LessonID)
+		if err != nil || lesson.TrainingID != quiz.TrainingID {
+			return errors.New("lesson is not part of this training")
+		}
+	}
+
+	// Kept questions keep their IDs, so answers of attempts in progress still match them.
+	existing := map[string]models.Question{}
+	for _, question := range quiz.Questions {
+		existing[question.ID] = question
+	}
+
+	questions := []models.Question{}
+	for i, item := range dto.Questions {
+		question, err := createQuestion(item)
+		if err != nil {
+			return fmt.Errorf("question %d: %w", i+1, err)
+		}
+
+		if kept, ok := existing[item.ID]; ok {
+			question.Base = kept.Base
+			delete(existing, item.ID)
+		}
+
+		question.QuizID = quiz.ID
+		question.Position = i + 1
+		questions = append(questions, question)
+	}
+
+	quiz.LessonID = dto.LessonID
+	quiz.Title = dto.Title
+	quiz.Description = dto.Description
+	quiz.TimeLimit = dto.TimeLimit
+	quiz.MaxAttempts = dto.MaxAttempts
+	quiz.Shuffle = dto.Shuffle
+	quiz.PassPercent = dto.PassPercent
+	quiz.Points = dto.Points
+	quiz.Published = dto.Published
+	quiz.Questions = questions
+
+	return nil
+}
+
+func createQuiz(update dto.UpdateQuiz) dto.CreateQuiz {
+	return dto.CreateQuiz(update)
+}
+
+// createQuestion checks that the answer key fits the question type.
+func createQuestion(dto dto.CreateQuestion) (models.Question, error) {
+	question := models.Question{
+		Type:   dto.Type,
+		Text:   dto.Text,
+		Points: dto.Points,
+	}
+
+	if question.Points == 0 {
+		question.Points = 1
+	}
+
+	switch dto.Type {
+	case models.TrueFalseQuestion:
+		dto.Options = []string{"True", "False"}
+		fallthrough
+	case models.SingleChoiceQuestion, models.MultipleChoiceQuestion:
+		if len(dto.Options) < 2 {
+			return question, errors.New("choice questions need at least two options")
+		}
+
+		correct := map[int]bool{}
+		for _, i := range dto.Correct {
+			if i < 0 || i >= len(dto.Options) || correct[i] {
+				return question, errors.New("correct options have to be distinct option indexes")
+			}
+			correct[i] = true
+		}
+
+		if len(correct) == 0 || (dto.Type != models.MultipleChoiceQuestion && len(correct) != 1) {
+			return question, errors.New("wrong number of correct options")
+		}
+
+		question.Options = dto.Options
+		question.Correct = dto.Correct
+	case models.ShortTextQuestion:
+		accepted := []string{}
+		for _, answer := range dto.Accepted {
+			if normalized := strings.TrimSpace(answer); normalized != "" {
+				accepted = append(accepted, normalized)
+			}
+		}
+
+		if len(accepted) == 0 {
+			return question, errors.New("short text questions need accepted answers")
+		}
+
+		question.Accepted = accepted
+	case models.NumericQuestion:
+		if dto.Answer == nil {
+			return question, errors.New("numeric questions need an answer")
+		}
+
+		question.Answer = dto.Answer
+		question.Tolerance = dto.Tolerance
+	}
+
+	return question, nil
+}
+
+func isQuizAvailable(quiz models.Quiz, locks map[string]bool) bool {
+	if !quiz.Published {
+		return false
+	}
+
+	if quiz.LessonID == nil {
+		return true
+	}
+
+	locked, ok := locks[*quiz.LessonID]
+
+	return ok && !locked
+}
+
+// withQuestions adds the questions to the attempt in the order they were shown,
+// the answer key is only revealed once the attempt is submitted.
+func withQuestions(attempt models.QuizAttempt, quiz models.Quiz, reveal bool) models.QuizAttempt {
+	questions := map[string]models.Question{}
+	for _, question := range quiz.Questions {
+		questions[question.ID] = question
+	}
+
+	attempt.Questions = []models.Question{}
+	for _, questionID := range attempt.Order {
+		question, ok := questions[questionID]
+		if !ok {
+			continue
+		}
+
+		if !reveal {
+			question.HideAnswers()
+		}
+
+		attempt.Questions = append(attempt.Questions, question)
+	}
+
+	return attempt
+}
+
+func roundPercent(percent float64) float64 {
+	return math.Round(percent*10) / 10
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+)
+
+type fakeQuizRepository struct {
+	repositories.IQuizRepository
+	awards []models.PointsEntry
+}
+
+func (r *fakeQuizRepository) SubmitAttempt(attempt *models.QuizAttempt, award *models.PointsEntry) (bool, error) {
+	if !attempt.Passed || award == nil {
+		return false, nil
+	}
+
+	r.awards = append(r.awards, *award)
+
+	return true, nil
+}
+
+type fakeLeaderboardService struct {
+	ILeaderboardService
+	added []models.PointsEntry
+}
+
+func (s *fakeLeaderboardService) Add(entry models.PointsEntry) {
+	s.added = append(s.added, entry)
+}
+
+func TestQuizServiceGrade(t *testing.T) {
+	number := func(n float64) *float64 {
+		return &n
+	}
+
+	quiz := models.Quiz{
+		Base:        models.Base{ID: "quiz"},
+		TrainingID:  "training",
+		PassPercent: 60,
+		Points:      10,
+		Questions: []models.Question{
+			{Base: models.Base{ID: "single"}, Type: models.SingleChoiceQuestion, Points: 1, Correct: models.List[int]{2}},
+			{Base: models.Base{ID: "text"}, Type: models.ShortTextQuestion, Points: 2, Accepted: models.List[string]{"santa"}},
+			{Base: models.Base{ID: "numeric"}, Type: models.NumericQuestion, Points: 3, Answer: number(24)},
+		},
+	}
+
+	order := models.List[string]{"single", "text", "numeric"}
+	right := []dto.AttemptAnswer{
+		{QuestionID: "single", Choices: []int{2}},
+		{QuestionID: "text", Text: "Santa"},
+		{QuestionID: "numeric", Number: number(24)},
+	}
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name        string
+		role        string
+		order       models.List[string]
+		deadline    *time.Time
+		answers     []dto.AttemptAnswer
+		wantScore   float64
+		wantMax     float64
+		wantPercent float64
+		wantPassed  bool
+		wantExpired bool
+		wantAnswers int
+		wantAward   bool
+	}{
+		{
+			name:        "all right",
+			role:        models.StudentMember,
+			order:       order,
+			answers:     right,
+			wantScore:   6,
+			wantMax:     6,
+			wantAnswers: 3,
+			wantPercent: 100,
+			wantPassed:  true,
+			wantAward:   true,
+		},
+		{
+			name:  "partly right",
+			role:  models.StudentMember,
+			order: order,
+			answers: []dto.AttemptAnswer{
+				{QuestionID: "single", Choices: []int{1}},
+				{QuestionID: "text", Text: "santa"},
+				{QuestionID: "numeric", Number: number(25)},
+			},
+			wantScore:   2,
+			wantMax:     6,
+			wantAnswers: 3,
+			wantPercent: 33.3,
+		},
+		{
+			name:  "passed exactly",
+			role:  models.StudentMember,
+			order: order,
+			answers: []dto.AttemptAnswer{
+				{QuestionID: "single", Choices: []int{2}},
+				{QuestionID: "text", Text: "grinch"},
+				{QuestionID: "numeric", Number: number(24)},
+			},
+			wantScore:   4,
+			wantMax:     6,
+			wantAnswers: 3,
+			wantPercent: 66.7,
+			wantPassed:  true,
+			wantAward:   true,
+		},
+		{
+			name:        "unanswered",
+			role:        models.StudentMember,
+			order:       order,
+			wantMax:     6,
+			wantAnswers: 3,
+			wantPercent: 0,
+		},
+		{
+			name:        "answers of other questions are ignored",
+			role:        models.StudentMember,
+			order:       models.List[string]{"single", "removed"},
+			answers:     append(right, dto.AttemptAnswer{QuestionID: "removed", Choices: []int{0}}),
+			wantScore:   1,
+			wantMax:     1,
+			wantAnswers: 1,
+			wantPercent: 100,
+			wantPassed:  true,
+			wantAward:   true,
+		},
+		{
+			name:        "expired",
+			role:        models.StudentMember,
+			order:       order,
+			deadline:    &past,
+			answers:     right,
+			wantMax:     6,
+			wantAnswers: 3,
+			wantExpired: true,
+		},
+		{
+			name:        "staff aren't awarded",
+			role:        models.InstructorMember,
+			order:       order,
+			answers:     right,
+			wantScore:   6,
+			wantMax:     6,
+			wantAnswers: 3,
+			wantPercent: 100,
+			wantPassed:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			quizzes := &fakeQuizRepository{}
+			leaderboard := &fakeLeaderboardService{}
+			service := &QuizService{quizRepository: quizzes, leaderboardService: leaderboard}
+
+			member := models.Member{UserID: "user", Role: test.role}
+			attempt := models.QuizAttempt{QuizID: quiz.ID, UserID: "user", Order: test.order, DeadlineAt: test.deadline}
+
+			attempt, err := service.grade(quiz, member, attempt, dto.SubmitAttempt{Answers: test.answers})
+			if err != nil {
+				t.Fatalf("grade() error = %v", err)
+			}
+
+			if attempt.Score != test.wantScore || attempt.MaxScore != test.wantMax {
+				t.Errorf("score = %v/%v, want %v/%v", attempt.Score, attempt.MaxScore, test.wantScore, test.wantMax)
+			}
+
+			if attempt.Percent != test.wantPercent {
+				t.Errorf("percent = %v, want %v", attempt.Percent, test.wantPercent)
+			}
+
+			if attempt.Passed != test.wantPassed {
+				t.Errorf("passed = %v, want %v", attempt.Passed, test.wantPassed)
+			}
+
+			if attempt.Expired != test.wantExpired {
+				t.Errorf("expired = %v, want %v", attempt.Expired, test.wantExpired)
+			}
+
+			if attempt.SubmittedAt == nil {
+				t.Error("attempt wasn't submitted")
+			}
+
+			if len(attempt.Answers) != test.wantAnswers {
+				t.Errorf("%d answers graded, want %d", len(attempt.Answers), test.wantAnswers)
+			}
+
+			if (len(leaderboard.added) == 1) != test.wantAward || len(quizzes.awards) != len(leaderboard.added) {
+				t.Errorf("awarded %d times, want award %v", len(leaderboard.added), test.wantAward)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+type IRefundService interface {
+	Request(orderID, userID string, dto dto.CreateRefund) (models.Refund, error)
+	FindByTrainingID(trainingID, userID string, params dto.RefundQueryParams) ([]models.Refund, error)
+	FindByUserID(userID string) []models.Refund
+	FindOne(id, userID string) (models.Refund, error)
+	Approve(id, userID string) (models.Refund, error)
+	Reject(id, userID string, dto dto.RejectRefund) (models.Refund, error)
+}
+
+type RefundService struct {
+	refundRepository   repositories.IRefundRepository
+	orderRepository    repositories.IOrderRepository
+	trainingRepository repositories.ITrainingRepository
+	sessionRepository  repositories.ISessionRepository
+	userRepository     repositories.IUserRepository
+	trainingService    ITrainingService
+	mailService        IMailService
+	paymentProvider    PaymentProvider
+}
+
+var (
+	refundOnce    sync.Once
+	refundService IRefundService
+)
+
+func GetRefundService() IRefundService {
+	refundOnce.Do(func() {
+		log.Info().Msg("Initializing refund service")
+		refundService = &RefundService{
+			refundRepository:   repositories.GetRefundRepository(),
+			orderRepository:    repositories.GetOrderRepository(),
+			trainingRepository: repositories.GetTrainingRepository(),
+			sessionRepository:  repositories.GetSessionRepository(),
+			userRepository:     repositories.GetUserRepository(),
+			trainingService:    GetTrainingService(),
+			mailService:        GetMailService(),
+			paymentProvider:    GetPaymentProvider(),
+		}
+	})
+	return refundService
+}
+
+// Request asks for the refund the policy of the training allows right now.
+func (s *RefundService) Request(orderID, userID string, dto dto.CreateRefund) (models.Refund, error) {
+	log.Debug().Str(logger.OrderID, orderID).Str(logger.UserID, userID).Msg("Requesting refund")
+
+	order, err := s.orderRepository.FindByID(orderID)
+	if err != nil || order.UserID != userID {
+		return models.Refund{}, errors.New("order not found")
+	}
+
+	if order.Status != models.PaidOrder || order.PaidAt == nil {
+		return models.Refund{}, errors.New("only paid orders can be refunded")
+	}
+
+	_, err = s.refundRepository.FindPending(orderID)
+	if err == nil {
+		return models.Refund{}, errors.New("refund request already pending")
+	}
+
+	amount := order.Training.RefundAmount(order.Amount, *order.PaidAt, s.firstSession(order.TrainingID), time.Now())
+	if amount <= 0 {
+		return models.Refund{}, errors.New("the refund policy of this training doesn't allow a refund anymore")
+	}
+
+	refund := models.Refund{
+		OrderID:    order.ID,
+		TrainingID: order.TrainingID,
+		UserID:     userID,
+		Amount:     amount,
+		Reason:     dto.Reason,
+		Status:     models.PendingRefund,
+	}
+
+	err = s.refundRepository.Create(&refund)
+	if err != nil {
+		return refund, err
+	}
+
+	user, err := s.userRepository.FindByID(userID)
+	if err != nil {
+		return refund, err
+	}
+
+	owner, err := s.userRepository.FindByID(order.Training.OwnerID)
+	if err == nil {
+		go s.mailService.Send(Mail{
+			To:      []string{owner.Email},
+			Subject: "Trainings - Refund Request",
+			Body: fmt.Sprintf("<strong>%s</strong> asked for a refund of %s for <strong>%s</strong>.<br>%s",
+				html.EscapeString(fullName(user)), formatMoney(amount, order.Currency),
+				html.EscapeString(order.Training.Name), html.EscapeString(refund.Reason)),
+		})
+	}
+
+	refund.Order = order
+
+	return refund, nil
+}
+
+func (s *RefundService) FindByTrainingID(trainingID, userID string, params dto.RefundQueryParams) ([]models.Refund, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Finding refunds")
+
+	err := s.verifyCanManage(trainingID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.refundRepository.FindByTrainingID(trainingID, params.Status), nil
+}
+
+func (s *RefundService) FindByUserID(userID string) []models.Refund {
+	log.Debug().Str(logger.UserID, userID).Msg("Finding user refunds")
+
+	return s.refundRepository.FindByUserID(userID)
+}
+
+func (s *RefundService) FindOne(id, userID string) (models.Refund, error) {
+	log.Debug().Str(logger.RefundID, id).Str(logger.UserID, userID).Msg("Finding refund")
+
+	refund, err := s.refundRepository.FindByID(id)
+	if err != nil {
+		return refund, err
+	}
+
+	if refund.UserID != userID && s.verifyCanManage(refund.TrainingID, userID) != nil {
+		return models.Refund{}, errors.New("refund not found")
+	}
+
+	return refund, nil
+}
+
+// Approve refunds the order through the payment provider and removes the buyer from the training.
+func (s *RefundService) Approve(id, userID string) (models.Refund, error) {
+	log.Debug().Str(logger.RefundID, id).Str(logger.UserID, userID).Msg("Approving refund")
+
+	refund, err := s.refundRepository.FindByID(id)
+	if err != nil {
+		return refund, err
+	}
+
+	err = s.verifyCanManage(refund.TrainingID, userID)
+	if err != nil {
+		return refund, err
+	}
+
+	// The order may have been refunded another way since the request, e.g. as a duplicate payment.
+	if refund.Order.Status != models.PaidOrder {
+		return refund, errors.New("order is not paid anymore")
+	}
+
+	decided, err := s.refundRepository.Decide(&refund, models.PendingRefund, models.ApprovedRefund, &userID, "")
+	if err != nil {
+		return refund, err
+	}
+
+	if !decided {
+		return refund, errors.New("refund was already decided")
+	}
+
+	// The refund id makes retries safe, the provider refunds a payment once per key.
+	providerRef, err := s.paymentProvider.Refund(refund.Order, refund.Amount, refund.ID)
+	if err != nil {
+		log.Err(err).Str(logger.RefundID, refund.ID).Msg("Failed to refund payment")
+
+		s.refundRepository.Decide(&refund, models.ApprovedRefund, models.PendingRefund, nil, "")
+
+		return refund, errors.New("failed to refund payment")
+	}
+
+	err = s.refundRepository.Complete(&refund, providerRef)
+	if err != nil {
+		return refund, err
+	}
+
+	err = s.trainingService.RemoveUser(refund.TrainingID, refund.UserID, userID)
+	if err != nil {
+		log.Warn().Err(err).Str(logger.RefundID, refund.ID).Msg("Refunded user was not removed from training")
+	}
+
+	training := refund.Order.Training
+	amount := formatMoney(refund.Amount, refund.Order.Currency)
+
+	go s.mailService.Send(Mail{
+		To:      []string{refund.User.Email},
+		Subject: "Trainings - Refund Approved",
+		Body: fmt.Sprintf("Your refund of %s for <strong>%s</strong> was approved, you are no longer enrolled in the training.",
+			amount, html.EscapeString(training.Name)),
+	})
+
+	owner, err := s.userRepository.FindByID(training.OwnerID)
+	if err == nil {
+		go s.mailService.Send(Mail{
+			To:      []string{owner.Email},
+			Subject: "Trainings - Refund Completed",
+			Body: fmt.Sprintf("%s were refunded to <strong>%s</strong> for <strong>%s</strong>.",
+				amount, html.EscapeString(fullName(refund.User)), html.EscapeString(training.Name)),
+		})
+	}
+
+	return s.refundRepository.FindByID(id)
+}
+
+func (s *RefundService) Reject(id, userID string, dto dto.RejectRefund) (models.Refund, error) {
+	log.Debug().Str(logger.RefundID, id).Str(logger.UserID, userID).Msg("Rejecting refund")
+
+	refund, err := s.refundRepository.FindByID(id)
+	if err != nil {
+		return refund, err
+	}
+
+	err = s.verifyCanManage(refund.TrainingID, userID)
+	if err != nil {
+		return refund, err
+	}
+
+	decided, err := s.refundRepository.Decide(&refund, models.PendingRefund, models.RejectedRefund, &userID, dto.Reason)
+	if err != nil {
+		return refund, err
+	}
+
+	if !decided {
+		return refund, errors.New("refund was already decided")
+	}
+
+	go s.mailService.Send(Mail{
+		To:      []string{refund.User.Email},
+		Subject: "Trainings - Refund Rejected",
+		Body: fmt.Sprintf("Your refund request for <strong>%s</strong> was rejected.<br>%s",
+			html.EscapeString(refund.Order.Training.Name), html.EscapeString(dto.Reason)),
+	})
+
+	owner, err := s.userRepository.FindByID(refund.Order.Training.OwnerID)
+	if err == nil {
+		go s.mailService.Send(Mail{
+			To:      []string{owner.Email},
+			Subject: "Trainings - Refund Rejected",
+			Body: fmt.Sprintf("The refund request of <strong>%s</strong> for <strong>%s</strong> was rejected.<br>%s",
+				html.EscapeString(fullName(refund.User)), html.EscapeString(refund.Order.Training.Name),
+				html.EscapeString(dto.Reason)),
+		})
+	}
+
+	return refund, nil
+}
+
+// firstSession returns when the first session of a training starts, nil when it has none.
+func (s *RefundService) firstSession(trainingID string) *time.Time {
+	var first *time.Time
+
+	for _, session := range s.sessionRepository.FindByTrainingID(trainingID) {
+		if first == nil || session.StartsAt.Before(*first) {
+			startsAt := session.StartsAt
+			first = &startsAt
+		}
+	}
+
+	return first
+}
+
+func (s *RefundService) verifyCanManage(trainingID, userID string) error {
+	member, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err != nil || !member.CanManage() {
+		return errors.New("you are not allowed to manage this training")
+	}
+
+	return nil
+}
@@ -0,0 +1,370 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+type IReminderService interface {
+	Start()
+}
+
+type ReminderService struct {
+	schedulerService       ISchedulerService
+	mailService            IMailService
+	sessionRepository      repositories.ISessionRepository
+	trainingRepository     repositories.ITrainingRepository
+	enrollmentRepository   repositories.IEnrollmentRepository
+	userRepository         repositories.IUserRepository
+	notificationRepository repositories.INotificationRepository
+	appUrl                 string
+	digestHour             int
+}
+
+type sessionReminder struct {
+	SessionID    string        `json:"sessionId"`
+	OccurrenceAt time.Time     `json:"occurrenceAt"`
+	Before       time.Duration `json:"before"`
+}
+
+const (
+	mailJob            = "mail"
+	planRemindersJob   = "plan-reminders"
+	sessionReminderJob = "session-reminder"
+	ownerDigestJob     = "owner-digest"
+
+	// Reminders are planned in slots so recurring sessions don't have to be expanded far ahead,
+	// and edits to a session are picked up by the next slot.
+	reminderPlanInterval = 15 * time.Minute
+)
+
+var reminderOffsets = []time.Duration{24 * time.Hour, time.Hour}
+
+var (
+	reminderOnce    sync.Once
+	reminderService IReminderService
+)
+
+func GetReminderService() IReminderService {
+	reminderOnce.Do(func() {
+		log.Info().Msg("Initializing reminder service")
+
+		digestHour, err := strconv.Atoi(os.Getenv("DIGEST_HOUR"))
+		if err != nil || digestHour < 0 || digestHour > 23 {
+			digestHour = 7
+		}
+
+		reminderService = &ReminderService{
+			schedulerService:       GetSchedulerService(),
+			mailService:            GetMailService(),
+			sessionRepository:      repositories.GetSessionRepository(),
+			trainingRepository:     repositories.GetTrainingRepository(),
+			enrollmentRepository:   repositories.GetEnrollmentRepository(),
+			userRepository:         repositories.GetUserRepository(),
+			notificationRepository: repositories.GetNotificationRepository(),
+			appUrl:                 strings.TrimSuffix(os.Getenv("APP_URL"), "/"),
+			digestHour:             digestHour,
+		}
+	})
+	return reminderService
+}
+
+// Start registers the reminder jobs and starts the scheduler. Every replica calls it,
+// the job IDs make sure each slot and digest is only scheduled and run once.
+func (s *ReminderService) Start() {
+	s.schedulerService.Handle(mailJob, s.sendMail)
+	s.schedulerService.Handle(planRemindersJob, s.planReminders)
+	s.schedulerService.Handle(sessionReminderJob, s.remindSession)
+	s.schedulerService.Handle(ownerDigestJob, s.sendDigests)
+
+	now := time.Now().UTC()
+
+	err := s.schedulePlan(now.Truncate(reminderPlanInterval))
+	if err != nil {
+		log.Err(err).Msg("Failed to schedule reminders")
+	}
+
+	err = s.scheduleDigest(now)
+	if err != nil {
+		log.Err(err).Msg("Failed to schedule owner digest")
+	}
+
+	s.schedulerService.Start()
+}
+
+func (s *ReminderService) sendMail(job Job) error {
+	var mail Mail
+	err := json.Unmarshal([]byte(job.Payload), &mail)
+	if err != nil {
+		return err
+	}
+
+	return s.mailService.Deliver(mail)
+}
+
+func (s *ReminderService) schedulePlan(slot time.Time) error {
+	return s.schedulerService.Schedule(Job{
+		ID:    fmt.Sprintf("%s:%d", planRemindersJob, slot.Unix()),
+		Kind:  planRemindersJob,
+		RunAt: slot,
+	})
+}
+
+// planReminders schedules the reminders that are due before the next slot has a chance to.
+func (s *ReminderService) planReminders(job Job) error {
+	err := s.schedulePlan(job.RunAt.Truncate(reminderPlanInterval).Add(reminderPlanInterval))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	from := now.Add(-reminderPlanInterval)
+	to := now.Add(2 * reminderPlanInterval)
+
+	for _, session := range s.sessionRepository.FindUpcoming(now, to.Add(reminderOffsets[0])) {
+		occurrences, err := session.Occurrences(now, to.Add(reminderOffsets[0]))
+		if err != nil {
+			log.Err(err).Str(logger.SessionID, session.ID).Msg("Failed to expand session")
+			continue
+		}
+
+		for _, occurrence := range occurrences {
+			for _, before := range reminderOffsets {
+				remindAt := occurrence.Add(-before)
+				// Reminders that are long overdue are skipped, the ones further ahead are left for later slots.
+				if remindAt.Before(from) || !remindAt.Before(to) {
+					continue
+				}
+
+				payload, err := json.Marshal(sessionReminder{
+					SessionID:    session.ID,
+					OccurrenceAt: occurrence,
+					Before:       before,
+				})
+				if err != nil {
+					return err
+				}
+
+				err = s.schedulerService.Schedule(Job{
+					ID:      fmt.Sprintf("%s:%s:%d:%d", sessionReminderJob, session.ID, occurrence.Unix(), int(before.Minutes())),
+					Kind:    sessionReminderJob,
+					Payload: string(payload),
+					RunAt:   remindAt,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// remindSession emails the members of a training who want to be reminded about an occurrence.
+func (s *ReminderService) remindSession(job Job) error {
+	var reminder sessionReminder
+	err := json.Unmarshal([]byte(job.Payload), &reminder)
+	if err != nil {
+		return err
+	}
+
+	session, err := s.sessionRepository.FindByID(reminder.SessionID)
+	if err != nil {
+		// The session was deleted after the reminder was planned.
+		return nil
+	}
+
+	occurrences, err := session.Occurrences(reminder.OccurrenceAt, reminder.OccurrenceAt.Add(time.Second))
+	if err != nil || len(occurrences) == 0 {
+		return err
+	}
+
+	members := s.trainingRepository.FindMembers(session.TrainingID)
+
+	userIDs := []string{}
+	for _, member := range members {
+		userIDs = append(userIDs, member.UserID)
+	}
+
+	preferences := s.notificationRepository.FindByUserIDs(userIDs)
+
+	when := "tomorrow"
+	if reminder.Before <= time.Hour {
+		when = "in one hour"
+	}
+
+	for _, member := range members {
+		preference := preferences[member.UserID]
+		if (reminder.Before <= time.Hour && !preference.HourReminder) || (reminder.Before > time.Hour && !preference.DayReminder) {
+			continue
+		}
+
+		start := reminder.OccurrenceAt.In(session.TimeLocation())
+
+		body := fmt.Sprintf("<strong>%s</strong> of <strong>%s</strong> starts %s, on %s (%s).",
+			html.EscapeString(session.Title), html.EscapeString(session.Training.Name), when, start.Format("Monday, January 2 at 15:04"), session.TimeZone)
+		if session.Location != "" {
+			body += "<br>Location: " + html.EscapeString(session.Location)
+		}
+		if session.MeetingURL != "" {
+			body += "<br>Join: " + html.EscapeString(session.MeetingURL)
+		}
+
+		err = s.scheduleMail(job.ID+":"+member.UserID, Mail{
+			To:      []string{member.User.Email},
+			Subject: "Trainings - Session reminder",
+			Body:    body,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *ReminderService) scheduleDigest(now time.Time) error {
+	now = now.UTC()
+	runAt := time.Date(now.Year(), now.Month(), now.Day(), s.digestHour, 0, 0, 0, time.UTC)
+	if !runAt.After(now) {
+		runAt = runAt.AddDate(0, 0, 1)
+	}
+
+	return s.schedulerService.Schedule(Job{
+		ID:    ownerDigestJob + ":" + runAt.Format("2006-01-02"),
+		Kind:  ownerDigestJob,
+		RunAt: runAt,
+	})
+}
+
+// sendDigests emails every owner a summary of the sessions of the next day and
+// the enrollment requests waiting for them, owners with nothing to report are skipped.
+func (s *ReminderService) sendDigests(job Job) error {
+	err := s.scheduleDigest(job.RunAt)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	to := now.Add(24 * time.Hour)
+
+	upcoming := map[string][]dto.Occurrence{}
+	for _, session := range s.sessionRepository.FindUpcoming(now, to) {
+		occurrences, err := session.Occurrences(now, to)
+		if err != nil {
+			continue
+		}
+
+		for _, occurrence := range occurrences {
+			upcoming[session.TrainingID] = append(upcoming[session.TrainingID], dto.Occurrence{
+				SessionID: session.ID,
+				Title:     fmt.Sprintf("%s - %s (%s)", occurrence.In(session.TimeLocation()).Format("Mon 15:04"), session.Title, session.TimeZone),
+				StartsAt:  occurrence,
+			})
+		}
+	}
+
+	pending := s.enrollmentRepository.CountPending()
+
+	trainingIDs := []string{}
+	for id := range upcoming {
+		trainingIDs = append(trainingIDs, id)
+	}
+	for id := range pending {
+		if _, ok := upcoming[id]; !ok {
+			trainingIDs = append(trainingIDs, id)
+		}
+	}
+
+	if len(trainingIDs) == 0 {
+		return nil
+	}
+
+	owned := map[string][]models.Training{}
+	for _, training := range s.trainingRepository.FindByIDs(trainingIDs) {
+		owned[training.OwnerID] = append(owned[training.OwnerID], training)
+	}
+
+	ownerIDs := []string{}
+	for id := range owned {
+		ownerIDs = append(ownerIDs, id)
+	}
+
+	preferences := s.notificationRepository.FindByUserIDs(ownerIDs)
+
+	for ownerID, trainings := range owned {
+		if !preferences[ownerID].OwnerDigest {
+			continue
+		}
+
+		owner, err := s.userRepository.FindByID(ownerID)
+		if err != nil {
+			continue
+		}
+
+		sort.Slice(trainings, func(i, j int) bool {
+			return trainings[i].Name < trainings[j].Name
+		})
+
+		body := "Here is what is happening in your trainings today.<br>"
+		for _, training := range trainings {
+			body += fmt.Sprintf("<br><strong>%s</strong><br>", html.EscapeString(training.Name))
+
+			occurrences := upcoming[training.ID]
+			sort.Slice(occurrences, func(i, j int) bool {
+				return occurrences[i].StartsAt.Before(occurrences[j].StartsAt)
+			})
+			for _, occurrence := range occurrences {
+				body += html.EscapeString(occurrence.Title) + "<br>"
+			}
+
+			if count := pending[training.ID]; count > 0 {
+				body += fmt.Sprintf("%d enrollment requests waiting for approval<br>", count)
+			}
+		}
+
+		if s.appUrl != "" {
+			body += "<br>" + s.appUrl
+		}
+
+		err = s.scheduleMail(job.ID+":"+ownerID, Mail{
+			To:      []string{owner.Email},
+			Subject: "Trainings - Daily digest",
+			Body:    body,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scheduleMail sends a mail through its own job, so a failed delivery is retried
+// without sending the mail again to everyone else.
+func (s *ReminderService) scheduleMail(id string, mail Mail) error {
+	payload, err := json.Marshal(mail)
+	if err != nil {
+		return err
+	}
+
+	return s.schedulerService.Schedule(Job{
+		ID:      mailJob + ":" + id,
+		Kind:    mailJob,
+		Payload: string(payload),
+	})
+}
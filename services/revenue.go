@@ -0,0 +1,228 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+// defaultRevenueRange is the period reported when the query doesn't set one.
+const defaultRevenueRange = 30 * 24 * time.Hour
+
+type IRevenueService interface {
+	FindByOwner(userID string, query dto.RevenueQuery) dto.RevenueReport
+	FindAll(userID string, query dto.RevenueQuery) (dto.RevenueReport, error)
+	Export(report dto.RevenueReport) ([]byte, error)
+}
+
+type RevenueService struct {
+	orderRepository repositories.IOrderRepository
+	userRepository  repositories.IUserRepository
+	currency        string
+}
+
+var (
+	revenueOnce    sync.Once
+	revenueService IRevenueService
+)
+
+func GetRevenueService() IRevenueService {
+	revenueOnce.Do(func() {
+		log.Info().Msg("Initializing revenue service")
+
+		currency := strings.ToLower(os.Getenv("PAYMENT_CURRENCY"))
+		if currency == "" {
+			currency = "eur"
+		}
+
+		revenueService = &RevenueService{
+			orderRepository: repositories.GetOrderRepository(),
+			userRepository:  repositories.GetUserRepository(),
+			currency:        currency,
+		}
+	})
+	return revenueService
+}
+
+// FindByOwner reports the sales of the trainings a user owns or co-owns.
+func (s *RevenueService) FindByOwner(userID string, query dto.RevenueQuery) dto.RevenueReport {
+	log.Debug().Str(logger.UserID, userID).Msg("Finding owner revenue")
+
+	return s.report(userID, query)
+}
+
+// FindAll reports the sales of the whole platform, admin only.
+func (s *RevenueService) FindAll(userID string, query dto.RevenueQuery) (dto.RevenueReport, error) {
+	log.Debug().Str(logger.UserID, userID).Msg("Finding platform revenue")
+
+	user, err := s.userRepository.FindByID(userID)
+	if err != nil || !user.HasRole(models.AdminRole) {
+		return dto.RevenueReport{}, errors.New("user is not admin")
+	}
+
+	return s.report("", query), nil
+}
+
+// Export writes a report as CSV with a row per training and period.
+func (s *RevenueService) Export(report dto.RevenueReport) ([]byte, error) {
+	records := [][]string{{"Training", "Period", "Sales", "Gross", "Refunds", "Net", "Currency"}}
+
+	row := func(name, period string, totals dto.RevenueTotals) []string {
+		return []string{
+			name,
+			period,
+			strconv.Itoa(totals.Sales),
+			formatCents(totals.Gross),
+			formatCents(totals.Refunds),
+			formatCents(totals.Net),
+			strings.ToUpper(report.Currency),
+		}
+	}
+
+	for _, training := range report.Trainings {
+		for _, period := range training.Periods {
+			records = append(records, row(training.TrainingName, period.Period, period.RevenueTotals))
+		}
+	}
+
+	records = append(records, row("Total", "", report.RevenueTotals))
+
+	return writeCsv(records)
+}
+
+func (s *RevenueService) report(managerID string, query dto.RevenueQuery) dto.RevenueReport {
+	to := query.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+
+	from := query.From
+	if from.IsZero() || !from.Before(to) {
+		from = to.Add(-defaultRevenueRange)
+	}
+
+	interval := query.Interval
+	if interval == "" {
+		interval = "day"
+	}
+
+	report := dto.RevenueReport{
+		From:      from,
+		To:        to,
+		Interval:  interval,
+		Currency:  s.currency,
+		Trainings: []dto.TrainingRevenue{},
+	}
+
+	periods := revenuePeriods(from, to, interval)
+	report.Periods = emptyPeriods(periods)
+
+	index := map[string]int{}
+	for i, period := range periods {
+		index[period] = i
+	}
+
+	trainings := map[string]*dto.TrainingRevenue{}
+
+	for _, order := range s.orderRepository.FindSales(managerID, from, to) {
+		i := index[revenuePeriod(*order.PaidAt, interval)]
+
+		training, ok := trainings[order.TrainingID]
+		if !ok {
+			training = &dto.TrainingRevenue{
+				TrainingID:   order.TrainingID,
+				TrainingName: order.Training.Name,
+				Periods:      emptyPeriods(periods),
+			}
+			trainings[order.TrainingID] = training
+		}
+
+		for _, totals := range []*dto.RevenueTotals{
+			&report.RevenueTotals,
+			&report.Periods[i].RevenueTotals,
+			&training.RevenueTotals,
+			&training.Periods[i].RevenueTotals,
+		} {
+			totals.Sales++
+			totals.Gross += order.Amount
+			totals.Refunds += order.Refunded
+			totals.Net += order.Amount - order.Refunded
+		}
+	}
+
+	for _, training := range trainings {
+		report.Trainings = append(report.Trainings, *training)
+	}
+
+	// Best selling trainings first.
+	sort.Slice(report.Trainings, func(i, j int) bool {
+		if report.Trainings[i].Net != report.Trainings[j].Net {
+			return report.Trainings[i].Net > report.Trainings[j].Net
+		}
+		return report.Trainings[i].TrainingName < report.Trainings[j].TrainingName
+	})
+
+	return report
+}
+
+// revenuePeriods lists the periods between from and to, so periods without sales are reported too.
+func revenuePeriods(from, to time.Time, interval string) []string {
+	periods := []string{}
+
+	for t := from.UTC(); t.Before(to); {
+		period := revenuePeriod(t, interval)
+		if len(periods) == 0 || periods[len(periods)-1] != period {
+			periods = append(periods, period)
+		}
+
+		switch interval {
+		case "month":
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case "week":
+			t = t.AddDate(0, 0, 7)
+		default:
+			t = t.AddDate(0, 0, 1)
+		}
+	}
+
+	last := revenuePeriod(to.Add(-time.Nanosecond), interval)
+	if len(periods) == 0 || periods[len(periods)-1] != last {
+		periods = append(periods, last)
+	}
+
+	return periods
+}
+
+// revenuePeriod names the period of a time, the day, the Monday of its week or its month.
+func revenuePeriod(t time.Time, interval string) string {
+	t = t.UTC()
+
+	switch interval {
+	case "month":
+		return t.Format("2006-01")
+	case "week":
+		offset := (int(t.Weekday()) + 6) % 7
+		return t.AddDate(0, 0, -offset).Format("2006-01-02")
+	default:
+		return t.Format("2006-01-02")
+	}
+}
+
+func emptyPeriods(periods []string) []dto.RevenuePeriod {
+	result := make([]dto.RevenuePeriod, len(periods))
+	for i, period := range periods {
+		result[i].Period = period
+	}
+
+	return result
+}
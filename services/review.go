@@ -0,0 +1,194 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+type IReviewService interface {
+	FindByTrainingID(trainingID, userID string, params dto.PaginationQuery) (dto.Page[models.Review], error)
+	FindReported(userID string, params dto.PaginationQuery) (dto.Page[models.Review], error)
+	Create(trainingID, userID string, dto dto.CreateReview) (models.Review, error)
+	Update(id, userID string, dto dto.UpdateReview) (models.Review, error)
+	Delete(id, userID string) error
+	Reply(id, userID string, dto dto.ReplyReview) (models.Review, error)
+	Report(id, userID string, dto dto.ReportReview) error
+}
+
+type ReviewService struct {
+	reviewRepository   repositories.IReviewRepository
+	trainingRepository repositories.ITrainingRepository
+	userRepository     repositories.IUserRepository
+	trainingService    ITrainingService
+}
+
+var (
+	reviewOnce    sync.Once
+	reviewService IReviewService
+)
+
+func GetReviewService() IReviewService {
+	reviewOnce.Do(func() {
+		log.Info().Msg("Initializing review service")
+		reviewService = &ReviewService{
+			reviewRepository:   repositories.GetReviewRepository(),
+			trainingRepository: repositories.GetTrainingRepository(),
+			userRepository:     repositories.GetUserRepository(),
+			trainingService:    GetTrainingService(),
+		}
+	})
+	return reviewService
+}
+
+func (s *ReviewService) FindByTrainingID(trainingID, userID string, params dto.PaginationQuery) (dto.Page[models.Review], error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Msg("Finding training reviews")
+
+	// Reviews are as visible as the training itself.
+	_, err := s.trainingService.FindOne(trainingID, userID)
+	if err != nil {
+		return dto.Page[models.Review]{}, err
+	}
+
+	return s.reviewRepository.FindByTrainingID(trainingID, params)
+}
+
+func (s *ReviewService) FindReported(userID string, params dto.PaginationQuery) (dto.Page[models.Review], error) {
+	log.Debug().Str(logger.UserID, userID).Msg("Finding reported reviews")
+
+	err := s.verifyAdmin(userID)
+	if err != nil {
+		return dto.Page[models.Review]{}, err
+	}
+
+	return s.reviewRepository.FindReported(params)
+}
+
+func (s *ReviewService) Create(trainingID, userID string, dto dto.CreateReview) (models.Review, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Creating review")
+
+	member, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err != nil {
+		return models.Review{}, errors.New("only enrolled users can review this training")
+	}
+
+	if member.CanPost() {
+		return models.Review{}, errors.New("staff can't review their own training")
+	}
+
+	review := models.Review{
+		TrainingID: trainingID,
+		UserID:     userID,
+		Rating:     dto.Rating,
+		Text:       dto.Text,
+	}
+
+	err = s.reviewRepository.Create(&review)
+	if err != nil {
+		return models.Review{}, errors.New("you have already reviewed this training")
+	}
+
+	return s.reviewRepository.FindByID(review.ID)
+}
+
+func (s *ReviewService) Update(id, userID string, dto dto.UpdateReview) (models.Review, error) {
+	log.Debug().Str(logger.ReviewID, id).Str(logger.UserID, userID).Msg("Updating review")
+
+	review, err := s.reviewRepository.FindByID(id)
+	if err != nil {
+		return review, err
+	}
+
+	if review.UserID != userID {
+		return models.Review{}, errors.New("you are not the author of this review")
+	}
+
+	review.Rating = dto.Rating
+	review.Text = dto.Text
+
+	err = s.reviewRepository.Update(&review)
+
+	return review, err
+}
+
+func (s *ReviewService) Delete(id, userID string) error {
+	log.Debug().Str(logger.ReviewID, id).Str(logger.UserID, userID).Msg("Deleting review")
+
+	review, err := s.reviewRepository.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	// Admins can remove reported reviews.
+	if review.UserID != userID && s.verifyAdmin(userID) != nil {
+		return errors.New("you are not the author of this review")
+	}
+
+	return s.reviewRepository.Delete(&review)
+}
+
+func (s *ReviewService) Reply(id, userID string, dto dto.ReplyReview) (models.Review, error) {
+	log.Debug().Str(logger.ReviewID, id).Str(logger.UserID, userID).Msg("Replying to review")
+
+	review, err := s.reviewRepository.FindByID(id)
+	if err != nil {
+		return review, err
+	}
+
+	member, err := s.trainingRepository.FindMember(review.TrainingID, userID)
+	if err != nil || !member.CanManage() {
+		return models.Review{}, errors.New("you are not allowed to manage this training")
+	}
+
+	// An empty reply removes the previous one.
+	review.Reply = dto.Reply
+	review.RepliedAt = nil
+	if dto.Reply != "" {
+		now := time.Now()
+		review.RepliedAt = &now
+	}
+
+	err = s.reviewRepository.Update(&review)
+
+	return review, err
+}
+
+func (s *ReviewService) Report(id, userID string, dto dto.ReportReview) error {
+	log.Debug().Str(logger.ReviewID, id).Str(logger.UserID, userID).Msg("Reporting review")
+
+	review, err := s.reviewRepository.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	if review.UserID == userID {
+		return errors.New("you can't report your own review")
+	}
+
+	report := models.ReviewReport{
+		ReviewID: id,
+		UserID:   userID,
+		Reason:   dto.Reason,
+	}
+
+	return s.reviewRepository.Report(&report)
+}
+
+func (s *ReviewService) verifyAdmin(userID string) error {
+	user, err := s.userRepository.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.HasRole(models.AdminRole) {
+		return errors.New("user is not admin")
+	}
+
+	return nil
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/rs/zerolog/log"
+	"github.com/xuri/excelize/v2"
+)
+
+type IRosterService interface {
+	Import(trainingID, userID string, file io.Reader, createMissing bool) ([]dto.RosterRowResult, error)
+	Export(trainingID, userID, format string) ([]byte, error)
+}
+
+type RosterService struct {
+	trainingRepository repositories.ITrainingRepository
+	mailService        IMailService
+	appUrl             string
+}
+
+const maxRosterRows = 1000
+
+var rosterHeader = []string{"Email", "First Name", "Last Name", "Role", "Enrolled At"}
+
+var (
+	rosterOnce    sync.Once
+	rosterService IRosterService
+)
+
+func GetRosterService() IRosterService {
+	rosterOnce.Do(func() {
+		log.Info().Msg("Initializing roster service")
+		rosterService = &RosterService{
+			trainingRepository: repositories.GetTrainingRepository(),
+			mailService:        GetMailService(),
+			appUrl:             strings.TrimSuffix(os.Getenv("APP_URL"), "/"),
+		}
+	})
+	return rosterService
+}
+
+// Import enrolls the users listed in a CSV file. The first line is a header with an email column
+// and optional firstName, lastName and role columns. Every line gets a result in the response.
+func (s *RosterService) Import(trainingID, userID string, file io.Reader, createMissing bool) ([]dto.RosterRowResult, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Importing roster")
+
+	training, err := s.findManagedTraining(trainingID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, results, err := parseRoster(file, training.OwnerID == userID)
+	if err != nil {
+		return nil, err
+	}
+
+	imported, err := s.trainingRepository.ImportMembers(&training, rows, createMissing)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range imported {
+		if result.Created {
+			go s.mailService.Send(Mail{
+				To:      []string{result.Email},
+				Subject: "Trainings - Account created",
+				Body: fmt.Sprintf("An account was created for you to join <strong>%s</strong>.<br>"+
+					"Finish signing up with a one time password sent to this email at %s", html.EscapeString(training.Name), s.appUrl),
+			})
+		}
+	}
+
+	results = append(results, imported...)
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Row < results[j].Row
+	})
+
+	return results, nil
+}
+
+func (s *RosterService) Export(trainingID, userID, format string) ([]byte, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Exporting roster")
+
+	_, err := s.findManagedTraining(trainingID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	members := s.trainingRepository.FindMembers(trainingID)
+
+	records := [][]string{rosterHeader}
+	for _, member := range members {
+		records = append(records, []string{
+			member.User.Email,
+			member.User.FirstName,
+			member.User.LastName,
+			member.Role,
+			member.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+
+	if format == "xlsx" {
+		return writeXlsx(records)
+	}
+
+	return writeCsv(records)
+}
+
+func (s *RosterService) findManagedTraining(trainingID, userID string) (models.Training, error) {
+	training, err := s.trainingRepository.FindByID(trainingID)
+	if err != nil {
+		return training, err
+	}
+
+	member, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err != nil || !member.CanManage() {
+		return training, errors.New("you are not allowed to manage this training")
+	}
+
+	return training, nil
+}
+
+// parseRoster reads the CSV file, rows that can't be imported are returned as results right away.
+func parseRoster(file io.Reader, isOwner bool) ([]dto.RosterRow, []dto.RosterRowResult, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, errors.New("roster file is empty or not a valid csv")
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.NewReplacer(" ", "", "_", "", "\ufeff", "").Replace(strings.ToLower(name))
+		columns[name] = i
+	}
+
+	if _, ok := columns["email"]; !ok {
+		return nil, nil, errors.New("roster file has no email column")
+	}
+
+	column := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rows := []dto.RosterRow{}
+	results := []dto.RosterRowResult{}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		if len(rows)+len(results) >= maxRosterRows {
+			return nil, nil, fmt.Errorf("roster file can't have more than %d rows", maxRosterRows)
+		}
+
+		row := dto.RosterRow{
+			Line:      line,
+			Email:     strings.ToLower(column(record, "email")),
+			FirstName: column(record, "firstname"),
+			LastName:  column(record, "lastname"),
+			Role:      strings.ToLower(column(record, "role")),
+		}
+
+		if row.Email == "" && row.FirstName == "" && row.LastName == "" {
+			continue
+		}
+
+		if row.Role == "" {
+			row.Role = models.StudentMember
+		}
+
+		invalid := ""
+		switch {
+		case !strings.Contains(row.Email, "@"):
+			invalid = "invalid email"
+		case row.Role != models.CoOwnerMember && row.Role != models.InstructorMember &&
+			row.Role != models.AssistantMember && row.Role != models.StudentMember:
+			invalid = "invalid role"
+		case row.Role == models.CoOwnerMember && !isOwner:
+			invalid = "only the owner can add co-owners"
+		}
+
+		if invalid != "" {
+			results = append(results, dto.RosterRowResult{
+				Row:    row.Line,
+				Email:  row.Email,
+				Status: dto.RosterInvalid,
+				Error:  invalid,
+			})
+			continue
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, results, nil
+}
+
+// escapeCells quotes cells spreadsheet apps would read as formulas, numbers are left as they are.
+func escapeCells(records [][]string) [][]string {
+	escaped := make([][]string, len(records))
+	for i, record := range records {
+		escaped[i] = make([]string, len(record))
+		for j, cell := range record {
+			escaped[i][j] = escapeCell(cell)
+		}
+	}
+
+	return escaped
+}
+
+func escapeCell(cell string) string {
+	if cell == "" || !strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return cell
+	}
+
+	if _, err := strconv.ParseFloat(cell, 64); err == nil {
+		return cell
+	}
+
+	return "'" + cell
+}
+
+func writeCsv(records [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	writer := csv.NewWriter(&buf)
+	err := writer.WriteAll(escapeCells(records))
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeXlsx(records [][]string) ([]byte, error) {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	sheet := file.GetSheetName(0)
+	for i, record := range escapeCells(records) {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return nil, err
+		}
+
+		err = file.SetSheetRow(sheet, cell, &record)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	buf, err := file.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
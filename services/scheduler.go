@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/rdb"
+	"github.com/go-redis/redis/v9"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// Job is a unit of background work. Jobs with the same ID are only scheduled once,
+// which lets every replica schedule the same job without running it twice.
+type Job struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Payload   string    `json:"payload"`
+	RunAt     time.Time `json:"runAt"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"lastError,omitempty"`
+}
+
+type JobHandler func(job Job) error
+
+type ISchedulerService interface {
+	Handle(kind string, handler JobHandler)
+	Schedule(job Job) error
+	Start()
+}
+
+type SchedulerService struct {
+	rdb      *redis.Client
+	ctx      context.Context
+	worker   string
+	interval time.Duration
+	handlers map[string]JobHandler
+	mu       sync.RWMutex
+	start    sync.Once
+}
+
+const (
+	jobQueueKey  = "jobs:queue"
+	jobDataKey   = "jobs:data"
+	jobDeadKey   = "jobs:dead"
+	jobLockKey   = "jobs:lock:"
+	jobUniqueKey = "jobs:unique:"
+
+	// A claimed job is hidden from other workers for the lease, if its worker dies it runs again afterwards.
+	jobLease       = 5 * time.Minute
+	jobMaxAttempts = 5
+	jobRetryDelay  = 30 * time.Second
+	jobBatchSize   = 20
+	// Job IDs stay reserved this long after the job was due, so it isn't scheduled again.
+	jobUniqueTTL = 72 * time.Hour
+)
+
+var (
+	schedulerOnce    sync.Once
+	schedulerService ISchedulerService
+)
+
+func GetSchedulerService() ISchedulerService {
+	schedulerOnce.Do(func() {
+		log.Info().Msg("Initializing scheduler service")
+
+		interval, err := time.ParseDuration(os.Getenv("SCHEDULER_INTERVAL"))
+		if err != nil {
+			interval = time.Second
+		}
+
+		hostname, _ := os.Hostname()
+
+		rdb, ctx := rdb.GetRDB()
+		schedulerService = &SchedulerService{
+			rdb:      rdb,
+			ctx:      ctx,
+			worker:   hostname + ":" + uuid.New().String(),
+			interval: interval,
+			handlers: map[string]JobHandler{},
+		}
+	})
+	return schedulerService
+}
+
+func (s *SchedulerService) Handle(kind string, handler JobHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[kind] = handler
+}
+
+// Schedule queues a job to run at RunAt, or right away if RunAt is not set.
+func (s *SchedulerService) Schedule(job Job) error {
+	if job.ID == "" {
+		job.ID = uuid.New().String()
+	}
+
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+
+	ttl := time.Until(job.RunAt) + jobUniqueTTL
+	if ttl < jobUniqueTTL {
+		ttl = jobUniqueTTL
+	}
+	fresh, err := s.rdb.SetNX(s.ctx, jobUniqueKey+job.ID, job.RunAt.Unix(), ttl).Result()
+	if err != nil {
+		return err
+	}
+
+	if !fresh {
+		return nil
+	}
+
+	log.Debug().Str(logger.JobID, job.ID).Str("kind", job.Kind).Time("run_at", job.RunAt).Msg("Scheduling job")
+
+	return s.enqueue(job)
+}
+
+// Start polls the queue in the background, it is safe to call more than once.
+func (s *SchedulerService) Start() {
+	s.start.Do(func() {
+		log.Info().Str("worker", s.worker).Msg("Starting scheduler")
+
+		go func() {
+			ticker := time.NewTicker(s.interval)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				s.poll()
+			}
+		}()
+	})
+}
+
+func (s *SchedulerService) poll() {
+	now := time.Now()
+
+	ids, err := s.rdb.ZRangeByScore(s.ctx, jobQueueKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.UnixMilli(), 10),
+		Count: jobBatchSize,
+	}).Result()
+	if err != nil {
+		log.Err(err).Msg("Failed to poll jobs")
+		return
+	}
+
+	for _, id := range ids {
+		job, ok := s.claim(id)
+		if ok {
+			go s.run(job)
+		}
+	}
+}
+
+// claim takes the lock of a due job and pushes it back in the queue by the lease,
+// it fails when another worker got to the job first.
+func (s *SchedulerService) claim(id string) (Job, bool) {
+	locked, err := s.rdb.SetNX(s.ctx, jobLockKey+id, s.worker, jobLease).Result()
+	if err != nil || !locked {
+		return Job{}, false
+	}
+
+	// The job could have been finished or rescheduled since the queue was read.
+	score, err := s.rdb.ZScore(s.ctx, jobQueueKey, id).Result()
+	if err != nil || score > float64(time.Now().UnixMilli()) {
+		s.rdb.Del(s.ctx, jobLockKey+id)
+		return Job{}, false
+	}
+
+	data, err := s.rdb.HGet(s.ctx, jobDataKey, id).Result()
+	if err != nil {
+		s.rdb.ZRem(s.ctx, jobQueueKey, id)
+		s.rdb.Del(s.ctx, jobLockKey+id)
+		return Job{}, false
+	}
+
+	var job Job
+	err = json.Unmarshal([]byte(data), &job)
+	if err != nil {
+		log.Err(err).Str(logger.JobID, id).Msg("Dropping malformed job")
+		s.rdb.ZRem(s.ctx, jobQueueKey, id)
+		s.rdb.HDel(s.ctx, jobDataKey, id)
+		s.rdb.Del(s.ctx, jobLockKey+id)
+		return Job{}, false
+	}
+
+	s.rdb.ZAdd(s.ctx, jobQueueKey, redis.Z{Score: float64(time.Now().Add(jobLease).UnixMilli()), Member: id})
+
+	return job, true
+}
+
+func (s *SchedulerService) run(job Job) {
+	defer s.rdb.Del(s.ctx, jobLockKey+job.ID)
+
+	log.Debug().Str(logger.JobID, job.ID).Str("kind", job.Kind).Msg("Running job")
+
+	err := s.execute(job)
+	if err == nil {
+		pipe := s.rdb.TxPipeline()
+		pipe.ZRem(s.ctx, jobQueueKey, job.ID)
+		pipe.HDel(s.ctx, jobDataKey, job.ID)
+		_, err = pipe.Exec(s.ctx)
+		if err != nil {
+			log.Err(err).Str(logger.JobID, job.ID).Msg("Failed to remove finished job")
+		}
+		return
+	}
+
+	job.Attempts++
+	job.LastError = err.Error()
+
+	if job.Attempts >= jobMaxAttempts {
+		log.Error().Err(err).Str(logger.JobID, job.ID).Str("kind", job.Kind).Msg("Job failed, giving up")
+
+		data, _ := json.Marshal(job)
+		pipe := s.rdb.TxPipeline()
+		pipe.ZRem(s.ctx, jobQueueKey, job.ID)
+		pipe.HDel(s.ctx, jobDataKey, job.ID)
+		pipe.LPush(s.ctx, jobDeadKey, data)
+		pipe.Exec(s.ctx)
+		return
+	}
+
+	// Back off exponentially: 30s, 1m, 2m, 4m.
+	job.RunAt = time.Now().Add(jobRetryDelay << (job.Attempts - 1))
+
+	log.Warn().Err(err).Str(logger.JobID, job.ID).Int("attempts", job.Attempts).Time("run_at", job.RunAt).Msg("Job failed, retrying")
+
+	err = s.enqueue(job)
+	if err != nil {
+		log.Err(err).Str(logger.JobID, job.ID).Msg("Failed to reschedule job")
+	}
+}
+
+func (s *SchedulerService) execute(job Job) (err error) {
+	s.mu.RLock()
+	handler, ok := s.handlers[job.Kind]
+	s.mu.RUnlock()
+
+	if !ok {
+		return errors.New("no handler for job kind " + job.Kind)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return handler(job)
+}
+
+func (s *SchedulerService) enqueue(job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	pipe := s.rdb.TxPipeline()
+	pipe.HSet(s.ctx, jobDataKey, job.ID, data)
+	pipe.ZAdd(s.ctx, jobQueueKey, redis.Z{Score: float64(job.RunAt.UnixMilli()), Member: job.ID})
+	_, err = pipe.Exec(s.ctx)
+
+	return err
+}
@@ -0,0 +1,332 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/dto"
+	"github.com/Marcel-MD/xmas-faf-api/logger"
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+type ISessionService interface {
+	FindByTrainingID(trainingID, userID string) ([]models.Session, error)
+	FindOne(id, userID string) (models.Session, error)
+	Create(trainingID, userID string, dto dto.CreateSession) (models.Session, error)
+	Update(id, userID string, dto dto.UpdateSession) (models.Session, error)
+	Delete(id, userID string) error
+	TrainingSchedule(trainingID, userID string, query dto.ScheduleQuery) ([]dto.Occurrence, error)
+	UserSchedule(userID string, query dto.ScheduleQuery) ([]dto.Occurrence, error)
+	TrainingCalendar(trainingID, userID string) ([]byte, error)
+	UserCalendar(token string) ([]byte, error)
+	CalendarToken(userID string, reset bool) (string, error)
+}
+
+type SessionService struct {
+	sessionRepository  repositories.ISessionRepository
+	trainingRepository repositories.ITrainingRepository
+	userRepository     repositories.IUserRepository
+	trainingService    ITrainingService
+}
+
+const (
+	defaultScheduleRange = 30 * 24 * time.Hour
+	maxScheduleRange     = 366 * 24 * time.Hour
+)
+
+var (
+	sessionOnce    sync.Once
+	sessionService ISessionService
+)
+
+func GetSessionService() ISessionService {
+	sessionOnce.Do(func() {
+		log.Info().Msg("Initializing session service")
+		sessionService = &SessionService{
+			sessionRepository:  repositories.GetSessionRepository(),
+			trainingRepository: repositories.GetTrainingRepository(),
+			userRepository:     repositories.GetUserRepository(),
+			trainingService:    GetTrainingService(),
+		}
+	})
+	return sessionService
+}
+
+func (s *SessionService) FindByTrainingID(trainingID, userID string) ([]models.Session, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Msg("Finding sessions")
+
+	_, err := s.trainingService.FindOne(trainingID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.hideMeetingUrls(trainingID, userID, s.sessionRepository.FindByTrainingID(trainingID)), nil
+}
+
+func (s *SessionService) FindOne(id, userID string) (models.Session, error) {
+	log.Debug().Str(logger.SessionID, id).Msg("Finding session")
+
+	session, err := s.sessionRepository.FindByID(id)
+	if err != nil {
+		return session, err
+	}
+
+	_, err = s.trainingService.FindOne(session.TrainingID, userID)
+	if err != nil {
+		return models.Session{}, err
+	}
+
+	return s.hideMeetingUrls(session.TrainingID, userID, []models.Session{session})[0], nil
+}
+
+func (s *SessionService) Create(trainingID, userID string, dto dto.CreateSession) (models.Session, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Creating session")
+
+	err := s.verifyCanManage(trainingID, userID)
+	if err != nil {
+		return models.Session{}, err
+	}
+
+	session := models.Session{TrainingID: trainingID}
+
+	err = fillSession(&session, dto)
+	if err != nil {
+		return session, err
+	}
+
+	err = s.sessionRepository.Create(&session)
+	if err != nil {
+		return session, err
+	}
+
+	return session, nil
+}
+
+func (s *SessionService) Update(id, userID string, dto dto.UpdateSession) (models.Session, error) {
+	log.Debug().Str(logger.SessionID, id).Str(logger.UserID, userID).Msg("Updating session")
+
+	session, err := s.sessionRepository.FindByID(id)
+	if err != nil {
+		return session, err
+	}
+
+	err = s.verifyCanManage(session.TrainingID, userID)
+	if err != nil {
+		return session, err
+	}
+
+	err = fillSession(&session, createSession(dto))
+	if err != nil {
+		return session, err
+	}
+
+	err = s.sessionRepository.Update(&session)
+	if err != nil {
+		return session, err
+	}
+
+	return session, nil
+}
+
+func (s *SessionService) Delete(id, userID string) error {
+	log.Debug().Str(logger.SessionID, id).Str(logger.UserID, userID).Msg("Deleting session")
+
+	session, err := s.sessionRepository.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.verifyCanManage(session.TrainingID, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.sessionRepository.Delete(&session)
+}
+
+func (s *SessionService) TrainingSchedule(trainingID, userID string, query dto.ScheduleQuery) ([]dto.Occurrence, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Msg("Finding training schedule")
+
+	_, err := s.trainingService.FindOne(trainingID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return expandSessions(s.hideMeetingUrls(trainingID, userID, s.sessionRepository.FindByTrainingID(trainingID)), query)
+}
+
+func (s *SessionService) UserSchedule(userID string, query dto.ScheduleQuery) ([]dto.Occurrence, error) {
+	log.Debug().Str(logger.UserID, userID).Msg("Finding user schedule")
+
+	return expandSessions(s.sessionRepository.FindByUserID(userID), query)
+}
+
+func (s *SessionService) TrainingCalendar(trainingID, userID string) ([]byte, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Msg("Exporting training calendar")
+
+	training, err := s.trainingService.FindOne(trainingID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := s.hideMeetingUrls(trainingID, userID, s.sessionRepository.FindByTrainingID(trainingID))
+	for i := range sessions {
+		sessions[i].Training = training
+	}
+
+	return writeCalendar(training.Name, sessions), nil
+}
+
+func (s *SessionService) UserCalendar(token string) ([]byte, error) {
+	log.Debug().Msg("Exporting user calendar")
+
+	user, err := s.userRepository.FindByCalendarToken(token)
+	if err != nil {
+		return nil, errors.New("calendar not found")
+	}
+
+	return writeCalendar("Trainings", s.sessionRepository.FindByUserID(user.ID)), nil
+}
+
+// CalendarToken returns the token of the personal calendar feed of a user,
+// generating a new one when there is none yet or when reset is set.
+func (s *SessionService) CalendarToken(userID string, reset bool) (string, error) {
+	log.Debug().Str(logger.UserID, userID).Msg("Finding calendar token")
+
+	user, err := s.userRepository.FindByID(userID)
+	if err != nil {
+		return "", err
+	}
+
+	if user.CalendarToken != nil && !reset {
+		return *user.CalendarToken, nil
+	}
+
+	bytes := make([]byte, 24)
+	_, err = rand.Read(bytes)
+	if err != nil {
+		return "", err
+	}
+
+	token := hex.EncodeToString(bytes)
+	user.CalendarToken = &token
+
+	err = s.userRepository.Update(&user)
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// hideMeetingUrls blanks the meeting links of the sessions unless the user is a member of the training,
+// visitors of public trainings can see the schedule but not join.
+func (s *SessionService) hideMeetingUrls(trainingID, userID string, sessions []models.Session) []models.Session {
+	err := s.trainingRepository.VerifyUserInTraining(trainingID, userID)
+	if err == nil {
+		return sessions
+	}
+
+	for i := range sessions {
+		sessions[i].MeetingURL = ""
+	}
+
+	return sessions
+}
+
+func (s *SessionService) verifyCanManage(trainingID, userID string) error {
+	member, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err != nil || !member.CanManage() {
+		return errors.New("you are not allowed to manage this training")
+	}
+
+	return nil
+}
+
+func createSession(update dto.UpdateSession) dto.CreateSession {
+	return dto.CreateSession(update)
+}
+
+func fillSession(session *models.Session, dto dto.CreateSession) error {
+	if !dto.EndsAt.After(dto.StartsAt) {
+		return errors.New("session has to end after it starts")
+	}
+
+	if dto.TimeZone == "" {
+		dto.TimeZone = "UTC"
+	}
+
+	_, err := time.LoadLocation(dto.TimeZone)
+	if err != nil {
+		return errors.New("invalid time zone")
+	}
+
+	session.Title = dto.Title
+	session.Description = dto.Description
+	session.StartsAt = dto.StartsAt.UTC()
+	session.EndsAt = dto.EndsAt.UTC()
+	session.TimeZone = dto.TimeZone
+	session.Location = dto.Location
+	session.MeetingURL = dto.MeetingURL
+	session.RRule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(dto.RRule)), "RRULE:")
+
+	_, err = session.Recurrence()
+	if err != nil {
+		return errors.New("invalid recurrence rule")
+	}
+
+	return nil
+}
+
+// expandSessions lists the occurrences of the sessions within the query range, sorted by start time.
+func expandSessions(sessions []models.Session, query dto.ScheduleQuery) ([]dto.Occurrence, error) {
+	from, to := query.From, query.To
+	if from.IsZero() {
+		from = time.Now()
+	}
+
+	if to.IsZero() {
+		to = from.Add(defaultScheduleRange)
+	}
+
+	if !to.After(from) {
+		return nil, errors.New("schedule has to end after it starts")
+	}
+
+	if to.Sub(from) > maxScheduleRange {
+		return nil, errors.New("schedule can't span more than a year")
+	}
+
+	occurrences := []dto.Occurrence{}
+	for _, session := range sessions {
+		starts, err := session.Occurrences(from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, start := range starts {
+			occurrences = append(occurrences, dto.Occurrence{
+				SessionID:  session.ID,
+				TrainingID: session.TrainingID,
+				Title:      session.Title,
+				StartsAt:   start.UTC(),
+				EndsAt:     start.Add(session.Duration()).UTC(),
+				Location:   session.Location,
+				MeetingURL: session.MeetingURL,
+			})
+		}
+	}
+
+	sort.Slice(occurrences, func(i, j int) bool {
+		return occurrences[i].StartsAt.Before(occurrences[j].StartsAt)
+	})
+
+	return occurrences, nil
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+)
+
+const (
+	stripeSignatureHeader = "Stripe-Signature"
+	stripeTolerance       = 5 * time.Minute
+)
+
+// StripePaymentProvider uses Stripe Checkout sessions. Orders are paid
+// once Stripe reports their session completed through the webhook.
+type StripePaymentProvider struct {
+	apiUrl        string
+	apiKey        string
+	webhookSecret []byte
+	appUrl        string
+	client        *http.Client
+}
+
+func (p *StripePaymentProvider) Name() string {
+	return "stripe"
+}
+
+func (p *StripePaymentProvider) CreateCheckout(order models.Order, training models.Training, user models.User) (Checkout, error) {
+	form := url.Values{}
+	form.Set("mode", "payment")
+	form.Set("client_reference_id", order.ID)
+	form.Set("customer_email", user.Email)
+	form.Set("success_url", fmt.Sprintf("%s/orders/%s", p.appUrl, order.ID))
+	form.Set("cancel_url", fmt.Sprintf("%s/trainings/%s", p.appUrl, training.ID))
+	form.Set("line_items[0][quantity]", "1")
+	form.Set("line_items[0][price_data][currency]", order.Currency)
+	form.Set("line_items[0][price_data][unit_amount]", strconv.Itoa(order.Amount))
+	form.Set("line_items[0][price_data][product_data][name]", training.Name)
+	form.Set("metadata[order_id]", order.ID)
+
+	var session struct {
+		ID  string `json:"id"`
+		Url string `json:"url"`
+	}
+
+	// Retrying the checkout of the same order returns the session Stripe already created.
+	err := p.request(http.MethodPost, "/checkout/sessions", form, order.ID, &session)
+	if err != nil {
+		return Checkout{}, err
+	}
+
+	return Checkout{Reference: session.ID, Url: session.Url}, nil
+}
+
+// Refund refunds the payment of the checkout session of the order.
+func (p *StripePaymentProvider) Refund(order models.Order, amount int, key string) (string, error) {
+	var session struct {
+		PaymentIntent string `json:"payment_intent"`
+	}
+
+	err := p.request(http.MethodGet, "/checkout/sessions/"+url.PathEscape(order.ProviderRef), nil, "", &session)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("payment_intent", session.PaymentIntent)
+	form.Set("amount", strconv.Itoa(amount))
+	form.Set("metadata[order_id]", order.ID)
+
+	var refund struct {
+		ID string `json:"id"`
+	}
+
+	err = p.request(http.MethodPost, "/refunds", form, key, &refund)
+	if err != nil {
+		return "", err
+	}
+
+	return refund.ID, nil
+}
+
+// request calls the Stripe API and decodes its response into out. Requests with
+// an idempotency key are only applied once by Stripe, however often they are sent.
+func (p *StripePaymentProvider) request(method, path string, form url.Values, key string, out interface{}) error {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequest(method, p.apiUrl+path, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("stripe request %s %s failed with status %d", method, path, res.StatusCode)
+	}
+
+	return json.Unmarshal(data, out)
+}
+
+func (p *StripePaymentProvider) ParseWebhook(payload []byte, header http.Header) (PaymentEvent, error) {
+	var event PaymentEvent
+
+	err := p.verify(payload, header.Get(stripeSignatureHeader), time.Now())
+	if err != nil {
+		return event, err
+	}
+
+	var body struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Data struct {
+			Object struct {
+				ID            string `json:"id"`
+				PaymentStatus string `json:"payment_status"`
+			} `json:"object"`
+		} `json:"data"`
+	}
+
+	err = json.Unmarshal(payload, &body)
+	if err != nil {
+		return event, err
+	}
+
+	event = PaymentEvent{ID: body.ID, Reference: body.Data.Object.ID}
+
+	switch body.Type {
+	case "checkout.session.completed":
+		// Delayed payment methods complete the session before the money arrives.
+		if body.Data.Object.PaymentStatus == "paid" {
+			event.Type = PaymentSucceeded
+		}
+	case "checkout.session.async_payment_succeeded":
+		event.Type = PaymentSucceeded
+	case "checkout.session.async_payment_failed", "checkout.session.expired":
+		event.Type = PaymentFailed
+	}
+
+	return event, nil
+}
+
+// verify checks the Stripe-Signature header, an HMAC of the timestamp and the payload, and rejects old events.
+func (p *StripePaymentProvider) verify(payload []byte, header string, now time.Time) error {
+	var timestamp string
+	var signatures []string
+
+	for _, part := range strings.Split(header, ",") {
+		key, value, found := strings.Cut(part, "=")
+		if !found {
+			continue
+		}
+
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return errors.New("invalid webhook signature")
+	}
+
+	age := now.Sub(time.Unix(seconds, 0))
+	if age > stripeTolerance || age < -stripeTolerance {
+		return errors.New("webhook event is too old")
+	}
+
+	mac := hmac.New(sha256.New, p.webhookSecret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return errors.New("invalid webhook signature")
+}
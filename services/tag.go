@@ -0,0 +1,38 @@
+package services
+
+import (
+	"sync"
+
+	"github.com/Marcel-MD/xmas-faf-api/models"
+	"github.com/Marcel-MD/xmas-faf-api/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+type ITagService interface {
+	FindAll() []models.Tag
+}
+
+type TagService struct {
+	tagRepository repositories.ITagRepository
+}
+
+var (
+	tagOnce    sync.Once
+	tagService ITagService
+)
+
+func GetTagService() ITagService {
+	tagOnce.Do(func() {
+		log.Info().Msg("Initializing tag service")
+		tagService = &TagService{
+			tagRepository: repositories.GetTagRepository(),
+		}
+	})
+	return tagService
+}
+
+func (s *TagService) FindAll() []models.Tag {
+	log.Debug().Msg("Finding all tags")
+
+	return s.tagRepository.FindAll()
+}
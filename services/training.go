@@ -2,6 +2,8 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"html"
 	"sync"
 
 	"github.com/Marcel-MD/xmas-faf-api/dto"
@@ -12,19 +14,28 @@ import (
 )
 
 type ITrainingService interface {
-	FindAll() []models.Training
-	FindOne(id string) (models.Training, error)
+	FindAll(params dto.TrainingQueryParams) (dto.TrainingPage, error)
+	FindOne(id, userID string) (models.Training, error)
 	Create(dto dto.CreateTraining, userID string) (models.Training, error)
 	Update(trainingID, userID string, dto dto.UpdateTraining) (models.Training, error)
 	Delete(trainingID, userID string) error
-	AddUser(trainingID, addUserID, userID string) error
+	AddUser(trainingID, addUserID, userID string) (bool, error)
+	Enroll(trainingID, userID, role string) (bool, error)
 	RemoveUser(trainingID, removeUserID, userID string) error
+	FindWaitlist(trainingID, userID string) ([]models.WaitlistEntry, error)
+	LeaveWaitlist(trainingID, userID string) error
+	FindMembers(trainingID, userID string) ([]models.Member, error)
+	ChangeRole(trainingID, memberID, userID string, dto dto.ChangeMemberRole) (models.Member, error)
+	TransferOwnership(trainingID, newOwnerID, userID string) (models.Training, error)
 	VerifyUserInTraining(trainingID, userID string) error
 }
 
 type TrainingService struct {
 	trainingRepository repositories.ITrainingRepository
 	userRepository     repositories.IUserRepository
+	categoryRepository repositories.ICategoryRepository
+	tagRepository      repositories.ITagRepository
+	mailService        IMailService
 }
 
 var (
@@ -38,25 +49,66 @@ func GetTrainingService() ITrainingService {
 		trainingService = &TrainingService{
 			trainingRepository: repositories.GetTrainingRepository(),
 			userRepository:     repositories.GetUserRepository(),
+			categoryRepository: repositories.GetCategoryRepository(),
+			tagRepository:      repositories.GetTagRepository(),
+			mailService:        GetMailService(),
 		}
 	})
 	return trainingService
 }
 
-func (s *TrainingService) FindAll() []models.Training {
+func (s *TrainingService) FindAll(params dto.TrainingQueryParams) (dto.TrainingPage, error) {
 	log.Debug().Msg("Finding all trainings")
 
-	return s.trainingRepository.FindAll()
+	var result dto.TrainingPage
+
+	page, err := s.trainingRepository.FindAll(params)
+	if err != nil {
+		return result, err
+	}
+
+	facets, err := s.trainingRepository.CountFacets(params)
+	if err != nil {
+		return result, err
+	}
+
+	result.Page = page
+	result.Facets = facets
+
+	return result, nil
 }
 
-func (s *TrainingService) FindOne(id string) (models.Training, error) {
-	log.Debug().Str(logger.TrainingID, id).Msg("Finding training")
+func (s *TrainingService) FindOne(id, userID string) (models.Training, error) {
+	log.Debug().Str(logger.TrainingID, id).Str(logger.UserID, userID).Msg("Finding training")
 
 	training, err := s.trainingRepository.FindByIdWithUsers(id)
 	if err != nil {
 		return training, err
 	}
 
+	isMember, canManage := false, false
+	if userID != "" {
+		member, err := s.trainingRepository.FindMember(id, userID)
+		isMember, canManage = err == nil, err == nil && member.CanManage()
+	}
+
+	switch training.Visibility {
+	case models.DraftVisibility:
+		if !canManage {
+			return models.Training{}, errors.New("training not found")
+		}
+	case models.PrivateVisibility:
+		if !isMember {
+			return models.Training{}, errors.New("training not found")
+		}
+	}
+
+	// Members and posts are only visible from inside the training.
+	if !isMember {
+		training.Users = nil
+		training.Posts = nil
+	}
+
 	return training, nil
 }
 
@@ -68,12 +120,29 @@ func (s *TrainingService) Create(dto dto.CreateTraining, userID string) (models.
 		return models.Training{}, err
 	}
 
+	category, err := s.categoryRepository.FindByID(dto.CategoryID)
+	if err != nil {
+		return models.Training{}, errors.New("category not found")
+	}
+
 	training := models.Training{
-		Name:     dto.Name,
-		OwnerID:  userID,
-		Image:    dto.Image,
-		Price:    dto.Price,
-		Category: dto.Category,
+		Name:        dto.Name,
+		Description: dto.Description,
+		OwnerID:     userID,
+		Image:       dto.Image,
+		Price:       dto.Price,
+		CategoryID:  &category.ID,
+		Visibility:  dto.Visibility,
+		AutoApprove: dto.AutoApprove,
+		Capacity:    dto.Capacity,
+
+		RefundDays:        dto.RefundDays,
+		RefundPercent:     dto.RefundPercent,
+		RefundBeforeStart: dto.RefundBeforeStart,
+	}
+
+	if training.Visibility == "" {
+		training.Visibility = models.PublicVisibility
 	}
 
 	err = s.trainingRepository.Create(&training)
@@ -81,7 +150,12 @@ func (s *TrainingService) Create(dto dto.CreateTraining, userID string) (models.
 		return training, err
 	}
 
-	err = s.AddUser(training.ID, user.ID, userID)
+	err = s.setTags(&training, dto.Tags)
+	if err != nil {
+		return training, err
+	}
+
+	_, err = s.Enroll(training.ID, user.ID, models.OwnerMember)
 	if err != nil {
 		return training, err
 	}
@@ -97,20 +171,47 @@ func (s *TrainingService) Update(trainingID, userID string, dto dto.UpdateTraini
 		return training, err
 	}
 
-	if training.OwnerID != userID {
-		return training, errors.New("you are not the owner of this training")
+	err = s.verifyCanManage(trainingID, userID)
+	if err != nil {
+		return training, err
+	}
+
+	category, err := s.categoryRepository.FindByID(dto.CategoryID)
+	if err != nil {
+		return training, errors.New("category not found")
 	}
 
 	training.Name = dto.Name
+	training.Description = dto.Description
 	training.Price = dto.Price
-	training.Category = dto.Category
+	training.CategoryID = &category.ID
 	training.Image = dto.Image
+	training.AutoApprove = dto.AutoApprove
+	training.Capacity = dto.Capacity
+	training.RefundDays = dto.RefundDays
+	training.RefundPercent = dto.RefundPercent
+	training.RefundBeforeStart = dto.RefundBeforeStart
+
+	if dto.Visibility != "" {
+		training.Visibility = dto.Visibility
+	}
 
 	err = s.trainingRepository.Update(&training)
 	if err != nil {
 		return training, err
 	}
 
+	err = s.setTags(&training, dto.Tags)
+	if err != nil {
+		return training, err
+	}
+
+	// Raising the capacity frees spots for waitlisted users.
+	err = s.promoteWaitlist(training)
+	if err != nil {
+		return training, err
+	}
+
 	return training, nil
 }
 
@@ -134,34 +235,39 @@ func (s *TrainingService) Delete(trainingID, userID string) error {
 	return nil
 }
 
-func (s *TrainingService) AddUser(trainingID, addUserID, userID string) error {
+func (s *TrainingService) AddUser(trainingID, addUserID, userID string) (bool, error) {
 	log.Debug().Str(logger.TrainingID, trainingID).Msg("Adding user to training")
 
-	err := s.trainingRepository.VerifyUserInTraining(trainingID, addUserID)
-	if err == nil {
-		return errors.New("user already in this training")
-	}
-
-	training, err := s.trainingRepository.FindByID(trainingID)
+	err := s.verifyCanManage(trainingID, userID)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	if training.OwnerID != userID {
-		return errors.New("you are not the owner of this training")
+	return s.Enroll(trainingID, addUserID, models.StudentMember)
+}
+
+// Enroll adds a user to a training without checking who asked for it,
+// callers are responsible for authorizing the enrollment.
+// It returns true when the training is full and the user was put on the waitlist instead, they get the role once promoted.
+func (s *TrainingService) Enroll(trainingID, userID, role string) (bool, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Enrolling user in training")
+
+	err := s.trainingRepository.VerifyUserInTraining(trainingID, userID)
+	if err == nil {
+		return false, errors.New("user already in this training")
 	}
 
-	user, err := s.userRepository.FindByID(addUserID)
+	training, err := s.trainingRepository.FindByID(trainingID)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	err = s.trainingRepository.AddUser(&training, &user)
+	user, err := s.userRepository.FindByID(userID)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	return nil
+	return s.trainingRepository.Enroll(&training, &user, role)
 }
 
 func (s *TrainingService) RemoveUser(trainingID, removeUserID, userID string) error {
@@ -182,22 +288,179 @@ func (s *TrainingService) RemoveUser(trainingID, removeUserID, userID string) er
 		return err
 	}
 
-	if training.OwnerID != userID && removeUserID != userID {
-		return errors.New("unauthorized")
-	}
-
 	if training.OwnerID == removeUserID {
 		return errors.New("you are the owner of this training")
 	}
 
+	if removeUserID != userID {
+		err = s.verifyCanManage(trainingID, userID)
+		if err != nil {
+			return err
+		}
+
+		removed, err := s.trainingRepository.FindMember(trainingID, removeUserID)
+		if err != nil {
+			return err
+		}
+
+		if removed.Role == models.CoOwnerMember && training.OwnerID != userID {
+			return errors.New("only the owner can remove co-owners")
+		}
+	}
+
 	err = s.trainingRepository.RemoveUser(&training, &user)
 	if err != nil {
 		return err
 	}
 
+	return s.promoteWaitlist(training)
+}
+
+func (s *TrainingService) FindWaitlist(trainingID, userID string) ([]models.WaitlistEntry, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Finding waitlist")
+
+	err := s.verifyCanManage(trainingID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.trainingRepository.FindWaitlist(trainingID), nil
+}
+
+func (s *TrainingService) LeaveWaitlist(trainingID, userID string) error {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Leaving waitlist")
+
+	return s.trainingRepository.RemoveFromWaitlist(trainingID, userID)
+}
+
+func (s *TrainingService) FindMembers(trainingID, userID string) ([]models.Member, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Finding members")
+
+	err := s.trainingRepository.VerifyUserInTraining(trainingID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.trainingRepository.FindMembers(trainingID), nil
+}
+
+func (s *TrainingService) ChangeRole(trainingID, memberID, userID string, dto dto.ChangeMemberRole) (models.Member, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, memberID).Msg("Changing member role")
+
+	training, err := s.trainingRepository.FindByID(trainingID)
+	if err != nil {
+		return models.Member{}, err
+	}
+
+	err = s.verifyCanManage(trainingID, userID)
+	if err != nil {
+		return models.Member{}, err
+	}
+
+	member, err := s.trainingRepository.FindMember(trainingID, memberID)
+	if err != nil {
+		return member, errors.New("user is not in training")
+	}
+
+	if member.Role == models.OwnerMember {
+		return member, errors.New("use ownership transfer to change the owner")
+	}
+
+	if (member.Role == models.CoOwnerMember || dto.Role == models.CoOwnerMember) && training.OwnerID != userID {
+		return member, errors.New("only the owner can manage co-owners")
+	}
+
+	err = s.trainingRepository.UpdateMemberRole(trainingID, memberID, dto.Role)
+	if err != nil {
+		return member, err
+	}
+
+	member.Role = dto.Role
+
+	return member, nil
+}
+
+func (s *TrainingService) TransferOwnership(trainingID, newOwnerID, userID string) (models.Training, error) {
+	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, newOwnerID).Msg("Transferring training ownership")
+
+	training, err := s.trainingRepository.FindByID(trainingID)
+	if err != nil {
+		return training, err
+	}
+
+	if training.OwnerID != userID {
+		return training, errors.New("you are not the owner of this training")
+	}
+
+	if newOwnerID == userID {
+		return training, errors.New("you already own this training")
+	}
+
+	err = s.trainingRepository.VerifyUserInTraining(trainingID, newOwnerID)
+	if err != nil {
+		return training, err
+	}
+
+	err = s.trainingRepository.TransferOwnership(&training, newOwnerID)
+	if err != nil {
+		return training, err
+	}
+
+	training.OwnerID = newOwnerID
+
+	return training, nil
+}
+
+func (s *TrainingService) verifyCanManage(trainingID, userID string) error {
+	member, err := s.trainingRepository.FindMember(trainingID, userID)
+	if err != nil || !member.CanManage() {
+		return errors.New("you are not allowed to manage this training")
+	}
+
 	return nil
 }
 
+func (s *TrainingService) promoteWaitlist(training models.Training) error {
+	promoted, err := s.trainingRepository.PromoteWaitlist(&training)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range promoted {
+		log.Debug().Str(logger.TrainingID, training.ID).Str(logger.UserID, user.ID).Msg("Promoted user from waitlist")
+
+		go s.mailService.Send(Mail{
+			To:      []string{user.Email},
+			Subject: "Trainings - Spot Available",
+			Body:    fmt.Sprintf("A spot opened up in <strong>%s</strong> and you are now enrolled.", html.EscapeString(training.Name)),
+		})
+	}
+
+	return nil
+}
+
+func (s *TrainingService) setTags(training *models.Training, names []string) error {
+	unique := []string{}
+	seen := map[string]bool{}
+
+	for _, name := range names {
+		slug := models.Slugify(name)
+		if slug != "" && !seen[slug] {
+			seen[slug] = true
+			unique = append(unique, slug)
+		}
+	}
+
+	tags, err := s.tagRepository.FindOrCreate(unique)
+	if err != nil {
+		return err
+	}
+
+	training.Tags = tags
+
+	return s.trainingRepository.ReplaceTags(training, tags)
+}
+
 func (s *TrainingService) VerifyUserInTraining(trainingID, userID string) error {
 	log.Debug().Str(logger.TrainingID, trainingID).Str(logger.UserID, userID).Msg("Verifying user in training")
 	return s.trainingRepository.VerifyUserInTraining(trainingID, userID)
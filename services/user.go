@@ -14,9 +14,10 @@ import (
 )
 
 type IUserService interface {
-	FindAll() []models.User
+	FindAll(params dto.PaginationQuery) (dto.Page[models.User], error)
 	SearchByEmail(email string) []models.User
 	FindOne(id string) (models.User, error)
+	FindCurrent(id string) (models.User, error)
 	SendOtp(email string) error
 	RegisterOtp(dto dto.RegisterOtpUser) (models.User, error)
 	Register(dto dto.RegisterUser) (models.User, error)
@@ -26,13 +27,16 @@ type IUserService interface {
 	Update(dto dto.UpdateUser, id string) (models.User, error)
 	AddRole(id string, role string, userID string) (models.User, error)
 	RemoveRole(id string, role string, userID string) (models.User, error)
+	FindNotificationPreference(id string) models.NotificationPreference
+	UpdateNotificationPreference(dto dto.UpdateNotificationPreference, id string) (models.NotificationPreference, error)
 }
 
 type UserService struct {
-	repository          repositories.IUserRepository
-	otpService          IOtpService
-	mailService         IMailService
-	loginLimiterService ILoginLimiterService
+	repository             repositories.IUserRepository
+	notificationRepository repositories.INotificationRepository
+	otpService             IOtpService
+	mailService            IMailService
+	loginLimiterService    ILoginLimiterService
 }
 
 var (
@@ -44,19 +48,20 @@ func GetUserService() IUserService {
 	userOnce.Do(func() {
 		log.Info().Msg("Initializing user service")
 		userService = &UserService{
-			repository:          repositories.GetUserRepository(),
-			otpService:          GetOtpService(),
-			mailService:         GetMailService(),
-			loginLimiterService: GetLoginLimiterService(),
+			repository:             repositories.GetUserRepository(),
+			notificationRepository: repositories.GetNotificationRepository(),
+			otpService:             GetOtpService(),
+			mailService:            GetMailService(),
+			loginLimiterService:    GetLoginLimiterService(),
 		}
 	})
 	return userService
 }
 
-func (s *UserService) FindAll() []models.User {
+func (s *UserService) FindAll(params dto.PaginationQuery) (dto.Page[models.User], error) {
 	log.Debug().Msg("Finding all users")
 
-	return s.repository.FindAll()
+	return s.repository.FindAll(params)
 }
 
 func (s *UserService) SearchByEmail(email string) []models.User {
@@ -65,9 +70,22 @@ func (s *UserService) SearchByEmail(email string) []models.User {
 	return s.repository.SearchByEmail(email)
 }
 
+// FindOne finds a user for anyone to see, only their public trainings are listed.
 func (s *UserService) FindOne(id string) (models.User, error) {
 	log.Debug().Str("id", id).Msg("Finding user")
 
+	user, err := s.repository.FindByIdWithPublicTrainings(id)
+	if err != nil {
+		return user, err
+	}
+
+	return user, nil
+}
+
+// FindCurrent finds the signed in user with every training they are in.
+func (s *UserService) FindCurrent(id string) (models.User, error) {
+	log.Debug().Str("id", id).Msg("Finding current user")
+
 	user, err := s.repository.FindByIdWithTrainings(id)
 	if err != nil {
 		return user, err
@@ -105,9 +123,34 @@ func (s *UserService) RegisterOtp(dto dto.RegisterOtpUser) (models.User, error)
 		return user, err
 	}
 
+	// Accounts created for a roster import have no password yet, proving the email lets their owner set one.
+	user, err = s.repository.FindByEmail(dto.Email)
+	if err == nil && user.Password == "" {
+		return s.claim(user, dto.RegisterUser)
+	}
+
 	return s.Register(dto.RegisterUser)
 }
 
+// claim completes an account that was created without a password.
+func (s *UserService) claim(user models.User, dto dto.RegisterUser) (models.User, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(dto.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return user, err
+	}
+
+	user.FirstName = dto.FirstName
+	user.LastName = dto.LastName
+	user.Password = string(hashedPassword)
+
+	err = s.repository.Update(&user)
+	if err != nil {
+		return user, err
+	}
+
+	return user, nil
+}
+
 func (s *UserService) Register(dto dto.RegisterUser) (models.User, error) {
 	log.Debug().Msg("Registering user")
 
@@ -201,6 +244,30 @@ func (s *UserService) Update(dto dto.UpdateUser, id string) (models.User, error)
 	return user, nil
 }
 
+func (s *UserService) FindNotificationPreference(id string) models.NotificationPreference {
+	log.Debug().Msg("Finding notification preference")
+
+	return s.notificationRepository.FindByUserID(id)
+}
+
+func (s *UserService) UpdateNotificationPreference(dto dto.UpdateNotificationPreference, id string) (models.NotificationPreference, error) {
+	log.Debug().Msg("Updating notification preference")
+
+	preference := models.NotificationPreference{
+		UserID:       id,
+		DayReminder:  dto.DayReminder,
+		HourReminder: dto.HourReminder,
+		OwnerDigest:  dto.OwnerDigest,
+	}
+
+	err := s.notificationRepository.Save(&preference)
+	if err != nil {
+		return preference, err
+	}
+
+	return preference, nil
+}
+
 func (s *UserService) AddRole(id string, role string, userID string) (models.User, error) {
 	log.Debug().Msg("Adding role to user")
 